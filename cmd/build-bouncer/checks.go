@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/berniemackie97/build-bouncer/internal/ci"
+	"github.com/berniemackie97/build-bouncer/internal/cli"
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/importer"
+	"github.com/berniemackie97/build-bouncer/internal/migrate"
 )
 
 type mergeResult struct {
@@ -137,11 +144,12 @@ func stripManualPlaceholder(checks []config.Check) []config.Check {
 	return out
 }
 
-func stripCIChecks(checks []config.Check) ([]config.Check, int) {
+// stripCIChecks removes CI-generated checks; with a provider, only that provider's.
+func stripCIChecks(checks []config.Check, provider string) ([]config.Check, int) {
 	out := make([]config.Check, 0, len(checks))
 	removed := 0
 	for _, c := range checks {
-		if isCICheck(c) {
+		if isCICheck(c) && (provider == "" || ci.ProviderForCheck(c) == strings.ToLower(strings.TrimSpace(provider))) {
 			removed++
 			continue
 		}
@@ -150,11 +158,48 @@ func stripCIChecks(checks []config.Check) ([]config.Check, int) {
 	return out, removed
 }
 
-func isCICheck(c config.Check) bool {
-	if strings.HasPrefix(strings.TrimSpace(c.Name), "ci:") {
-		return true
+// stripImportedChecks removes checks created by `import` from the given sources, so a
+// re-import replaces them instead of piling up.
+func stripImportedChecks(checks []config.Check, sources map[string]bool) ([]config.Check, int) {
+	out := make([]config.Check, 0, len(checks))
+	removed := 0
+	for _, c := range checks {
+		if source := importer.SourceForCheck(c); source != "" && sources[source] {
+			removed++
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, removed
+}
+
+// hasPathFilters reports whether any check is limited by `paths:`, so the changed files
+// are only computed when something uses them.
+func hasPathFilters(cfg *config.Config) bool {
+	for _, c := range cfg.Checks {
+		if len(c.Paths) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stripMigratedChecks removes checks created by `migrate` from the given hook managers.
+func stripMigratedChecks(checks []config.Check, tools map[string]bool) ([]config.Check, int) {
+	out := make([]config.Check, 0, len(checks))
+	removed := 0
+	for _, c := range checks {
+		if tool := migrate.ToolForCheck(c); tool != "" && tools[tool] {
+			removed++
+			continue
+		}
+		out = append(out, c)
 	}
-	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(c.Source)), "ci")
+	return out, removed
+}
+
+func isCICheck(c config.Check) bool {
+	return ci.ProviderForCheck(c) != ""
 }
 
 func isManualPlaceholder(c config.Check) bool {
@@ -163,3 +208,47 @@ func isManualPlaceholder(c config.Check) bool {
 	}
 	return strings.Contains(c.Run, manualPlaceholderSnippet)
 }
+
+// chooseChecks asks about each generated check. defaults holds the answer used for an
+// empty reply (nil keeps everything by default); running out of input takes the
+// defaults for the rest.
+func chooseChecks(stdin io.Reader, ctx cli.Context, checks []config.Check, defaults []bool) ([]config.Check, error) {
+	keepByDefault := func(i int) bool { return defaults == nil || defaults[i] }
+
+	scanner := bufio.NewScanner(stdin)
+	var kept []config.Check
+	for i, check := range checks {
+		hint := "[Y/n]"
+		if !keepByDefault(i) {
+			hint = "[y/N]"
+		}
+		fmt.Fprintf(ctx.Stderr, "[%d/%d] %s\n  run: %s\n  Keep? %s: ", i+1, len(checks), check.Name, firstLine(check.Run), hint)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(ctx.Stderr)
+			for j := i; j < len(checks); j++ {
+				if keepByDefault(j) {
+					kept = append(kept, checks[j])
+				}
+			}
+			break
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "y", "yes":
+			kept = append(kept, check)
+		case "n", "no":
+		default:
+			if keepByDefault(i) {
+				kept = append(kept, check)
+			}
+		}
+	}
+	return kept, nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
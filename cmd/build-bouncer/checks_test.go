@@ -1,9 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/berniemackie97/build-bouncer/internal/cli"
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
 )
 
 func TestMergeChecksSkipsDuplicates(t *testing.T) {
@@ -38,3 +46,71 @@ func TestStripManualPlaceholder(t *testing.T) {
 		t.Fatalf("expected placeholder to be removed, got %+v", stripped)
 	}
 }
+
+func TestCheckAppliesPathFiltersOnlyInTheHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	gitCmd("init", "-q")
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "web"
+    shell: "sh"
+    run: "touch web-ran"
+    paths: ["web/**"]
+`,
+		"README.md": "docs\n",
+	})
+	gitCmd("add", "-A")
+	gitCmd("commit", "-q", "-m", "base")
+	base := gitCmd("rev-parse", "HEAD")
+	writeRepoFiles(t, repo, map[string]string{"README.md": "more docs\n"})
+	gitCmd("commit", "-q", "-am", "docs")
+	head := gitCmd("rev-parse", "HEAD")
+	t.Setenv(git.PushRefsEnv, "refs/heads/main "+head+" refs/heads/main "+base)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get cwd: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("chdir to repo: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+
+	marker := filepath.Join(repo, "web-ran")
+	for _, tc := range []struct {
+		args []string
+		runs bool
+	}{
+		{args: []string{"--hook"}, runs: false},
+		{args: []string{"--changed"}, runs: false},
+		{args: nil, runs: true},
+	} {
+		_ = os.Remove(marker)
+		var stdout, stderr bytes.Buffer
+		ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+		if code := runCheck(tc.args, ctx); code != exitOK {
+			t.Fatalf("check %v exit=%d\nstdout:\n%s\nstderr:\n%s", tc.args, code, stdout.String(), stderr.String())
+		}
+		if _, err := os.Stat(marker); (err == nil) != tc.runs {
+			t.Fatalf("check %v: expected the paths: check to run=%v", tc.args, tc.runs)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/berniemackie97/build-bouncer/internal/ci"
 	"github.com/berniemackie97/build-bouncer/internal/cli"
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/textdiff"
 )
 
 func newCICommand() cli.Command {
 	return cli.Command{
 		Name:    "ci",
-		Usage:   "ci <sync>",
+		Usage:   "ci <sync|export> [--provider NAME] [--dry-run] [--diff] [--interactive] [--check] [--expand] [--output PATH] [--force]",
 		Summary: "Manage CI-derived checks.",
 		Run: func(ctx cli.Context, args []string) int {
 			return runCI(args, ctx)
@@ -19,73 +25,224 @@ func newCICommand() cli.Command {
 	}
 }
 
+// ciSyncOptions controls how `ci sync` applies imported checks.
+type ciSyncOptions struct {
+	Provider string
+	// DryRun prints the diff without writing; Diff prints it and writes.
+	DryRun bool
+	Diff   bool
+	// Interactive asks about each imported check before keeping it.
+	Interactive bool
+	// Check writes nothing and fails when the config has drifted from CI.
+	Check bool
+	Stdin io.Reader
+}
+
 func runCI(args []string, ctx cli.Context) int {
 	if len(args) < 1 {
-		fmt.Fprintln(ctx.Stderr, "ci: missing subcommand (expected: sync)")
+		fmt.Fprintln(ctx.Stderr, "ci: missing subcommand (expected: sync, export)")
 		return exitUsage
 	}
 
 	switch args[0] {
 	case "sync":
 		fs := cli.NewFlagSet(ctx, "ci sync")
+		provider := fs.String("provider", "", "only sync one CI provider ("+strings.Join(ci.ProviderNames(), ", ")+")")
+		dryRun := fs.Bool("dry-run", false, "show the config diff without writing it")
+		diff := fs.Bool("diff", false, "show the config diff before writing it")
+		interactive := fs.Bool("interactive", false, "choose which imported checks to keep")
+		check := fs.Bool("check", false, "exit non-zero if the config is out of date with CI")
+		if err := fs.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if *check && *interactive {
+			fmt.Fprintln(ctx.Stderr, "ci sync: --check cannot be combined with --interactive")
+			return exitUsage
+		}
+		return runCISync(ciSyncOptions{
+			Provider:    *provider,
+			DryRun:      *dryRun,
+			Diff:        *diff,
+			Interactive: *interactive,
+			Check:       *check,
+			Stdin:       os.Stdin,
+		}, ctx)
+	case "export":
+		fs := cli.NewFlagSet(ctx, "ci export")
+		provider := fs.String("provider", "", "CI provider to render ("+strings.Join(ci.ExportProviders, ", ")+")")
+		expand := fs.Bool("expand", false, "emit one native step per check instead of running build-bouncer")
+		output := fs.String("output", "", "file to write, relative to the repo root (\"-\" for stdout)")
+		force := fs.Bool("force", false, "overwrite an existing file that was not generated by ci export")
 		if err := fs.Parse(args[1:]); err != nil {
 			return exitUsage
 		}
-		return runCISync(ctx)
+		return runCIExport(ciExportOptions{
+			Provider: *provider,
+			Expand:   *expand,
+			Output:   *output,
+			Force:    *force,
+		}, ctx)
 	default:
 		fmt.Fprintf(ctx.Stderr, "ci: unknown subcommand: %s\n", args[0])
 		return exitUsage
 	}
 }
 
-func runCISync(ctx cli.Context) int {
+func runCISync(opts ciSyncOptions, ctx cli.Context) int {
 	cfgPath, cfgDir, err := config.FindConfigFromCwd()
 	if err != nil {
 		fmt.Fprintln(ctx.Stderr, "ci sync:", err)
 		return exitUsage
 	}
 
-	cfg, err := config.Load(cfgPath)
+	// Edit only the local file; inherited (extends/include) checks stay where they are.
+	cfg, err := config.LoadFile(cfgPath)
 	if err != nil {
 		fmt.Fprintln(ctx.Stderr, "ci sync:", err)
 		return exitUsage
 	}
-
-	ciChecks, err := ci.ChecksFromGitHubActions(cfgDir)
+	before, err := config.Marshal(cfg)
 	if err != nil {
 		fmt.Fprintln(ctx.Stderr, "ci sync:", err)
 		return exitUsage
 	}
-	if len(ciChecks) == 0 {
-		fmt.Fprintln(ctx.Stdout, "No CI checks found in .github/workflows.")
-		return exitOK
-	}
 
+	ciChecks, err := importCIChecks(cfgDir, opts.Provider)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci sync:", err)
+		return exitUsage
+	}
 	ciChecks = stampGeneratedChecks(ciChecks, "ci")
 
-	mergedBase, removed := stripCIChecks(cfg.Checks)
-	mergedBase = stripManualPlaceholder(mergedBase)
+	// Strip even when nothing was imported: checks from a deleted workflow are stale too.
+	mergedBase, removed := stripCIChecks(cfg.Checks, opts.Provider)
+	if len(ciChecks) > 0 {
+		mergedBase = stripManualPlaceholder(mergedBase)
+	}
 
 	merge := mergeChecks(mergedBase, ciChecks)
+	if opts.Interactive && len(merge.Added) > 0 {
+		kept, err := chooseChecks(opts.Stdin, ctx, merge.Added, nil)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "ci sync:", err)
+			return exitUsage
+		}
+		merge = mergeChecks(mergedBase, kept)
+	}
 	cfg.Checks = merge.Merged
 
-	if err := config.Save(cfgPath, cfg); err != nil {
+	after, err := config.Marshal(cfg)
+	if err != nil {
 		fmt.Fprintln(ctx.Stderr, "ci sync:", err)
 		return exitUsage
 	}
 
+	if len(ciChecks) == 0 && bytes.Equal(before, after) {
+		if opts.Provider != "" {
+			fmt.Fprintf(ctx.Stdout, "No CI checks found for provider %s.\n", opts.Provider)
+		} else {
+			fmt.Fprintln(ctx.Stdout, "No CI checks found.")
+		}
+		return exitOK
+	}
+
+	if opts.Check {
+		if bytes.Equal(before, after) {
+			fmt.Fprintln(ctx.Stdout, "CI checks are up to date.")
+			return exitOK
+		}
+		fmt.Fprint(ctx.Stdout, configDiff(cfgPath, cfgDir, before, after))
+		fmt.Fprintln(ctx.Stderr, "ci sync: config is out of date with CI; run `build-bouncer ci sync` to update it")
+		return exitRunFailed
+	}
+
+	if opts.DryRun || opts.Diff {
+		// Diff against the file as written, so hand edits show up too.
+		current, err := os.ReadFile(cfgPath)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "ci sync:", err)
+			return exitUsage
+		}
+		if d := configDiff(cfgPath, cfgDir, current, after); d != "" {
+			fmt.Fprint(ctx.Stdout, d)
+		} else {
+			fmt.Fprintln(ctx.Stdout, "No changes to", displayPath(cfgDir, cfgPath))
+		}
+	}
+
+	if !opts.DryRun {
+		if len(cfg.Checks) == 0 && strings.TrimSpace(cfg.Extends) == "" && len(cfg.Include) == 0 {
+			fmt.Fprintln(ctx.Stderr, "ci sync: removing the stale CI checks would leave no checks; add one to", displayPath(cfgDir, cfgPath), "first")
+			return exitUsage
+		}
+		if err := config.Save(cfgPath, cfg); err != nil {
+			fmt.Fprintln(ctx.Stderr, "ci sync:", err)
+			return exitUsage
+		}
+	}
+
+	verb := "Added"
+	if opts.DryRun {
+		verb = "Would add"
+	}
 	if len(merge.Added) > 0 {
-		fmt.Fprintf(ctx.Stdout, "Added %d CI checks from .github/workflows\n", len(merge.Added))
+		fmt.Fprintf(ctx.Stdout, "%s %d CI checks\n", verb, len(merge.Added))
 	}
 	if len(merge.Skipped) > 0 {
 		fmt.Fprintf(ctx.Stdout, "Skipped %d duplicate CI checks\n", len(merge.Skipped))
 	}
 	if removed > 0 {
-		fmt.Fprintf(ctx.Stdout, "Removed %d stale CI checks\n", removed)
+		if opts.DryRun {
+			fmt.Fprintf(ctx.Stdout, "Would remove %d stale CI checks\n", removed)
+		} else {
+			fmt.Fprintf(ctx.Stdout, "Removed %d stale CI checks\n", removed)
+		}
 	}
 	if len(merge.Added) == 0 {
 		fmt.Fprintln(ctx.Stdout, "No new CI checks to add.")
 	}
+	if opts.DryRun {
+		fmt.Fprintln(ctx.Stdout, "Dry run: config not written.")
+	}
 
 	return exitOK
 }
+
+func configDiff(cfgPath string, cfgDir string, before []byte, after []byte) string {
+	name := displayPath(cfgDir, cfgPath)
+	return textdiff.Unified("a/"+name, "b/"+name, string(before), string(after), textdiff.DefaultContext)
+}
+
+// displayPath shows path relative to the repo root when possible.
+func displayPath(root string, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// importCIChecks collects checks from every detected CI provider, or only from the
+// named one.
+func importCIChecks(root string, only string) ([]config.Check, error) {
+	selected := ci.Providers()
+	if strings.TrimSpace(only) != "" {
+		provider, ok := ci.LookupProvider(only)
+		if !ok {
+			return nil, fmt.Errorf("unknown CI provider %q (available: %s)", only, strings.Join(ci.ProviderNames(), ", "))
+		}
+		selected = []ci.Provider{provider}
+	}
+
+	var checks []config.Check
+	for _, provider := range selected {
+		if !provider.Detect(root) {
+			continue
+		}
+		found, err := provider.Checks(root)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, found...)
+	}
+	return checks, nil
+}
@@ -54,7 +54,7 @@ jobs:
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
-	if code := runCISync(ctx); code != exitOK {
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
 		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
 	}
 
@@ -114,7 +114,7 @@ jobs:
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
-	if code := runCISync(ctx); code != exitOK {
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
 		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
 	}
 
@@ -135,3 +135,400 @@ jobs:
 		}
 	}
 }
+
+func TestCISyncImportsGitLabCI(t *testing.T) {
+	repo := withTempRepo(t)
+
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{
+			{Name: "lint", Run: "go vet ./..."},
+			{Name: "ci:gitlab:old", Run: "echo stale", Source: "gitlab"},
+		},
+	}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	pipeline := `
+test:
+  script:
+    - go test ./...
+`
+	if err := os.WriteFile(filepath.Join(repo, ".gitlab-ci.yml"), []byte(pipeline), 0o644); err != nil {
+		t.Fatalf("write pipeline: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+
+	updated, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(updated.Checks) != 2 || updated.Checks[1].Name != "ci:gitlab:test" || updated.Checks[1].Source != "gitlab" {
+		t.Fatalf("expected stale gitlab check replaced, got %+v", updated.Checks)
+	}
+	if !strings.Contains(stdout.String(), "Removed 1 stale CI checks") {
+		t.Fatalf("expected stale removal message, got %q", stdout.String())
+	}
+}
+
+func TestCISyncProviderOnlyReplacesThatProvider(t *testing.T) {
+	repo := withTempRepo(t)
+
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{
+			{Name: "ci:ci:build:old", Run: "echo github", Source: "ci:ci"},
+			{Name: "ci:bitbucket:default:old", Run: "echo stale", Source: "bitbucket"},
+		},
+	}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	pipeline := `
+pipelines:
+  default:
+    - step:
+        name: Test
+        script:
+          - go test ./...
+`
+	if err := os.WriteFile(filepath.Join(repo, "bitbucket-pipelines.yml"), []byte(pipeline), 0o644); err != nil {
+		t.Fatalf("write pipeline: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{Provider: "bitbucket"}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+
+	updated, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(updated.Checks) != 2 || updated.Checks[0].Name != "ci:ci:build:old" || updated.Checks[1].Name != "ci:bitbucket:default:test" {
+		t.Fatalf("expected only bitbucket checks replaced, got %+v", updated.Checks)
+	}
+
+	if code := runCISync(ciSyncOptions{Provider: "jenkins"}, ctx); code != exitUsage {
+		t.Fatalf("expected unknown provider to fail, got %d", code)
+	}
+}
+
+func TestStampGeneratedChecksSharesMatrixIDStem(t *testing.T) {
+	checks := stampGeneratedChecks([]config.Check{
+		{Name: "ci:test:test:go (go=1.21)", Run: "go test", MatrixParent: "ci:test:test:go", MatrixValues: map[string]string{"go": "1.21"}},
+		{Name: "ci:test:test:go (go=1.22)", Run: "go test -race", MatrixParent: "ci:test:test:go", MatrixValues: map[string]string{"go": "1.22"}},
+	}, "ci")
+
+	first, second := checks[0].ID, checks[1].ID
+	if !strings.HasSuffix(first, "[go=1.21]") || !strings.HasSuffix(second, "[go=1.22]") {
+		t.Fatalf("expected combination suffixes, got %q and %q", first, second)
+	}
+	if strings.TrimSuffix(first, "[go=1.21]") != strings.TrimSuffix(second, "[go=1.22]") {
+		t.Fatalf("expected shared ID stem, got %q and %q", first, second)
+	}
+}
+
+func setupCISyncRepo(t *testing.T, workflow string) string {
+	t.Helper()
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	cfg := &config.Config{
+		Version: 1,
+		Checks:  []config.Check{{Name: "tests", Run: "go test ./..."}},
+	}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	workflowDir := filepath.Join(repo, ".github", "workflows")
+	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+		t.Fatalf("create workflows dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workflowDir, "ci.yml"), []byte(workflow), 0o644); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+	return cfgPath
+}
+
+const twoStepWorkflow = `
+jobs:
+  build:
+    steps:
+      - name: lint
+        run: go vet ./...
+      - name: build
+        run: go build ./...
+`
+
+func TestCISyncDryRunPrintsDiffWithoutWriting(t *testing.T) {
+	cfgPath := setupCISyncRepo(t, twoStepWorkflow)
+	original, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{DryRun: true}, ctx); code != exitOK {
+		t.Fatalf("ci sync --dry-run exit=%d stderr=%q", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"--- a/.buildbouncer/config.yaml", "name: ci:ci:build:lint", "Would add 2 CI checks", "Dry run"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	after, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !bytes.Equal(original, after) {
+		t.Fatalf("expected dry run to leave config untouched")
+	}
+}
+
+func TestCISyncCheckReportsDrift(t *testing.T) {
+	setupCISyncRepo(t, twoStepWorkflow)
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{Check: true}, ctx); code != exitRunFailed {
+		t.Fatalf("expected drift exit, got %d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "out of date") {
+		t.Fatalf("expected drift message, got %q", stderr.String())
+	}
+
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := runCISync(ciSyncOptions{Check: true}, ctx); code != exitOK {
+		t.Fatalf("expected synced config to pass --check, got %d stdout=%q", code, stdout.String())
+	}
+}
+
+func TestCISyncDropsGitHubExpressionsFromEnvAndCwd(t *testing.T) {
+	cfgPath := setupCISyncRepo(t, `
+jobs:
+  build:
+    env:
+      GOFLAGS: -mod=readonly
+      SHA: ${{ github.sha }}
+    steps:
+      - name: test
+        working-directory: ${{ github.workspace }}/app
+        env:
+          TOKEN: ${{ secrets.GITHUB_TOKEN }}
+          VERSION: ${{ steps.meta.outputs.version }}
+        run: go test ./...
+`)
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+	updated, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load synced config: %v", err)
+	}
+	var imported *config.Check
+	for i := range updated.Checks {
+		if strings.HasPrefix(updated.Checks[i].Name, "ci:") {
+			imported = &updated.Checks[i]
+		}
+	}
+	if imported == nil {
+		t.Fatalf("expected an imported check, got %+v", updated.Checks)
+	}
+	if len(imported.Env) != 1 || imported.Env["GOFLAGS"] != "-mod=readonly" {
+		t.Fatalf("expected only literal env to survive, got %v", imported.Env)
+	}
+	if imported.Cwd != "app" {
+		t.Fatalf("expected cwd app, got %q", imported.Cwd)
+	}
+}
+
+func TestCISyncCheckFlagsChecksFromDeletedWorkflows(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{
+			{Name: "tests", Run: "go test ./..."},
+			{Name: "ci:ci:build:lint", Run: "go vet ./...", Source: "ci:ci"},
+		},
+	}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCISync(ciSyncOptions{Check: true}, ctx); code != exitRunFailed {
+		t.Fatalf("expected drift exit for a deleted workflow, got %d stdout=%q", code, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "-      name: ci:ci:build:lint") {
+		t.Fatalf("expected the stale check in the diff, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Removed 1 stale CI checks") {
+		t.Fatalf("expected the stale check removed, got %q", stdout.String())
+	}
+	stdout.Reset()
+	if code := runCISync(ciSyncOptions{Check: true}, ctx); code != exitOK || !strings.Contains(stdout.String(), "No CI checks found.") {
+		t.Fatalf("expected a clean --check, got %d stdout=%q", code, stdout.String())
+	}
+}
+
+func TestCISyncInteractiveKeepsChosenChecks(t *testing.T) {
+	cfgPath := setupCISyncRepo(t, twoStepWorkflow)
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	opts := ciSyncOptions{Interactive: true, Stdin: strings.NewReader("n\n\n")}
+	if code := runCISync(opts, ctx); code != exitOK {
+		t.Fatalf("ci sync --interactive exit=%d stderr=%q", code, stderr.String())
+	}
+
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	var names []string
+	for _, c := range cfg.Checks {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "tests,ci:ci:build:build" {
+		t.Fatalf("expected declined lint check to be dropped, got %v", names)
+	}
+}
+
+func TestCIExportIsIdempotentAndProtectsHandWrittenFiles(t *testing.T) {
+	cfgPath := setupCISyncRepo(t, twoStepWorkflow)
+	repo := filepath.Dir(filepath.Dir(cfgPath))
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCIExport(ciExportOptions{Provider: "github"}, ctx); code != exitOK {
+		t.Fatalf("ci export exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Wrote: .github/workflows/build-bouncer.yml") {
+		t.Fatalf("expected write message, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runCIExport(ciExportOptions{Provider: "github"}, ctx); code != exitOK {
+		t.Fatalf("ci export exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Up to date") {
+		t.Fatalf("expected re-export to be a no-op, got %q", stdout.String())
+	}
+
+	// The exported workflow must not be imported back by ci sync.
+	if code := runCISync(ciSyncOptions{}, ctx); code != exitOK {
+		t.Fatalf("ci sync exit=%d stderr=%q", code, stderr.String())
+	}
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 3 {
+		t.Fatalf("expected tests plus two workflow checks, got %+v", cfg.Checks)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, ".gitlab-ci.yml"), []byte("test:\n  script: [make]\n"), 0o644); err != nil {
+		t.Fatalf("write gitlab ci: %v", err)
+	}
+	stderr.Reset()
+	if code := runCIExport(ciExportOptions{Provider: "gitlab"}, ctx); code != exitUsage {
+		t.Fatalf("expected refusal to overwrite hand-written .gitlab-ci.yml, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "not generated by build-bouncer") {
+		t.Fatalf("unexpected error: %q", stderr.String())
+	}
+}
+
+func TestCIExportExpandWarnsAboutBuiltinChecks(t *testing.T) {
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "tests"
+    run: "go test ./..."
+  - name: "secrets"
+    builtin: "secrets"
+`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runCIExport(ciExportOptions{Provider: "github", Expand: true, Output: "-"}, ctx); code != exitOK {
+		t.Fatalf("ci export exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `leaves out built-in check "secrets"`) {
+		t.Fatalf("expected a warning about the built-in check, got %q", stderr.String())
+	}
+	if strings.Contains(stderr.String(), `"tests"`) || !strings.Contains(stdout.String(), "go test ./...") {
+		t.Fatalf("expected only the built-in check left out\nstdout:\n%s\nstderr:\n%s", stdout.String(), stderr.String())
+	}
+}
+
+func TestCIExportIgnoresLocalOverlay(t *testing.T) {
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "tests"
+    run: "go test ./..."
+`,
+		".buildbouncer/config.local.yaml": `
+checks:
+  - name: "mine"
+    run: "./scripts/my-lint.sh"
+profiles:
+  ci:
+    checks: [mine]
+`,
+	})
+
+	for _, expand := range []bool{false, true} {
+		var stdout, stderr bytes.Buffer
+		ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+		if code := runCIExport(ciExportOptions{Provider: "github", Expand: expand, Output: "-"}, ctx); code != exitOK {
+			t.Fatalf("ci export (expand=%v) exit=%d stderr=%q", expand, code, stderr.String())
+		}
+		out := stdout.String()
+		if strings.Contains(out, "my-lint") || strings.Contains(out, "--profile") {
+			t.Fatalf("expected the local overlay left out of the export (expand=%v):\n%s", expand, out)
+		}
+		if expand && !strings.Contains(out, "go test ./...") {
+			t.Fatalf("expected the team check in the expanded export:\n%s", out)
+		}
+	}
+}
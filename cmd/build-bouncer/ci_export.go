@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/ci"
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+type ciExportOptions struct {
+	Provider string
+	Expand   bool
+	// Output overrides the provider's default file; "-" writes to stdout.
+	Output string
+	Force  bool
+}
+
+func runCIExport(opts ciExportOptions, ctx cli.Context) int {
+	provider := strings.ToLower(strings.TrimSpace(opts.Provider))
+	if provider == "" {
+		fmt.Fprintf(ctx.Stderr, "ci export: --provider is required (%s)\n", strings.Join(ci.ExportProviders, ", "))
+		return exitUsage
+	}
+
+	cfgPath, cfgDir, err := config.FindConfigFromCwd()
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+	// The pipeline is committed, so a developer's config.local.yaml must not leak into it.
+	cfg, err := config.LoadTeam(cfgPath)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+
+	exportOpts := ci.ExportOptions{Version: version, Expand: opts.Expand}
+	if strings.Contains(version, "dev") {
+		exportOpts.Version = "latest"
+		fmt.Fprintln(ctx.Stderr, "ci export: development build; the workflow installs build-bouncer@latest")
+	}
+	if cfg.HasProfile("ci") {
+		exportOpts.Profile = "ci"
+		if opts.Expand {
+			if cfg, err = cfg.ApplyProfile("ci"); err != nil {
+				fmt.Fprintln(ctx.Stderr, "ci export:", err)
+				return exitUsage
+			}
+		}
+	}
+
+	data, err := ci.Export(provider, cfg, exportOpts)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+	if opts.Expand {
+		for _, name := range ci.OmittedBuiltins(cfg) {
+			fmt.Fprintf(ctx.Stderr, "ci export: --expand leaves out built-in check %q; it needs build-bouncer itself (export without --expand to keep it)\n", name)
+		}
+	}
+
+	if opts.Output == "-" {
+		_, _ = ctx.Stdout.Write(data)
+		return exitOK
+	}
+
+	rel := opts.Output
+	if strings.TrimSpace(rel) == "" {
+		rel = ci.DefaultExportPath(provider)
+	}
+	path := rel
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfgDir, filepath.FromSlash(rel))
+	}
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		fmt.Fprintln(ctx.Stdout, "Up to date:", displayPath(cfgDir, path))
+		return exitOK
+	case err == nil && !ci.IsGenerated(existing) && !opts.Force:
+		fmt.Fprintf(ctx.Stderr, "ci export: %s exists and was not generated by build-bouncer (use --force or --output)\n", displayPath(cfgDir, path))
+		return exitUsage
+	case err != nil && !os.IsNotExist(err):
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		fmt.Fprintln(ctx.Stderr, "ci export:", err)
+		return exitUsage
+	}
+	fmt.Fprintln(ctx.Stdout, "Wrote:", displayPath(cfgDir, path))
+	return exitOK
+}
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/berniemackie97/build-bouncer/internal/ci"
 	"github.com/berniemackie97/build-bouncer/internal/cli"
 	"github.com/berniemackie97/build-bouncer/internal/config"
 
@@ -24,9 +24,13 @@ func registerCommands(app *cli.App) {
 	app.Register(newSetupCommand())
 	app.Register(newInitCommand())
 	app.Register(newCheckCommand())
+	app.Register(newFixCommand())
+	app.Register(newRerunCommand())
 	app.Register(newValidateCommand())
 	app.Register(newDoctorCommand())
 	app.Register(newCICommand())
+	app.Register(newImportCommand())
+	app.Register(newMigrateCommand())
 	app.Register(newHookCommand())
 	app.Register(newUninstallCommand())
 }
@@ -34,18 +38,19 @@ func registerCommands(app *cli.App) {
 func newSetupCommand() cli.Command {
 	return cli.Command{
 		Name:    "setup",
-		Usage:   "setup [--force] [--no-copy] [--ci] [--template-flag]",
+		Usage:   "setup [--force] [--no-copy] [--ci] [--auto | --template-flag]",
 		Summary: "Init config/packs, install hook, then run checks.",
 		Run: func(ctx cli.Context, args []string) int {
 			fs := cli.NewFlagSet(ctx, "setup")
 			force := fs.Bool("force", false, "overwrite default insult/banter packs")
 			noCopy := fs.Bool("no-copy", false, "do not copy the build-bouncer binary into .git/hooks/bin")
 			ci := fs.Bool("ci", false, "CI mode")
+			auto := fs.Bool("auto", false, "detect projects in the repo and combine their templates")
 			selector := registerTemplateFlags(fs)
 			if err := fs.Parse(args); err != nil {
 				return exitUsage
 			}
-			templateID, err := selector.Selected()
+			templateID, err := selectTemplate(selector, *auto)
 			if err != nil {
 				fmt.Fprintln(ctx.Stderr, "setup:", err)
 				printInitHelp(ctx)
@@ -75,7 +80,7 @@ func runSetup(force bool, noCopy bool, ci bool, templateID string, ctx cli.Conte
 		}
 	} else {
 		if templateID == "" {
-			fmt.Fprintln(ctx.Stderr, "setup: missing template flag (try: build-bouncer setup --go)")
+			fmt.Fprintln(ctx.Stderr, "setup: missing template flag (try: build-bouncer setup --go or --auto)")
 			printInitHelp(ctx)
 			return exitUsage
 		}
@@ -104,16 +109,22 @@ func runSetup(force bool, noCopy bool, ci bool, templateID string, ctx cli.Conte
 func newInitCommand() cli.Command {
 	return cli.Command{
 		Name:    "init",
-		Usage:   "init [--force] [--template-flag]",
+		Usage:   "init [--force] [--auto | --template-flag] | init --list",
 		Summary: "Create .buildbouncer/config.yaml and default insult/banter packs.",
 		Run: func(ctx cli.Context, args []string) int {
 			fs := cli.NewFlagSet(ctx, "init")
 			force := fs.Bool("force", false, "overwrite default insult/banter packs")
+			auto := fs.Bool("auto", false, "detect projects in the repo and combine their templates")
+			list := fs.Bool("list", false, "list templates and where each comes from")
 			selector := registerTemplateFlags(fs)
 			if err := fs.Parse(args); err != nil {
 				return exitUsage
 			}
-			templateID, err := selector.Selected()
+			if *list {
+				printTemplateList(ctx)
+				return exitOK
+			}
+			templateID, err := selectTemplate(selector, *auto)
 			if err != nil {
 				fmt.Fprintln(ctx.Stderr, "init:", err)
 				printInitHelp(ctx)
@@ -158,39 +169,35 @@ func runInit(force bool, templateID string, ctx cli.Context) int {
 		return exitUsage
 	}
 
-	tmpl, ok := findConfigTemplate(templateID)
-	if !ok {
-		fmt.Fprintln(ctx.Stderr, "init: unknown template:", templateID)
-		printInitHelp(ctx)
-		return exitUsage
-	}
-
-	templateBytes, err := loadTemplateBytes(root, tmpl.File)
-	if err != nil {
-		fmt.Fprintln(ctx.Stderr, "init:", err)
-		return exitUsage
-	}
-
-	ciChecks, err := ci.ChecksFromGitHubActions(root)
-	if err != nil {
-		fmt.Fprintln(ctx.Stderr, "init:", err)
-		return exitUsage
+	var cfg *config.Config
+	var projects []detectedProject
+	if templateID == autoTemplateID {
+		cfg, projects, err = autoDetectedConfig(root)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "init:", err)
+			if errors.Is(err, errNoProjectsDetected) {
+				printInitHelp(ctx)
+			}
+			return exitUsage
+		}
+	} else {
+		if _, ok := findConfigTemplate(templateID); !ok {
+			fmt.Fprintln(ctx.Stderr, "init: unknown template:", templateID)
+			printInitHelp(ctx)
+			return exitUsage
+		}
+		cfg, err = templateConfig(root, detectedProject{TemplateID: templateID}, "")
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "init:", err)
+			return exitUsage
+		}
 	}
 
-	cfg, err := config.Parse(templateBytes)
+	ciChecks, err := importCIChecks(root, "")
 	if err != nil {
 		fmt.Fprintln(ctx.Stderr, "init:", err)
 		return exitUsage
 	}
-	adjust := applyTemplateOverrides(root, templateID, cfg.Checks)
-	cfg.Checks = adjust.Checks
-	cfg.Meta.Template.ID = templateID
-	cfg.Meta.Inputs = mergeInputs(cfg.Meta.Inputs, adjust.Inputs)
-	templateSource := "template:" + templateID
-	if strings.TrimSpace(adjust.Source) != "" {
-		templateSource = adjust.Source
-	}
-	cfg.Checks = stampGeneratedChecks(cfg.Checks, templateSource)
 	if templateID == "manual" && len(ciChecks) > 0 {
 		cfg.Checks = stripManualPlaceholder(cfg.Checks)
 	}
@@ -201,16 +208,31 @@ func runInit(force bool, templateID string, ctx cli.Context) int {
 		fmt.Fprintln(ctx.Stderr, "init:", err)
 		return exitUsage
 	}
+	ignoredLocal, err := ensureGitignoreEntry(root, config.ConfigDirName+"/"+config.LocalConfigName)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "init:", err)
+		return exitUsage
+	}
 	if len(merge.Added) > 0 {
-		fmt.Fprintf(ctx.Stdout, "Added %d CI checks from .github/workflows\n", len(merge.Added))
+		fmt.Fprintf(ctx.Stdout, "Added %d CI checks\n", len(merge.Added))
 	}
 	if len(merge.Skipped) > 0 {
 		fmt.Fprintf(ctx.Stdout, "Skipped %d duplicate CI checks\n", len(merge.Skipped))
 	}
 
+	for _, p := range projects {
+		dir := p.Dir
+		if dir == "" {
+			dir = "."
+		}
+		fmt.Fprintf(ctx.Stdout, "Detected %s in %s\n", p.TemplateID, dir)
+	}
 	fmt.Fprintln(ctx.Stdout, "Created:", cfgPath)
 	fmt.Fprintln(ctx.Stdout, tui.Check("Insults: "+insultsPath))
 	fmt.Fprintln(ctx.Stdout, tui.Check("Banter: "+banterPath))
+	if ignoredLocal {
+		fmt.Fprintln(ctx.Stdout, tui.Check("Ignored: "+config.ConfigDirName+"/"+config.LocalConfigName+" (in .gitignore)"))
+	}
 	fmt.Fprintln(ctx.Stdout, "")
 	fmt.Fprintln(ctx.Stdout, tui.Info("Next: build-bouncer hook install"))
 	return exitOK
@@ -219,7 +241,7 @@ func runInit(force bool, templateID string, ctx cli.Context) int {
 func newCheckCommand() cli.Command {
 	return cli.Command{
 		Name:    "check",
-		Usage:   "check [--ci] [--verbose] [--hook] [--log-dir DIR] [--tail N] [--parallel N] [--fail-fast] [--force-push]",
+		Usage:   "check [--ci] [--verbose] [--hook] [--profile NAME] [--log-dir DIR] [--tail N] [--parallel N] [--fail-fast] [--force-push] [--report-json FILE]",
 		Summary: "Run configured checks.",
 		Run: func(ctx cli.Context, args []string) int {
 			return runCheck(args, ctx)
@@ -237,6 +259,9 @@ func runCheck(args []string, ctx cli.Context) int {
 	parallel := fs.Int("parallel", 0, "max concurrent checks (default: 1 or config)")
 	failFast := fs.Bool("fail-fast", false, "cancel remaining checks on first failure")
 	forcePush := fs.Bool("force-push", false, "bypass all checks and allow push (from git push --force)")
+	profile := fs.String("profile", "", "config profile to apply (default: \"ci\" with --ci, \"hook\" with --hook, when defined)")
+	reportJSON := fs.String("report-json", "", "also write the report (failures, failed tests, diagnostics) as JSON to FILE")
+	changed := fs.Bool("changed", false, "skip checks whose paths: match none of the changed files (always on with --hook)")
 	if err := fs.Parse(args); err != nil {
 		return exitUsage
 	}
@@ -261,6 +286,18 @@ func runCheck(args []string, ctx cli.Context) int {
 		return exitUsage
 	}
 
+	profileName := resolveProfileName(cfg, *profile, *ci, *hook)
+	if profileName != "" {
+		cfg, err = cfg.ApplyProfile(profileName)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "check:", err)
+			return exitUsage
+		}
+		if *verbose || *ci {
+			fmt.Fprintln(ctx.Stdout, "Profile:", profileName)
+		}
+	}
+
 	quietUI := !*verbose && !*ci && (*hook || ui.IsTerminal(os.Stdout))
 	banterEnabled := cfg.Banter.Enabled == nil || *cfg.Banter.Enabled
 
@@ -295,7 +332,7 @@ func runCheck(args []string, ctx cli.Context) int {
 		Verbose:     *verbose || *ci,
 		LogDir:      *logDir,
 		MaxParallel: cfg.Runner.MaxParallel,
-		FailFast:    cfg.Runner.FailFast || *failFast,
+		FailFast:    cfg.Runner.IsFailFast() || *failFast,
 		Progress: func(e runner.ProgressEvent) {
 			if sp == nil {
 				return
@@ -316,6 +353,16 @@ func runCheck(args []string, ctx cli.Context) int {
 			}
 		},
 	}
+	if !*ci {
+		opts.PushRefs = os.Getenv(git.PushRefsEnv)
+		// A manual check runs everything it is asked to; only the hook (or --changed)
+		// narrows paths: checks to what is being pushed.
+		if (*hook || *changed) && hasPathFilters(cfg) {
+			if changed, ok := git.ChangedFiles(cfgDir, opts.PushRefs); ok {
+				opts.ChangedFiles = changed
+			}
+		}
+	}
 	if *parallel > 0 {
 		opts.MaxParallel = *parallel
 	}
@@ -333,6 +380,17 @@ func runCheck(args []string, ctx cli.Context) int {
 		return exitUsage
 	}
 
+	// The last report is what `rerun` repeats; failing to record it is not worth failing
+	// the check over.
+	rep.Profile = profileName
+	_ = runner.WriteReportFile(runner.LastReportPath(cfgDir), rep)
+	if path := strings.TrimSpace(*reportJSON); path != "" {
+		if err := runner.WriteReportFile(path, rep); err != nil {
+			fmt.Fprintln(ctx.Stderr, "check:", err)
+			return exitUsage
+		}
+	}
+
 	if len(rep.Failures) > 0 {
 		// Determine if we should block based on protection level
 		protectionLevel := cfg.Protection.ProtectionLevel()
@@ -367,7 +425,10 @@ func runCheck(args []string, ctx cli.Context) int {
 
 		if *verbose {
 			for _, f := range rep.Failures {
-				reason := strings.TrimSpace(runner.ExtractWhy(f, rep.FailureTails[f]))
+				reason := diagnosticLines(rep.FailureDiagnostics[f])
+				if reason == "" {
+					reason = strings.TrimSpace(runner.ExtractWhy(f, rep.FailureTails[f]))
+				}
 				if reason == "" {
 					reason = strings.TrimSpace(rep.FailureHeadlines[f])
 				}
@@ -380,6 +441,13 @@ func runCheck(args []string, ctx cli.Context) int {
 						fmt.Fprintln(ctx.Stderr, "  "+line)
 					}
 				}
+				if tests := rep.FailedTests[f]; len(tests) > 0 {
+					fmt.Fprintln(ctx.Stderr, "")
+					fmt.Fprintln(ctx.Stderr, tui.Dim(fmt.Sprintf("  Failed tests (%d):", len(tests))))
+					for _, line := range prompt.FailedTestLines(tests, verboseFailedTests) {
+						fmt.Fprintln(ctx.Stderr, "  "+line)
+					}
+				}
 				if *tail > 0 {
 					tailText := runner.TailLines(rep.FailureTails[f], *tail)
 					if strings.TrimSpace(tailText) != "" {
@@ -426,13 +494,29 @@ func runCheck(args []string, ctx cli.Context) int {
 		// Interactive override prompt (only in hook mode during git push)
 		// Skip prompt in CI mode, manual mode, or if terminal is not available
 		if *hook && !*ci && cfg.Protection.IsInteractive() && ui.IsTerminal(os.Stdin) {
-			result, err := prompt.AskOverride(os.Stdin, ctx.Stdout, ctx.Stderr, rep, protectionLevel)
+			fixable, fixableNames := checksWithFix(cfg, rep.Failures)
+			result, err := prompt.AskOverride(os.Stdin, ctx.Stdout, ctx.Stderr, rep, protectionLevel, fixableNames)
 			if err != nil {
 				fmt.Fprintln(ctx.Stderr, "")
 				fmt.Fprintln(ctx.Stderr, "Error reading input:", err)
 				return exitRunFailed
 			}
 
+			if result.Fix != "" {
+				// Amending anything but the pushed commit would rewrite an unrelated one.
+				if result.Fix == prompt.FixAmend && !git.PushesHead(cfgDir, opts.PushRefs) {
+					fmt.Fprintln(ctx.Stderr, "fix: not amending: HEAD is not the commit being pushed (choose commit instead)")
+					return exitRunFailed
+				}
+				// git has already decided which commits this push sends, so the fix can
+				// only go out with the next push.
+				if fixChecks(ctx, cfgDir, fixable, result.Fix, false) == exitOK {
+					fmt.Fprintln(ctx.Stdout, "")
+					fmt.Fprintln(ctx.Stdout, tui.Info("Push again to include the fixes."))
+				}
+				return exitRunFailed
+			}
+
 			if result.Override {
 				fmt.Fprintln(ctx.Stdout, "")
 				fmt.Fprintln(ctx.Stdout, tui.Success("✓ Override accepted - Push proceeding"))
@@ -468,6 +552,41 @@ func runCheck(args []string, ctx cli.Context) int {
 	return exitOK
 }
 
+// resolveProfileName picks the profile to apply. An explicit --profile wins;
+// otherwise --ci and --hook fall back to profiles named "ci" and "hook" when they exist.
+func resolveProfileName(cfg *config.Config, explicit string, ci bool, hook bool) string {
+	if name := strings.TrimSpace(explicit); name != "" {
+		return name
+	}
+	switch {
+	case ci && cfg.HasProfile("ci"):
+		return "ci"
+	case hook && cfg.HasProfile("hook"):
+		return "hook"
+	default:
+		return ""
+	}
+}
+
+// verboseDiagnostics caps the parser diagnostics listed per failing check.
+const verboseDiagnostics = 10
+
+// verboseFailedTests caps the failed tests listed per failing check.
+const verboseFailedTests = 25
+
+// diagnosticLines lists a check's parser diagnostics, one per line.
+func diagnosticLines(diagnostics []runner.Diagnostic) string {
+	lines := make([]string, 0, verboseDiagnostics+1)
+	for i, diagnostic := range diagnostics {
+		if i == verboseDiagnostics {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(diagnostics)-i))
+			break
+		}
+		lines = append(lines, diagnostic.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
 func newHookCommand() cli.Command {
 	return cli.Command{
 		Name:    "hook",
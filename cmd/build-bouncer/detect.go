@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// autoTemplateID stands in for a template flag when `init --auto` composes several.
+const autoTemplateID = "auto"
+
+var errNoProjectsDetected = errors.New("no projects detected; pick a template instead")
+
+// autoDetectMaxDepth bounds how far below the repo root `init --auto` looks for projects.
+const autoDetectMaxDepth = 4
+
+// autoDetectSkipDirs are never scanned: dependencies, build output, fixtures.
+var autoDetectSkipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "build": true, "dist": true,
+	"out": true, "bin": true, "obj": true, "testdata": true, "third_party": true,
+	"__pycache__": true, "venv": true, "bower_components": true, "Pods": true,
+}
+
+// detectedProject is one template found in one directory. Dir is slash-separated and
+// relative to the repo root ("" for the root itself).
+type detectedProject struct {
+	Dir        string
+	TemplateID string
+}
+
+// detectProjects finds every directory with a template's marker files, shallowest first.
+// A project nested inside one of the same kind that already covers it (a Cargo
+// workspace, a .sln, a multi-project Gradle build, npm workspaces, ...) is left out.
+func detectProjects(root string) []detectedProject {
+	var found []detectedProject
+	templates := listConfigTemplates()
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		} else {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || autoDetectSkipDirs[name] {
+				return filepath.SkipDir
+			}
+			if strings.Count(rel, "/") >= autoDetectMaxDepth {
+				return filepath.SkipDir
+			}
+			// Nested repositories (submodules, vendored checkouts) get their own config.
+			if _, err := os.Stat(filepath.Join(p, ".git")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+
+		entries, readErr := os.ReadDir(p)
+		if readErr != nil {
+			return nil
+		}
+		for _, id := range templatesInDir(p, entries, templates) {
+			if coveredByAncestor(root, found, rel, id) {
+				continue
+			}
+			found = append(found, detectedProject{Dir: rel, TemplateID: id})
+		}
+		return nil
+	})
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return dirDepth(found[i].Dir) < dirDepth(found[j].Dir)
+	})
+	return found
+}
+
+func dirDepth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// templatesInDir returns the templates whose markers are in dir, refined to the most
+// specific variant (react over node, android over gradle, flutter over dart).
+func templatesInDir(dir string, entries []fs.DirEntry, templates []configTemplate) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, tmpl := range templates {
+		if !hasMarker(entries, tmpl.Markers) {
+			continue
+		}
+		id := refineDetectedTemplate(dir, tmpl.ID)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	// A Deno project's package.json is for npm compatibility, not a second project.
+	if seen["deno"] {
+		ids = removeNodeFamily(ids)
+	}
+	return ids
+}
+
+func hasMarker(entries []fs.DirEntry, markers []string) bool {
+	for _, marker := range markers {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if ok, _ := path.Match(marker, entry.Name()); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// refineDetectedTemplate picks the variant a marker really means, or "" when the marker
+// turns out to be something else.
+func refineDetectedTemplate(dir string, id string) string {
+	switch id {
+	case "node":
+		return nodeFrameworkTemplate(filepath.Join(dir, "package.json"))
+	case "gradle":
+		for _, name := range []string{"build.gradle", "build.gradle.kts", "app/build.gradle", "app/build.gradle.kts"} {
+			if strings.Contains(readFileString(filepath.Join(dir, filepath.FromSlash(name))), "com.android") {
+				return "android"
+			}
+		}
+		if fileExists(filepath.Join(dir, "build.gradle.kts")) || fileExists(filepath.Join(dir, "settings.gradle.kts")) {
+			return "kotlin"
+		}
+	case "dart":
+		if strings.Contains(readFileString(filepath.Join(dir, "pubspec.yaml")), "flutter:") {
+			return "flutter"
+		}
+	case "r":
+		// DESCRIPTION is a common file name; R packages start it with "Package:".
+		if !strings.HasPrefix(strings.TrimSpace(readFileString(filepath.Join(dir, "DESCRIPTION"))), "Package:") {
+			return ""
+		}
+	}
+	return id
+}
+
+// nodeFrameworkTemplate maps package.json dependencies to the matching node template.
+func nodeFrameworkTemplate(pkgPath string) string {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(pkgPath)), &pkg); err != nil {
+		return "node"
+	}
+	has := func(name string) bool {
+		_, dep := pkg.Dependencies[name]
+		_, dev := pkg.DevDependencies[name]
+		return dep || dev
+	}
+	switch {
+	case has("next"):
+		return "nextjs"
+	case has("nuxt"):
+		return "nuxt"
+	case has("astro"):
+		return "astro"
+	case has("@angular/core"):
+		return "angular"
+	case has("@sveltejs/kit") || has("svelte"):
+		return "svelte"
+	case has("vue"):
+		return "vue"
+	case has("react"):
+		return "react"
+	}
+	return "node"
+}
+
+// templateFamily groups variants with the template whose markers found them.
+func templateFamily(id string) string {
+	switch id {
+	case "react", "vue", "angular", "svelte", "nextjs", "nuxt", "astro":
+		return "node"
+	case "kotlin", "android":
+		return "gradle"
+	case "flutter":
+		return "dart"
+	}
+	return id
+}
+
+func removeNodeFamily(ids []string) []string {
+	out := ids[:0]
+	for _, id := range ids {
+		if templateFamily(id) != "node" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// coveredByAncestor reports whether a project of the same family above dir already
+// builds and tests it. Go modules and Python projects never cover nested ones (they are
+// separate units); Cargo and npm only with an explicit workspace; build systems that
+// aggregate subprojects (.sln, Maven, Gradle, CMake, Terraform modules, ...) always do.
+func coveredByAncestor(root string, found []detectedProject, dir string, id string) bool {
+	family := templateFamily(id)
+	for _, p := range found {
+		if templateFamily(p.TemplateID) != family || !isAncestorDir(p.Dir, dir) {
+			continue
+		}
+		ancestor := filepath.Join(root, filepath.FromSlash(p.Dir))
+		switch family {
+		case "go", "python":
+			continue
+		case "rust":
+			if strings.Contains(readFileString(filepath.Join(ancestor, "Cargo.toml")), "[workspace]") {
+				return true
+			}
+		case "node":
+			if fileExists(filepath.Join(ancestor, "pnpm-workspace.yaml")) || strings.Contains(readFileString(filepath.Join(ancestor, "package.json")), `"workspaces"`) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func isAncestorDir(ancestor string, dir string) bool {
+	if ancestor == dir {
+		return false
+	}
+	return ancestor == "" || strings.HasPrefix(dir, ancestor+"/")
+}
+
+// templateConfig loads a template and adapts it to the project in p.Dir: detectors run
+// there, checks get it as cwd, and names get prefix (when set) so projects never clash.
+func templateConfig(root string, p detectedProject, prefix string) (*config.Config, error) {
+	tmpl, ok := findConfigTemplate(p.TemplateID)
+	if !ok {
+		return nil, fmt.Errorf("unknown template: %s", p.TemplateID)
+	}
+	templateBytes, err := tmpl.load(root)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Parse(templateBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// User templates are used as written; detectors only tailor the embedded ones.
+	adjust := templateAdjustResult{Checks: cfg.Checks}
+	if tmpl.Origin == embeddedTemplateOrigin {
+		adjust = applyTemplateOverrides(filepath.Join(root, filepath.FromSlash(p.Dir)), p.TemplateID, cfg.Checks)
+	}
+	checks := adjust.Checks
+	for i := range checks {
+		if p.Dir != "" {
+			checks[i].Cwd = path.Join(p.Dir, checks[i].Cwd)
+		}
+		if prefix != "" {
+			checks[i].Name = prefix + ":" + checks[i].Name
+		}
+	}
+	templateSource := "template:" + p.TemplateID
+	if strings.TrimSpace(adjust.Source) != "" {
+		templateSource = adjust.Source
+	}
+	cfg.Checks = stampGeneratedChecks(checks, templateSource)
+	cfg.Meta.Template.ID = p.TemplateID
+	cfg.Meta.Inputs = mergeInputs(cfg.Meta.Inputs, prefixedInputs(adjust.Inputs, prefix))
+	return cfg, nil
+}
+
+func prefixedInputs(inputs map[string]string, prefix string) map[string]string {
+	if prefix == "" {
+		return inputs
+	}
+	out := make(map[string]string, len(inputs))
+	for k, v := range inputs {
+		out[prefix+":"+k] = v
+	}
+	return out
+}
+
+// autoDetectedConfig composes one template per detected project. Settings other than
+// checks come from the first (shallowest) project's template.
+func autoDetectedConfig(root string) (*config.Config, []detectedProject, error) {
+	projects := detectProjects(root)
+	if len(projects) == 0 {
+		return nil, nil, errNoProjectsDetected
+	}
+	perDir := map[string]int{}
+	for _, p := range projects {
+		perDir[p.Dir]++
+	}
+
+	var out *config.Config
+	for _, p := range projects {
+		cfg, err := templateConfig(root, p, autoCheckPrefix(p, perDir[p.Dir] > 1))
+		if err != nil {
+			return nil, nil, err
+		}
+		if out == nil {
+			out = cfg
+			continue
+		}
+		out.Checks = append(out.Checks, cfg.Checks...)
+		out.Meta.Inputs = mergeInputs(out.Meta.Inputs, cfg.Meta.Inputs)
+	}
+	if len(projects) > 1 {
+		out.Meta.Template.ID = autoTemplateID
+	}
+	return out, projects, nil
+}
+
+// autoCheckPrefix names a project's checks after its directory ("web:lint"), adding the
+// template when a directory holds several ("api:go:tests"); a lone root project keeps
+// the template's plain names.
+func autoCheckPrefix(p detectedProject, sharedDir bool) string {
+	switch {
+	case p.Dir == "" && sharedDir:
+		return p.TemplateID
+	case p.Dir == "":
+		return ""
+	case sharedDir:
+		return p.Dir + ":" + p.TemplateID
+	default:
+		return p.Dir
+	}
+}
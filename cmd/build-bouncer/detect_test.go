@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func writeRepoFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+}
+
+func TestDetectProjectsFindsPolyglotLayout(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFiles(t, root, map[string]string{
+		"services/api/go.mod":             "module example.com/api\n",
+		"services/worker/go.mod":          "module example.com/worker\n",
+		"web/package.json":                `{"dependencies": {"react": "^18"}}`,
+		"web/node_modules/x/package.json": `{}`,
+		"infra/main.tf":                   "terraform {}\n",
+		"infra/modules/vpc/main.tf":       "variable \"cidr\" {}\n",
+		"tools/Cargo.toml":                "[workspace]\nmembers = [\"cli\"]\n",
+		"tools/cli/Cargo.toml":            "[package]\nname = \"cli\"\n",
+		"docs/DESCRIPTION":                "Just some notes\n",
+		".github/package.json":            `{}`,
+	})
+
+	var got []string
+	for _, p := range detectProjects(root) {
+		got = append(got, p.TemplateID+"@"+p.Dir)
+	}
+	want := "terraform@infra, rust@tools, react@web, go@services/api, go@services/worker"
+	if strings.Join(got, ", ") != want {
+		t.Fatalf("detected %q, want %q", strings.Join(got, ", "), want)
+	}
+}
+
+func TestDetectProjectsRefinesVariants(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFiles(t, root, map[string]string{
+		"mobile/pubspec.yaml":   "name: app\nflutter:\n  uses-material-design: true\n",
+		"android/build.gradle":  "apply plugin: 'com.android.application'\n",
+		"site/package.json":     `{"devDependencies": {"astro": "^4"}}`,
+		"edge/deno.json":        `{}`,
+		"edge/package.json":     `{}`,
+		"backend/pom.xml":       "<project/>\n",
+		"backend/core/pom.xml":  "<project/>\n",
+		"package.json":          `{"workspaces": ["site"]}`,
+		"site/nested/README.md": "",
+	})
+
+	var got []string
+	for _, p := range detectProjects(root) {
+		got = append(got, p.TemplateID+"@"+p.Dir)
+	}
+	want := "node@, android@android, maven@backend, deno@edge, flutter@mobile"
+	if strings.Join(got, ", ") != want {
+		t.Fatalf("detected %q, want %q", strings.Join(got, ", "), want)
+	}
+}
+
+func TestInitAutoComposesTemplatesPerProject(t *testing.T) {
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		"go.mod":           "module example.com/app\n",
+		"web/package.json": `{"dependencies": {"react": "^18"}, "scripts": {"lint": "eslint .", "test": "vitest"}}`,
+		"infra/main.tf":    "terraform {}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runInit(false, autoTemplateID, cli.Context{Stdout: &stdout, Stderr: &stderr}); code != exitOK {
+		t.Fatalf("init --auto exit=%d stderr=%q", code, stderr.String())
+	}
+	for _, want := range []string{"Detected go in .", "Detected react in web", "Detected terraform in infra"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	cfg, err := config.LoadFile(config.DefaultConfigPath(repo))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	var got []string
+	for _, c := range cfg.Checks {
+		got = append(got, c.Name+"@"+c.Cwd)
+	}
+	want := "tests@, lint@, infra:format@infra, infra:validate@infra, web:lint@web, web:tests@web"
+	if strings.Join(got, ", ") != want {
+		t.Fatalf("checks %q, want %q", strings.Join(got, ", "), want)
+	}
+	if cfg.Meta.Template.ID != autoTemplateID {
+		t.Fatalf("expected meta.template.id auto, got %q", cfg.Meta.Template.ID)
+	}
+	if cfg.Meta.Inputs["web:node.runner"] != "npm" {
+		t.Fatalf("expected prefixed node inputs, got %v", cfg.Meta.Inputs)
+	}
+}
+
+func TestSelectTemplateRejectsAutoWithTemplateFlag(t *testing.T) {
+	var stderr bytes.Buffer
+	fs := cli.NewFlagSet(cli.Context{Stdout: &stderr, Stderr: &stderr}, "init")
+	sel := registerTemplateFlags(fs)
+	if err := fs.Parse([]string{"--go"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := selectTemplate(sel, true); err == nil {
+		t.Fatal("expected --auto with --go to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/berniemackie97/build-bouncer/internal/cli"
@@ -39,7 +40,7 @@ func runDoctor(cfgPath string, ctx cli.Context) int {
 			return exitUsage
 		}
 	} else {
-		cfgDir = filepath.Dir(cfgPath)
+		cfgDir = config.RootDir(cfgPath)
 	}
 
 	cfg, err := config.Load(cfgPath)
@@ -53,7 +54,13 @@ func runDoctor(cfgPath string, ctx cli.Context) int {
 	fmt.Fprintln(ctx.Stdout, "OS:", runtime.GOOS)
 	fmt.Fprintln(ctx.Stdout, "PATH:", os.Getenv("PATH"))
 
-	for i, check := range cfg.Checks {
+	interpolation := runner.InterpolationFor(cfgDir)
+	for i, raw := range cfg.Checks {
+		check, err := cfg.ExpandCheck(raw, interpolation)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "doctor:", err)
+			return exitUsage
+		}
 		fmt.Fprintln(ctx.Stdout, "")
 		fmt.Fprintf(ctx.Stdout, "[%d] %s\n", i+1, check.Name)
 		if strings.TrimSpace(check.ID) != "" {
@@ -62,9 +69,48 @@ func runDoctor(cfgPath string, ctx cli.Context) int {
 		if strings.TrimSpace(check.Source) != "" {
 			fmt.Fprintln(ctx.Stdout, "  source:", check.Source)
 		}
-		fmt.Fprintln(ctx.Stdout, "  run:", check.Run)
-		fmt.Fprintln(ctx.Stdout, "  shell:", resolvedShell(check))
+		if strings.TrimSpace(check.Note) != "" {
+			fmt.Fprintln(ctx.Stdout, "  note:", check.Note)
+		}
+		if check.Builtin != "" {
+			fmt.Fprintln(ctx.Stdout, "  builtin:", check.Builtin)
+			for _, line := range formatWith(check.With) {
+				fmt.Fprintln(ctx.Stdout, "   ", line)
+			}
+		} else {
+			fmt.Fprintln(ctx.Stdout, "  run:", raw.Run)
+			if check.Run != raw.Run {
+				fmt.Fprintln(ctx.Stdout, "  expanded:", check.Run)
+			}
+			fmt.Fprintln(ctx.Stdout, "  shell:", resolvedShell(check))
+		}
+		if strings.TrimSpace(check.Fix) != "" {
+			fmt.Fprintln(ctx.Stdout, "  fix:", check.Fix)
+		}
+		if parsers := cfg.ParsersFor(check); len(parsers) > 0 {
+			names := make([]string, 0, len(parsers))
+			for _, parser := range parsers {
+				names = append(names, parser.Name)
+			}
+			fmt.Fprintln(ctx.Stdout, "  parsers:", strings.Join(names, ", "))
+		}
+		if len(check.ProblemMatchers) > 0 {
+			fmt.Fprintln(ctx.Stdout, "  problemMatchers:", strings.Join(check.ProblemMatchers, ", "))
+		}
+		if check.Output != nil {
+			output := check.Output.Format
+			if check.Output.File != "" {
+				output += " (" + check.Output.File + ")"
+			}
+			fmt.Fprintln(ctx.Stdout, "  output:", output)
+		}
+		if check.Rerun != "" {
+			fmt.Fprintln(ctx.Stdout, "  rerun:", check.Rerun)
+		}
 		fmt.Fprintln(ctx.Stdout, "  cwd:", resolvedCwd(cfgDir, check.Cwd))
+		if len(check.Paths) > 0 {
+			fmt.Fprintln(ctx.Stdout, "  paths:", strings.Join(check.Paths, ", "))
+		}
 		if len(check.OS) > 0 {
 			fmt.Fprintln(ctx.Stdout, "  os:", strings.Join(check.OS, ","))
 		}
@@ -95,3 +141,17 @@ func resolvedCwd(root string, cwd string) string {
 	}
 	return filepath.Join(root, filepath.FromSlash(cwd))
 }
+
+// formatWith renders a builtin check's options as sorted "key: value" lines.
+func formatWith(with map[string]any) []string {
+	keys := make([]string, 0, len(with))
+	for k := range with {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", k, with[k]))
+	}
+	return lines
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+)
+
+func TestDoctorWithConfigFlagUsesRepoRoot(t *testing.T) {
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "build"
+    run: "make -C ${{ repo.root }}"
+    cwd: "app"
+`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runDoctor(filepath.Join(repo, ".buildbouncer", "config.yaml"), ctx); code != exitOK {
+		t.Fatalf("doctor exit=%d stderr=%q", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"Repo: " + repo + "\n",
+		"expanded: make -C " + repo + "\n",
+		"cwd: " + filepath.Join(repo, "app") + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
+	"github.com/berniemackie97/build-bouncer/internal/prompt"
+	"github.com/berniemackie97/build-bouncer/internal/runner"
+	"github.com/berniemackie97/build-bouncer/internal/tui"
+)
+
+func newFixCommand() cli.Command {
+	return cli.Command{
+		Name:    "fix",
+		Usage:   "fix [--commit | --amend] [--verbose] [--profile NAME] [CHECK...]",
+		Summary: "Run the fix: commands of failing (or named) checks, then re-run them.",
+		Run: func(ctx cli.Context, args []string) int {
+			return runFix(args, ctx)
+		},
+	}
+}
+
+func runFix(args []string, ctx cli.Context) int {
+	fs := cli.NewFlagSet(ctx, "fix")
+	commit := fs.Bool("commit", false, "commit the files the fixers changed")
+	amend := fs.Bool("amend", false, "amend HEAD with the files the fixers changed")
+	verbose := fs.Bool("verbose", false, "show fixer output even when they succeed")
+	profile := fs.String("profile", "", "config profile to apply")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *commit && *amend {
+		fmt.Fprintln(ctx.Stderr, "fix: use --commit or --amend, not both")
+		return exitUsage
+	}
+	mode := ""
+	switch {
+	case *commit:
+		mode = prompt.FixCommit
+	case *amend:
+		mode = prompt.FixAmend
+	}
+
+	cfgPath, cfgDir, err := config.FindConfigFromCwd()
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "fix:", err)
+		return exitUsage
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "fix:", err)
+		return exitUsage
+	}
+	if name := strings.TrimSpace(*profile); name != "" {
+		if cfg, err = cfg.ApplyProfile(name); err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+	}
+
+	var targets *config.Config
+	if fs.NArg() > 0 {
+		if targets, err = cfg.SelectChecks(fs.Args()); err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+	} else {
+		// Without names, fix whatever is failing. Only checks with a fixer can be fixed,
+		// so only they need to run to find out.
+		fixable, names := checksWithFix(cfg, nil)
+		if len(names) == 0 {
+			fmt.Fprintln(ctx.Stderr, "fix: no checks have a fix: command")
+			return exitUsage
+		}
+		fmt.Fprintln(ctx.Stdout, tui.Info("Running checks that have a fixer: "+strings.Join(names, ", ")))
+		rep, err := runner.RunAllReport(cfgDir, fixable, runner.Options{MaxParallel: cfg.Runner.MaxParallel})
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+		if len(rep.Failures) == 0 {
+			fmt.Fprintln(ctx.Stdout, tui.Success("✓ Nothing to fix"))
+			return exitOK
+		}
+		if targets, err = fixable.SelectChecks(rep.Failures); err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+	}
+
+	return fixChecks(ctx, cfgDir, targets, mode, *verbose)
+}
+
+// checksWithFix narrows cfg to the checks that have a `fix:` command, optionally only
+// those named in failures, and returns their names.
+func checksWithFix(cfg *config.Config, failures []string) (*config.Config, []string) {
+	failed := map[string]bool{}
+	for _, name := range failures {
+		failed[name] = true
+	}
+	out := *cfg
+	out.Checks = nil
+	var names []string
+	for _, check := range cfg.Checks {
+		if strings.TrimSpace(check.Fix) == "" || (failures != nil && !failed[check.Name]) {
+			continue
+		}
+		out.Checks = append(out.Checks, check)
+		names = append(names, check.Name)
+	}
+	return &out, names
+}
+
+// fixChecks runs the fixers of cfg's checks, commits or amends what they changed when
+// mode asks for it, and re-runs the checks. It returns exitOK once they all pass.
+func fixChecks(ctx cli.Context, cfgDir string, cfg *config.Config, mode string, verbose bool) int {
+	var snapshot *git.WorktreeSnapshot
+	if mode != "" {
+		var err error
+		if snapshot, err = git.SnapshotWorktree(cfgDir); err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+	}
+
+	var fixerOutput bytes.Buffer
+	output := io.Writer(&fixerOutput)
+	if verbose {
+		output = ctx.Stdout
+	}
+	results, err := runner.RunFixers(cfgDir, cfg, output)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "fix:", err)
+		return exitUsage
+	}
+
+	fmt.Fprintln(ctx.Stdout, "")
+	fmt.Fprintln(ctx.Stdout, tui.Section("Fixers"))
+	var fixed []string
+	anyFailed := false
+	for _, result := range results {
+		switch {
+		case result.Skipped != "":
+			fmt.Fprintf(ctx.Stdout, "%s %s\n", tui.Bullet(result.Check), tui.Dim("("+result.Skipped+")"))
+		case result.Err != nil:
+			anyFailed = true
+			fmt.Fprintln(ctx.Stdout, tui.Cross(fmt.Sprintf("%s: %v", result.Check, result.Err)))
+		case result.ExitCode != 0:
+			anyFailed = true
+			fmt.Fprintln(ctx.Stdout, tui.Cross(fmt.Sprintf("%s (exit %d)", result.Check, result.ExitCode)))
+		default:
+			fixed = append(fixed, result.Check)
+			fmt.Fprintln(ctx.Stdout, tui.Check(result.Check))
+		}
+	}
+	if anyFailed && !verbose && fixerOutput.Len() > 0 {
+		fmt.Fprintln(ctx.Stdout, "")
+		fmt.Fprint(ctx.Stdout, fixerOutput.String())
+	}
+
+	if snapshot != nil && len(fixed) > 0 {
+		changes, err := snapshot.Changes()
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "fix:", err)
+			return exitUsage
+		}
+		files := changes.Files()
+		switch {
+		case len(changes.Edited) > 0:
+			// Committing a file commits all of it, including work that was there before.
+			fmt.Fprintf(ctx.Stderr, "fix: not committing: %s already had uncommitted changes; commit or stash them and run fix again (the fixes are left in the working tree)\n", strings.Join(changes.Edited, ", "))
+			return exitRunFailed
+		case len(files) == 0:
+			fmt.Fprintln(ctx.Stdout, tui.Info("The fixers changed no files; nothing to commit"))
+		default:
+			head, err := git.CommitFiles(cfgDir, files, "Apply fixers: "+strings.Join(fixed, ", "), mode == prompt.FixAmend)
+			if err != nil {
+				fmt.Fprintln(ctx.Stderr, "fix:", err)
+				return exitRunFailed
+			}
+			verb := "Committed"
+			if mode == prompt.FixAmend {
+				verb = "Amended HEAD with"
+			}
+			count := fmt.Sprintf("%d files", len(files))
+			if len(files) == 1 {
+				count = "1 file"
+			}
+			fmt.Fprintln(ctx.Stdout, tui.Success(fmt.Sprintf("✓ %s %s (%s)", verb, count, shortCommit(head))))
+		}
+	}
+
+	rep, err := runner.RunAllReport(cfgDir, cfg, runner.Options{MaxParallel: cfg.Runner.MaxParallel})
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "fix:", err)
+		return exitUsage
+	}
+	fmt.Fprintln(ctx.Stdout, "")
+	if len(rep.Failures) > 0 {
+		fmt.Fprintln(ctx.Stdout, tui.Section("Still failing"))
+		for _, name := range rep.Failures {
+			fmt.Fprintln(ctx.Stdout, tui.Cross(name))
+			if headline := strings.TrimSpace(rep.FailureHeadlines[name]); headline != "" {
+				fmt.Fprintln(ctx.Stdout, tui.Dim("    "+headline))
+			}
+		}
+		return exitRunFailed
+	}
+	fmt.Fprintln(ctx.Stdout, tui.Success("✓ Fixed checks pass"))
+	return exitOK
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+)
+
+const fixConfig = `
+version: 1
+checks:
+  - name: "fmt"
+    shell: "sh"
+    run: "grep -q tidy code.txt"
+    fix: "echo tidy > code.txt"
+  - name: "lint"
+    shell: "sh"
+    run: "true"
+`
+
+func TestFixRunsFixersForFailingChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": fixConfig,
+		"code.txt":                  "messy\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runFix(nil, ctx); code != exitOK {
+		t.Fatalf("fix exit=%d\nstdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "code.txt")); string(data) != "tidy\n" {
+		t.Fatalf("fixer did not run, code.txt = %q", data)
+	}
+	if out := stdout.String(); !strings.Contains(out, "Running checks that have a fixer: fmt") || !strings.Contains(out, "Fixed checks pass") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	stdout.Reset()
+	if code := runFix(nil, ctx); code != exitOK || !strings.Contains(stdout.String(), "Nothing to fix") {
+		t.Fatalf("expected nothing to fix, exit=%d:\n%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	if code := runFix([]string{"nope"}, ctx); code != exitUsage || !strings.Contains(stderr.String(), `unknown check "nope"`) {
+		t.Fatalf("expected unknown check error, exit=%d stderr=%q", code, stderr.String())
+	}
+}
+
+func TestFixCommitsWhatTheFixersChanged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": fixConfig,
+		"code.txt":                  "messy\n",
+		"notes.txt":                 "committed\n",
+	})
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	writeRepoFiles(t, repo, map[string]string{"notes.txt": "uncommitted work\n"})
+
+	// CommitFiles runs plain git, so the identity has to come from the environment.
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	if code := runFix([]string{"--commit", "fmt"}, ctx); code != exitOK {
+		t.Fatalf("fix exit=%d\nstdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if got := git("log", "-1", "--format=%s"); got != "Apply fixers: fmt" {
+		t.Fatalf("HEAD subject = %q", got)
+	}
+	if got := git("show", "--name-only", "--format=", "HEAD"); got != "code.txt" {
+		t.Fatalf("commit should hold only the fixed file, got %q", got)
+	}
+	if got := git("status", "--porcelain"); got != "M notes.txt" {
+		t.Fatalf("unrelated changes should stay uncommitted, status %q", got)
+	}
+	if code := runFix([]string{"--commit", "--amend"}, ctx); code != exitUsage {
+		t.Fatalf("expected --commit with --amend to be rejected, exit=%d", code)
+	}
+}
+
+func TestFixRefusesToCommitFilesThatWereAlreadyDirty(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": fixConfig,
+		"code.txt":                  "messy\n",
+	})
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	base := git("rev-parse", "HEAD")
+	// Work in progress in the very file the fixer rewrites.
+	writeRepoFiles(t, repo, map[string]string{"code.txt": "messy\nhalf-written feature\n"})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	for _, flag := range []string{"--commit", "--amend"} {
+		stderr.Reset()
+		if code := runFix([]string{flag, "fmt"}, ctx); code != exitRunFailed {
+			t.Fatalf("fix %s: expected refusal, exit=%d\nstdout:\n%s\nstderr:\n%s", flag, code, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "code.txt already had uncommitted changes") {
+			t.Fatalf("fix %s: unexpected error %q", flag, stderr.String())
+		}
+		if got := git("rev-parse", "HEAD"); got != base {
+			t.Fatalf("fix %s: expected HEAD left alone, got %s", flag, got)
+		}
+		// Put the work in progress back for the next round.
+		writeRepoFiles(t, repo, map[string]string{"code.txt": "messy\nhalf-written feature\n"})
+	}
+}
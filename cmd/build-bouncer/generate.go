@@ -27,7 +27,11 @@ func stampGeneratedChecks(checks []config.Check, source string) []config.Check {
 			if idSource == "" {
 				idSource = source
 			}
-			check.ID = stableCheckID(idSource, check)
+			if check.MatrixParent != "" {
+				check.ID = matrixCheckID(idSource, check)
+			} else {
+				check.ID = stableCheckID(idSource, check)
+			}
 		}
 		out[i] = check
 	}
@@ -61,6 +65,22 @@ func stableCheckID(source string, check config.Check) string {
 	return strings.TrimSpace(source) + ":" + hex.EncodeToString(sum[:6])
 }
 
+// matrixCheckID gives every combination of an imported matrix job the same stem, so a
+// profile selecting the stem selects them all: "<source>:<hash>[k=v,...]".
+func matrixCheckID(source string, check config.Check) string {
+	stem := stableCheckID(source, config.Check{Name: check.MatrixParent, OS: check.OS})
+	keys := make([]string, 0, len(check.MatrixValues))
+	for k := range check.MatrixValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+check.MatrixValues[k])
+	}
+	return stem + "[" + strings.Join(parts, ",") + "]"
+}
+
 func normalizeStringListKey(list config.StringList) string {
 	if len(list) == 0 {
 		return ""
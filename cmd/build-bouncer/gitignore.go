@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ensureGitignoreEntry appends entry to the repo's .gitignore unless an equivalent line
+// is already present. It reports whether the file changed.
+func ensureGitignoreEntry(root string, entry string) (bool, error) {
+	path := filepath.Join(root, ".gitignore")
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	want := strings.TrimPrefix(entry, "/")
+	for _, rawLine := range strings.Split(string(existing), "\n") {
+		line := strings.TrimPrefix(strings.TrimSpace(rawLine), "/")
+		if line == want {
+			return false, nil
+		}
+	}
+
+	var b strings.Builder
+	b.Write(existing)
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(entry)
+	b.WriteString("\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
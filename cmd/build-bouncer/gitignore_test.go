@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureGitignoreEntry(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(path, []byte("node_modules/"), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+
+	changed, err := ensureGitignoreEntry(root, ".buildbouncer/config.local.yaml")
+	if err != nil || !changed {
+		t.Fatalf("expected entry to be added, changed=%v err=%v", changed, err)
+	}
+	changed, err = ensureGitignoreEntry(root, ".buildbouncer/config.local.yaml")
+	if err != nil || changed {
+		t.Fatalf("expected second call to be a no-op, changed=%v err=%v", changed, err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read .gitignore: %v", err)
+	}
+	if string(got) != "node_modules/\n.buildbouncer/config.local.yaml\n" {
+		t.Fatalf("unexpected .gitignore:\n%s", got)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/importer"
+)
+
+func newImportCommand() cli.Command {
+	return cli.Command{
+		Name:    "import",
+		Usage:   "import [--source NAME] [--all] [--interactive] [--dry-run]",
+		Summary: "Import checks from Makefile, justfile, Taskfile, package.json, composer.json, tox, and nox.",
+		Run: func(ctx cli.Context, args []string) int {
+			fs := cli.NewFlagSet(ctx, "import")
+			source := fs.String("source", "", "only import from one source ("+strings.Join(importer.SourceNames(), ", ")+")")
+			all := fs.Bool("all", false, "import every task, not just the ones that look like checks")
+			interactive := fs.Bool("interactive", false, "choose which tasks to import")
+			dryRun := fs.Bool("dry-run", false, "show the config diff without writing it")
+			if err := fs.Parse(args); err != nil {
+				return exitUsage
+			}
+			return runImport(importOptions{
+				Source:      *source,
+				All:         *all,
+				Interactive: *interactive,
+				DryRun:      *dryRun,
+				Stdin:       os.Stdin,
+			}, ctx)
+		},
+	}
+}
+
+type importOptions struct {
+	Source string
+	// All imports tasks that don't look like checks too.
+	All         bool
+	Interactive bool
+	// DryRun prints the config diff instead of writing it.
+	DryRun bool
+	Stdin  io.Reader
+}
+
+func runImport(opts importOptions, ctx cli.Context) int {
+	cfgPath, cfgDir, err := config.FindConfigFromCwd()
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "import:", err)
+		return exitUsage
+	}
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "import:", err)
+		return exitUsage
+	}
+	before, err := config.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "import:", err)
+		return exitUsage
+	}
+
+	selected := importer.Sources()
+	if strings.TrimSpace(opts.Source) != "" {
+		source, ok := importer.LookupSource(opts.Source)
+		if !ok {
+			fmt.Fprintf(ctx.Stderr, "import: unknown source %q (available: %s)\n", opts.Source, strings.Join(importer.SourceNames(), ", "))
+			return exitUsage
+		}
+		selected = []importer.Source{source}
+	}
+
+	var candidates []importer.Candidate
+	scanned := map[string]bool{}
+	var scannedNames []string
+	for _, source := range selected {
+		if !source.Detect(cfgDir) {
+			continue
+		}
+		found, err := source.Candidates(cfgDir)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "import:", err)
+			return exitUsage
+		}
+		scanned[source.Name()] = true
+		scannedNames = append(scannedNames, source.Name())
+		candidates = append(candidates, found...)
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintln(ctx.Stdout, "No task runner tasks found.")
+		return exitOK
+	}
+
+	checks := make([]config.Check, len(candidates))
+	defaults := make([]bool, len(candidates))
+	for i, c := range candidates {
+		checks[i] = c.Check
+		defaults[i] = c.Recommended || opts.All
+	}
+
+	var chosen []config.Check
+	if opts.Interactive {
+		chosen, err = chooseChecks(opts.Stdin, ctx, checks, defaults)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "import:", err)
+			return exitUsage
+		}
+	} else {
+		for i, check := range checks {
+			if defaults[i] {
+				chosen = append(chosen, check)
+			}
+		}
+	}
+	chosen = stampGeneratedChecks(chosen, "import")
+
+	base, removed := stripImportedChecks(cfg.Checks, scanned)
+	base = stripManualPlaceholder(base)
+	merge := mergeChecks(base, chosen)
+	cfg.Checks = merge.Merged
+	if len(cfg.Checks) == 0 && strings.TrimSpace(cfg.Extends) == "" && len(cfg.Include) == 0 {
+		fmt.Fprintln(ctx.Stderr, "import: replacing the previously imported checks would leave no checks; add one to", displayPath(cfgDir, cfgPath), "or re-run with --all")
+		return exitUsage
+	}
+
+	after, err := config.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "import:", err)
+		return exitUsage
+	}
+	unchanged := bytes.Equal(before, after)
+
+	if opts.DryRun {
+		// Diff against the file as written, so hand edits show up too.
+		current, err := os.ReadFile(cfgPath)
+		if err != nil {
+			fmt.Fprintln(ctx.Stderr, "import:", err)
+			return exitUsage
+		}
+		if d := configDiff(cfgPath, cfgDir, current, after); d != "" {
+			fmt.Fprint(ctx.Stdout, d)
+		} else {
+			fmt.Fprintln(ctx.Stdout, "No changes to", displayPath(cfgDir, cfgPath))
+		}
+	} else if !unchanged {
+		if err := config.Save(cfgPath, cfg); err != nil {
+			fmt.Fprintln(ctx.Stderr, "import:", err)
+			return exitUsage
+		}
+	}
+
+	switch {
+	case unchanged:
+		fmt.Fprintf(ctx.Stdout, "Checks from %s are up to date.\n", strings.Join(scannedNames, ", "))
+	default:
+		verb, replaced := "Imported", "Replaced"
+		if opts.DryRun {
+			verb, replaced = "Would import", "Would replace"
+		}
+		fmt.Fprintf(ctx.Stdout, "%s %d checks from %s\n", verb, len(merge.Added), strings.Join(scannedNames, ", "))
+		if len(merge.Skipped) > 0 {
+			fmt.Fprintf(ctx.Stdout, "Skipped %d duplicate checks\n", len(merge.Skipped))
+		}
+		if removed > 0 {
+			fmt.Fprintf(ctx.Stdout, "%s %d previously imported checks\n", replaced, removed)
+		}
+	}
+	if !opts.Interactive && len(chosen) < len(candidates) {
+		fmt.Fprintf(ctx.Stdout, "%d more tasks available; re-run with --all or --interactive to pick them.\n", len(candidates)-len(chosen))
+	}
+	if opts.DryRun {
+		fmt.Fprintln(ctx.Stdout, "Dry run: config not written.")
+	}
+	return exitOK
+}
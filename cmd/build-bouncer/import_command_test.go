@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func TestImportResyncsPreviouslyImportedChecks(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{
+		Version: 1,
+		Checks:  []config.Check{{Name: "custom", Run: "./custom.sh"}},
+	}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	makefile := filepath.Join(repo, "Makefile")
+	if err := os.WriteFile(makefile, []byte(".PHONY: lint test serve\nlint:\n\tgo vet ./...\ntest:\n\tgo test ./...\nserve:\n\tgo run .\n"), 0o644); err != nil {
+		t.Fatalf("write Makefile: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runImport(importOptions{}, ctx); code != exitOK {
+		t.Fatalf("import exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Imported 2 checks from make") || !strings.Contains(stdout.String(), "1 more tasks available") {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+
+	if err := os.WriteFile(makefile, []byte(".PHONY: lint\nlint:\n\tgo vet ./...\n"), 0o644); err != nil {
+		t.Fatalf("rewrite Makefile: %v", err)
+	}
+	stdout.Reset()
+	if code := runImport(importOptions{Source: "make"}, ctx); code != exitOK {
+		t.Fatalf("import exit=%d stderr=%q", code, stderr.String())
+	}
+
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	var names []string
+	for _, c := range cfg.Checks {
+		names = append(names, c.Name+"@"+c.Source)
+	}
+	if strings.Join(names, ",") != "custom@,make:lint@import:make" {
+		t.Fatalf("expected stale make:test to be replaced, got %v", names)
+	}
+}
+
+func TestImportInteractivePicksNonRecommendedTasks(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{Version: 1, Checks: []config.Check{{Name: "custom", Run: "./custom.sh"}}}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "justfile"), []byte("lint:\n    golangci-lint run\ndocs:\n    mkdocs build\n"), 0o644); err != nil {
+		t.Fatalf("write justfile: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	opts := importOptions{Interactive: true, Stdin: strings.NewReader("n\ny\n")}
+	if code := runImport(opts, ctx); code != exitOK {
+		t.Fatalf("import exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "just:docs") || !strings.Contains(stderr.String(), "[y/N]") {
+		t.Fatalf("expected prompt for docs recipe, got %q", stderr.String())
+	}
+
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 2 || cfg.Checks[1].Name != "just:docs" || cfg.Checks[1].Run != "just docs" {
+		t.Fatalf("expected only the chosen docs recipe, got %+v", cfg.Checks)
+	}
+}
+
+func TestImportDryRunAndNoOpLeaveTheConfigAlone(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{Version: 1, Checks: []config.Check{{Name: "custom", Run: "./custom.sh"}}}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	makefile := filepath.Join(repo, "Makefile")
+	if err := os.WriteFile(makefile, []byte(".PHONY: lint serve\nlint:\n\tgo vet ./...\nserve:\n\tgo run .\n"), 0o644); err != nil {
+		t.Fatalf("write Makefile: %v", err)
+	}
+	readConfig := func() string {
+		t.Helper()
+		data, err := os.ReadFile(cfgPath)
+		if err != nil {
+			t.Fatalf("read config: %v", err)
+		}
+		return string(data)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	original := readConfig()
+	if code := runImport(importOptions{DryRun: true}, ctx); code != exitOK {
+		t.Fatalf("import --dry-run exit=%d stderr=%q", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "+      run: make lint") || !strings.Contains(out, "Would import 1 checks from make") || !strings.Contains(out, "Dry run: config not written.") {
+		t.Fatalf("expected a diff of the import, got:\n%s", out)
+	}
+	if readConfig() != original {
+		t.Fatalf("--dry-run wrote the config")
+	}
+
+	if code := runImport(importOptions{}, ctx); code != exitOK {
+		t.Fatalf("import exit=%d stderr=%q", code, stderr.String())
+	}
+	// A hand edit that Save would drop shows whether the no-op import rewrote the file.
+	edited := "# keep me\n" + readConfig()
+	if err := os.WriteFile(cfgPath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("edit config: %v", err)
+	}
+	stdout.Reset()
+	if code := runImport(importOptions{}, ctx); code != exitOK {
+		t.Fatalf("import exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Checks from make are up to date.") || readConfig() != edited {
+		t.Fatalf("expected an unchanged import to leave the file alone, got:\n%s", stdout.String())
+	}
+}
+
+func TestImportRefusesToLeaveNoChecks(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{Version: 1, Checks: []config.Check{{Name: "make:lint", Run: "make lint", Source: "import:make"}}}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	// lint is gone and serve doesn't look like a check, so nothing would be left.
+	if err := os.WriteFile(filepath.Join(repo, "Makefile"), []byte(".PHONY: serve\nserve:\n\tgo run .\n"), 0o644); err != nil {
+		t.Fatalf("write Makefile: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runImport(importOptions{}, ctx); code != exitUsage || !strings.Contains(stderr.String(), "would leave no checks") {
+		t.Fatalf("expected refusal, exit=%d stderr=%q", code, stderr.String())
+	}
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil || len(cfg.Checks) != 1 {
+		t.Fatalf("expected the config untouched, got %+v (err=%v)", cfg, err)
+	}
+}
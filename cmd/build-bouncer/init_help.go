@@ -18,9 +18,34 @@ func printInitHelp(ctx cli.Context) {
 	fmt.Fprintln(ctx.Stdout, "")
 	fmt.Fprintln(ctx.Stdout, "Examples:")
 	fmt.Fprintln(ctx.Stdout, "  build-bouncer init --go")
+	fmt.Fprintln(ctx.Stdout, "  build-bouncer init --auto   (detect every project in the repo)")
+	fmt.Fprintln(ctx.Stdout, "  build-bouncer init --list   (show every template and where it comes from)")
 	fmt.Fprintln(ctx.Stdout, "  build-bouncer setup --go")
 }
 
+// printTemplateList shows each template with its origin, then the user template
+// directories and any template files that could not be loaded.
+func printTemplateList(ctx cli.Context) {
+	user, errs := loadUserTemplates()
+	for _, tmpl := range mergeTemplates(configTemplates, user) {
+		flags := formatFlags(tmpl.Flags)
+		if flags == "" {
+			flags = "(no free flag; use extends: template:" + tmpl.ID + ")"
+		}
+		fmt.Fprintf(ctx.Stdout, "%s  %s\n", tmpl.ID, flags)
+		fmt.Fprintf(ctx.Stdout, "    %s\n", tmpl.Summary)
+		fmt.Fprintf(ctx.Stdout, "    from: %s\n", tmpl.Origin)
+	}
+	fmt.Fprintln(ctx.Stdout, "")
+	fmt.Fprintln(ctx.Stdout, "Template directories (first match wins, before embedded):")
+	for _, dir := range userTemplateDirs() {
+		fmt.Fprintf(ctx.Stdout, "  %s\n", dir)
+	}
+	for _, err := range errs {
+		fmt.Fprintln(ctx.Stderr, "init: skipped template:", err)
+	}
+}
+
 func formatFlags(flags []string) string {
 	if len(flags) == 0 {
 		return ""
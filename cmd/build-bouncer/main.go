@@ -3,7 +3,9 @@ package main
 import (
 	"os"
 
+	"github.com/berniemackie97/build-bouncer/internal/builtin"
 	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
 )
 
 const (
@@ -15,6 +17,11 @@ const (
 	exitRunFailed = 10
 )
 
+func init() {
+	// Reject unknown `builtin:` names and bad `with:` options when the config loads.
+	config.BuiltinValidator = builtin.Validate
+}
+
 func main() {
 	app := cli.NewApp(appName, version, os.Stdout, os.Stderr, exitUsage)
 	registerCommands(app)
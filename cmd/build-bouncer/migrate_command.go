@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/migrate"
+)
+
+func newMigrateCommand() cli.Command {
+	return cli.Command{
+		Name:    "migrate",
+		Usage:   "migrate [--from " + strings.Join(migrate.ToolNames(), "|") + "]",
+		Summary: "Convert pre-commit, lefthook, or husky hooks into checks.",
+		Run: func(ctx cli.Context, args []string) int {
+			fs := cli.NewFlagSet(ctx, "migrate")
+			from := fs.String("from", "", "hook manager to migrate from ("+strings.Join(migrate.ToolNames(), ", ")+"); default: every one found")
+			if err := fs.Parse(args); err != nil {
+				return exitUsage
+			}
+			return runMigrate(*from, ctx)
+		},
+	}
+}
+
+func runMigrate(from string, ctx cli.Context) int {
+	cfgPath, cfgDir, err := config.FindConfigFromCwd()
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "migrate:", err)
+		return exitUsage
+	}
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "migrate:", err)
+		return exitUsage
+	}
+
+	selected := migrate.Tools()
+	if strings.TrimSpace(from) != "" {
+		tool, ok := migrate.LookupTool(from)
+		if !ok {
+			fmt.Fprintf(ctx.Stderr, "migrate: unknown hook manager %q (available: %s)\n", from, strings.Join(migrate.ToolNames(), ", "))
+			return exitUsage
+		}
+		if !tool.Detect(cfgDir) {
+			fmt.Fprintf(ctx.Stderr, "migrate: no %s configuration found\n", tool.Name())
+			return exitUsage
+		}
+		selected = []migrate.Tool{tool}
+	}
+
+	var migrated []config.Check
+	var unsupported []string
+	var parallel, failFast bool
+	var toolNames []string
+	for _, tool := range selected {
+		if !tool.Detect(cfgDir) {
+			continue
+		}
+		res, err := tool.Migrate(cfgDir)
+		if err != nil {
+			fmt.Fprintf(ctx.Stderr, "migrate: %s: %v\n", tool.Name(), err)
+			return exitUsage
+		}
+		toolNames = append(toolNames, tool.Name())
+		migrated = append(migrated, res.Checks...)
+		for _, item := range res.Unsupported {
+			unsupported = append(unsupported, tool.Name()+": "+item)
+		}
+		parallel = parallel || res.Parallel
+		failFast = failFast || res.FailFast
+	}
+	if len(toolNames) == 0 {
+		fmt.Fprintln(ctx.Stdout, "No pre-commit, lefthook, or husky configuration found.")
+		return exitOK
+	}
+
+	scanned := map[string]bool{}
+	for _, name := range toolNames {
+		scanned[name] = true
+	}
+	base, removed := stripMigratedChecks(cfg.Checks, scanned)
+	base = stripManualPlaceholder(base)
+	merge := mergeChecks(base, stampGeneratedChecks(migrated, ""))
+	cfg.Checks = merge.Merged
+
+	raisedParallel := parallel && len(merge.Added) > cfg.Runner.MaxParallel
+	if raisedParallel {
+		cfg.Runner.MaxParallel = len(merge.Added)
+	}
+	setFailFast := failFast && !cfg.Runner.IsFailFast()
+	if setFailFast {
+		cfg.Runner.FailFast = &failFast
+	}
+
+	if err := config.Save(cfgPath, cfg); err != nil {
+		fmt.Fprintln(ctx.Stderr, "migrate:", err)
+		return exitUsage
+	}
+
+	fmt.Fprintf(ctx.Stdout, "Migrated %d checks from %s\n", len(merge.Added), strings.Join(toolNames, ", "))
+	if len(merge.Skipped) > 0 {
+		fmt.Fprintf(ctx.Stdout, "Skipped %d duplicate checks\n", len(merge.Skipped))
+	}
+	if removed > 0 {
+		fmt.Fprintf(ctx.Stdout, "Replaced %d previously migrated checks\n", removed)
+	}
+	if raisedParallel {
+		fmt.Fprintf(ctx.Stdout, "Set runner.maxParallel to %d (the hooks ran in parallel)\n", cfg.Runner.MaxParallel)
+	}
+	if setFailFast {
+		fmt.Fprintln(ctx.Stdout, "Set runner.failFast (the hooks stopped at the first failure)")
+	}
+	if len(unsupported) > 0 {
+		fmt.Fprintf(ctx.Stdout, "Not migrated (%d):\n", len(unsupported))
+		for _, item := range unsupported {
+			fmt.Fprintln(ctx.Stdout, "  -", item)
+		}
+	}
+	fmt.Fprintf(ctx.Stdout, "Once the checks pass, uninstall %s's git hooks and run `build-bouncer hook install`.\n", strings.Join(toolNames, "/"))
+	return exitOK
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func TestMigrateFromLefthookWritesChecksAndReportsLeftovers(t *testing.T) {
+	repo := withTempRepo(t)
+	cfgPath := filepath.Join(repo, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{
+		Version: 1,
+		Checks:  []config.Check{{Name: "custom", Run: "./custom.sh"}},
+	}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	lefthook := `
+pre-push:
+  parallel: true
+  commands:
+    vet:
+      glob: "*.go"
+      run: go vet ./...
+    test:
+      run: go test ./...
+commit-msg:
+  commands:
+    msg:
+      run: commitlint --edit {1}
+`
+	if err := os.WriteFile(filepath.Join(repo, "lefthook.yml"), []byte(lefthook), 0o644); err != nil {
+		t.Fatalf("write lefthook.yml: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runMigrate("lefthook", ctx); code != exitOK {
+		t.Fatalf("migrate exit=%d stderr=%q", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Migrated 2 checks from lefthook", "Set runner.maxParallel to 2", "Not migrated (1):", "lefthook: commit-msg"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	cfg, err := config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 3 || cfg.Checks[1].Name != "lefthook:pre-push:vet" {
+		t.Fatalf("unexpected checks %+v", cfg.Checks)
+	}
+	if got := strings.Join(cfg.Checks[1].Paths, ","); got != "*.go" {
+		t.Fatalf("unexpected paths %q", got)
+	}
+	if cfg.Checks[1].Source != "migrate:lefthook" || cfg.Checks[1].ID == "" {
+		t.Fatalf("expected stamped check, got %+v", cfg.Checks[1])
+	}
+
+	stdout.Reset()
+	if code := runMigrate("", ctx); code != exitOK {
+		t.Fatalf("re-migrate exit=%d stderr=%q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Replaced 2 previously migrated checks") {
+		t.Fatalf("expected re-migration to replace checks:\n%s", stdout.String())
+	}
+	cfg, err = config.LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 3 {
+		t.Fatalf("re-migration duplicated checks: %+v", cfg.Checks)
+	}
+}
+
+func TestMigrateRejectsMissingConfig(t *testing.T) {
+	repo := withTempRepo(t)
+	if err := config.Save(filepath.Join(repo, ".buildbouncer", "config.yaml"), &config.Config{
+		Version: 1,
+		Checks:  []config.Check{{Name: "custom", Run: "./custom.sh"}},
+	}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runMigrate("husky", cli.Context{Stdout: &stdout, Stderr: &stderr}); code != exitUsage {
+		t.Fatalf("expected usage error, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "no husky configuration found") {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+}
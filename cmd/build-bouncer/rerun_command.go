@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/prompt"
+	"github.com/berniemackie97/build-bouncer/internal/runner"
+	"github.com/berniemackie97/build-bouncer/internal/tui"
+)
+
+func newRerunCommand() cli.Command {
+	return cli.Command{
+		Name:    "rerun",
+		Usage:   "rerun [--failed-tests] [--verbose] [CHECK...]",
+		Summary: "Re-run the checks that failed in the last check run (or only their failed tests).",
+		Run: func(ctx cli.Context, args []string) int {
+			return runRerun(args, ctx)
+		},
+	}
+}
+
+func runRerun(args []string, ctx cli.Context) int {
+	flags := cli.NewFlagSet(ctx, "rerun")
+	failedTests := flags.Bool("failed-tests", false, "run only the tests that failed, through each check's rerun template")
+	verbose := flags.Bool("verbose", false, "stream full tool output to the terminal")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	cfgPath, cfgDir, err := config.FindConfigFromCwd()
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "rerun:", err)
+		return exitUsage
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "rerun:", err)
+		return exitUsage
+	}
+	last, err := runner.ReadReportFile(runner.LastReportPath(cfgDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(ctx.Stderr, "rerun: no recorded check run; run `build-bouncer check` first")
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "rerun:", err)
+		return exitUsage
+	}
+	// Rerun the checks the way the recorded run ran them.
+	if last.Profile != "" {
+		if cfg, err = cfg.ApplyProfile(last.Profile); err != nil {
+			fmt.Fprintln(ctx.Stderr, "rerun:", err)
+			return exitUsage
+		}
+		if *verbose {
+			fmt.Fprintln(ctx.Stdout, "Profile:", last.Profile)
+		}
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		for _, failure := range last.Failures {
+			names = append(names, failure.Check)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(ctx.Stdout, tui.Success("✓ Nothing failed in the last check run"))
+		return exitOK
+	}
+	targets, err := cfg.SelectChecks(names)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "rerun:", err)
+		return exitUsage
+	}
+
+	fmt.Fprintln(ctx.Stdout, tui.Section("Rerunning"))
+	for i, check := range targets.Checks {
+		failure, _ := last.Failure(check.Name)
+		if !*failedTests || len(failure.FailedTests) == 0 {
+			fmt.Fprintln(ctx.Stdout, tui.Bullet(check.Name))
+			continue
+		}
+		command, err := runner.RerunCommand(check, failure.FailedTests)
+		if err != nil {
+			fmt.Fprintf(ctx.Stdout, "%s %s\n", tui.Bullet(check.Name), tui.Dim("("+err.Error()+"; running the whole check)"))
+			continue
+		}
+		targets.Checks[i].Run = command
+		fmt.Fprintf(ctx.Stdout, "%s %s\n", tui.Bullet(fmt.Sprintf("%s (%d failed tests)", check.Name, len(failure.FailedTests))), tui.Dim(command))
+	}
+
+	rep, err := runner.RunAllReport(cfgDir, targets, runner.Options{Verbose: *verbose, MaxParallel: cfg.Runner.MaxParallel})
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "rerun:", err)
+		return exitUsage
+	}
+	fmt.Fprintln(ctx.Stdout, "")
+	if len(rep.Failures) > 0 {
+		fmt.Fprintln(ctx.Stdout, tui.Section("Still failing"))
+		for _, name := range rep.Failures {
+			fmt.Fprintln(ctx.Stdout, tui.Cross(name))
+			if headline := strings.TrimSpace(rep.FailureHeadlines[name]); headline != "" {
+				fmt.Fprintln(ctx.Stdout, tui.Dim("    "+headline))
+			}
+			for _, line := range prompt.FailedTestLines(rep.FailedTests[name], verboseFailedTests) {
+				fmt.Fprintln(ctx.Stdout, tui.Dim("      "+line))
+			}
+		}
+		return exitRunFailed
+	}
+	fmt.Fprintln(ctx.Stdout, tui.Success("✓ Rerun checks pass"))
+	return exitOK
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/runner"
+)
+
+func TestRerunFailedTestsRunsOnlyThoseTests(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "unit"
+    shell: "sh"
+    run: "sh fake_go_test.sh"
+    rerun: "{run} -run {regex}"
+`,
+		// Fails like go test does, unless asked to run a subset.
+		"fake_go_test.sh": `if [ "$1" = "-run" ]; then echo "$2" > ran.txt; exit 0; fi
+printf -- '--- FAIL: TestAdd (0.01s)\n    math_test.go:9: got 3, want 4\nFAIL\nFAIL\texample.com/app\t0.02s\n'
+exit 1
+`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runRerun(nil, ctx); code != exitUsage || !strings.Contains(stderr.String(), "no recorded check run") {
+		t.Fatalf("expected a missing-report error, exit=%d stderr=%q", code, stderr.String())
+	}
+
+	reportPath := filepath.Join(repo, "report.json")
+	if code := runCheck([]string{"--ci", "--report-json", reportPath}, ctx); code != exitRunFailed {
+		t.Fatalf("check exit=%d\nstderr:\n%s", code, stderr.String())
+	}
+	report, err := runner.ReadReportFile(reportPath)
+	if err != nil {
+		t.Fatalf("read --report-json: %v", err)
+	}
+	failure, ok := report.Failure("unit")
+	if !ok || len(failure.FailedTests) != 1 || failure.FailedTests[0].Label() != "example.com/app.TestAdd" || failure.FailedTests[0].Message != "math_test.go:9: got 3, want 4" {
+		t.Fatalf("unexpected report failure %+v", failure)
+	}
+
+	stdout.Reset()
+	if code := runRerun([]string{"--failed-tests"}, ctx); code != exitOK {
+		t.Fatalf("rerun exit=%d\nstdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "sh fake_go_test.sh -run '^(TestAdd)$'") || !strings.Contains(out, "Rerun checks pass") {
+		t.Fatalf("unexpected rerun output:\n%s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "ran.txt")); strings.TrimSpace(string(data)) != "^(TestAdd)$" {
+		t.Fatalf("rerun did not filter to the failed test, ran.txt = %q", data)
+	}
+}
+
+func TestRerunAppliesTheRecordedProfile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{
+		".buildbouncer/config.yaml": `
+version: 1
+checks:
+  - name: "unit"
+    shell: "sh"
+    run: "test -f fixed.txt"
+  - name: "slow"
+    shell: "sh"
+    run: "false"
+profiles:
+  quick:
+    checks: [unit]
+`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	reportPath := filepath.Join(repo, "report.json")
+	if code := runCheck([]string{"--ci", "--profile", "quick", "--report-json", reportPath}, ctx); code != exitRunFailed {
+		t.Fatalf("check exit=%d\nstderr:\n%s", code, stderr.String())
+	}
+	if report, err := runner.ReadReportFile(reportPath); err != nil || report.Profile != "quick" {
+		t.Fatalf("expected the profile in the report, got %+v (err=%v)", report, err)
+	}
+
+	writeRepoFiles(t, repo, map[string]string{"fixed.txt": "ok\n"})
+	stdout.Reset()
+	if code := runRerun([]string{"--verbose"}, ctx); code != exitOK {
+		t.Fatalf("rerun exit=%d\nstdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Profile: quick") {
+		t.Fatalf("expected the recorded profile to be applied:\n%s", stdout.String())
+	}
+
+	// Checks the profile left out were not part of the run, so they can't be rerun from it.
+	stderr.Reset()
+	if code := runRerun([]string{"slow"}, ctx); code != exitUsage || !strings.Contains(stderr.String(), `"slow"`) {
+		t.Fatalf("expected slow to be outside the recorded profile, exit=%d stderr=%q", code, stderr.String())
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/importer"
 )
 
 type packageJSON struct {
@@ -139,30 +140,7 @@ func loadPackageJSON(path string) (packageJSON, bool) {
 }
 
 func detectNodeRunner(root string, pkg packageJSON) string {
-	if pm := strings.TrimSpace(pkg.PackageManager); pm != "" {
-		pm = strings.ToLower(pm)
-		switch {
-		case strings.HasPrefix(pm, "bun"):
-			return "bun"
-		case strings.HasPrefix(pm, "pnpm"):
-			return "pnpm"
-		case strings.HasPrefix(pm, "yarn"):
-			return "yarn"
-		case strings.HasPrefix(pm, "npm"):
-			return "npm"
-		}
-	}
-
-	if fileExists(filepath.Join(root, "pnpm-lock.yaml")) {
-		return "pnpm"
-	}
-	if fileExists(filepath.Join(root, "yarn.lock")) {
-		return "yarn"
-	}
-	if fileExists(filepath.Join(root, "bun.lockb")) || fileExists(filepath.Join(root, "bun.lock")) {
-		return "bun"
-	}
-	return "npm"
+	return importer.NodeRunner(root, pkg.PackageManager)
 }
 
 func updateGradleChecks(root string, checks []config.Check) ([]config.Check, string) {
@@ -10,13 +10,27 @@ import (
 	"strings"
 
 	assettemplates "github.com/berniemackie97/build-bouncer/assets/templates"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
 )
 
+func init() {
+	// `extends: template:<id>` should resolve exactly like `init --<id>` does.
+	config.TemplateResolver = resolveTemplateByID
+}
+
 type configTemplate struct {
 	ID      string
 	File    string
 	Summary string
 	Flags   []string
+	// Markers are file names (or globs) whose presence in a directory means `init --auto`
+	// should use this template there. Variants of another template leave it empty.
+	Markers []string
+	// Origin is "embedded" or the path of the user template file that defined it.
+	Origin string
+	// body is the config of a user template, already stripped of its metadata.
+	body []byte
 }
 
 var configTemplates = []configTemplate{
@@ -31,18 +45,21 @@ var configTemplates = []configTemplate{
 		File:    "config_go.yaml",
 		Summary: "Go projects (go test ./..., go vet ./...)",
 		Flags:   []string{"go", "golang"},
+		Markers: []string{"go.mod"},
 	},
 	{
 		ID:      "dotnet",
 		File:    "config_dotnet.yaml",
 		Summary: ".NET projects (dotnet test, dotnet format)",
 		Flags:   []string{"dotnet", "net", "csharp"},
+		Markers: []string{"*.sln", "*.csproj", "*.fsproj", "*.vbproj"},
 	},
 	{
 		ID:      "node",
 		File:    "config_node.yaml",
 		Summary: "Node projects (npm run lint/test/build)",
 		Flags:   []string{"node", "nodejs", "js", "javascript", "ts", "typescript"},
+		Markers: []string{"package.json"},
 	},
 	{
 		ID:      "react",
@@ -91,30 +108,35 @@ var configTemplates = []configTemplate{
 		File:    "config_python.yaml",
 		Summary: "Python projects (ruff/black/pytest)",
 		Flags:   []string{"python", "py", "django", "flask", "fastapi"},
+		Markers: []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt"},
 	},
 	{
 		ID:      "ruby",
 		File:    "config_ruby.yaml",
 		Summary: "Ruby projects (rubocop/rspec)",
 		Flags:   []string{"ruby", "rails"},
+		Markers: []string{"Gemfile"},
 	},
 	{
 		ID:      "php",
 		File:    "config_php.yaml",
 		Summary: "PHP projects (composer scripts)",
 		Flags:   []string{"php", "laravel", "symfony"},
+		Markers: []string{"composer.json"},
 	},
 	{
 		ID:      "maven",
 		File:    "config_java_maven.yaml",
 		Summary: "Java projects with Maven (mvn test/package)",
 		Flags:   []string{"maven", "java-maven"},
+		Markers: []string{"pom.xml"},
 	},
 	{
 		ID:      "gradle",
 		File:    "config_java_gradle.yaml",
 		Summary: "Java projects with Gradle (gradlew test/build)",
 		Flags:   []string{"gradle", "java-gradle"},
+		Markers: []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
 	},
 	{
 		ID:      "kotlin",
@@ -133,18 +155,21 @@ var configTemplates = []configTemplate{
 		File:    "config_rust.yaml",
 		Summary: "Rust projects (cargo fmt/clippy/test)",
 		Flags:   []string{"rust"},
+		Markers: []string{"Cargo.toml"},
 	},
 	{
 		ID:      "cpp",
 		File:    "config_cpp.yaml",
 		Summary: "C/C++ projects (cmake/ctest)",
 		Flags:   []string{"cpp", "cxx", "cplusplus"},
+		Markers: []string{"CMakeLists.txt"},
 	},
 	{
 		ID:      "swift",
 		File:    "config_swift.yaml",
 		Summary: "Swift projects (swift test/build)",
 		Flags:   []string{"swift"},
+		Markers: []string{"Package.swift"},
 	},
 	{
 		ID:      "flutter",
@@ -157,66 +182,77 @@ var configTemplates = []configTemplate{
 		File:    "config_dart.yaml",
 		Summary: "Dart projects (dart analyze/test)",
 		Flags:   []string{"dart"},
+		Markers: []string{"pubspec.yaml"},
 	},
 	{
 		ID:      "elixir",
 		File:    "config_elixir.yaml",
 		Summary: "Elixir projects (mix format/test)",
 		Flags:   []string{"elixir"},
+		Markers: []string{"mix.exs"},
 	},
 	{
 		ID:      "deno",
 		File:    "config_deno.yaml",
 		Summary: "Deno projects (deno lint/format/test)",
 		Flags:   []string{"deno"},
+		Markers: []string{"deno.json", "deno.jsonc"},
 	},
 	{
 		ID:      "scala",
 		File:    "config_scala.yaml",
 		Summary: "Scala projects (sbt test)",
 		Flags:   []string{"scala", "sbt"},
+		Markers: []string{"build.sbt"},
 	},
 	{
 		ID:      "clojure",
 		File:    "config_clojure.yaml",
 		Summary: "Clojure projects (lein test)",
 		Flags:   []string{"clojure", "lein", "leiningen"},
+		Markers: []string{"project.clj", "deps.edn"},
 	},
 	{
 		ID:      "haskell",
 		File:    "config_haskell.yaml",
 		Summary: "Haskell projects (stack test)",
 		Flags:   []string{"haskell", "stack", "cabal"},
+		Markers: []string{"stack.yaml", "*.cabal"},
 	},
 	{
 		ID:      "erlang",
 		File:    "config_erlang.yaml",
 		Summary: "Erlang projects (rebar3 eunit)",
 		Flags:   []string{"erlang", "rebar", "rebar3"},
+		Markers: []string{"rebar.config"},
 	},
 	{
 		ID:      "lua",
 		File:    "config_lua.yaml",
 		Summary: "Lua projects (luacheck/busted)",
 		Flags:   []string{"lua", "luajit"},
+		Markers: []string{"*.rockspec", ".luacheckrc"},
 	},
 	{
 		ID:      "perl",
 		File:    "config_perl.yaml",
 		Summary: "Perl projects (prove)",
 		Flags:   []string{"perl"},
+		Markers: []string{"cpanfile", "Makefile.PL"},
 	},
 	{
 		ID:      "r",
 		File:    "config_r.yaml",
 		Summary: "R projects (R CMD check)",
 		Flags:   []string{"r", "rlang"},
+		Markers: []string{"DESCRIPTION"},
 	},
 	{
 		ID:      "terraform",
 		File:    "config_terraform.yaml",
 		Summary: "Terraform projects (fmt/validate)",
 		Flags:   []string{"terraform", "tf"},
+		Markers: []string{"*.tf"},
 	},
 }
 
@@ -271,8 +307,28 @@ func loadTemplateBytes(targetRoot string, templateName string) ([]byte, error) {
 	return nil, errors.New("template not found: " + templateName + " (expected assets/templates or set BUILDBOUNCER_TEMPLATES_DIR)")
 }
 
+func resolveTemplateByID(id string) ([]byte, error) {
+	tmpl, ok := findConfigTemplate(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q", id)
+	}
+	root, err := git.FindRepoRootOrCwd()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.load(root)
+}
+
+// load returns the template's config YAML.
+func (t configTemplate) load(root string) ([]byte, error) {
+	if t.body != nil {
+		return t.body, nil
+	}
+	return loadTemplateBytes(root, t.File)
+}
+
 func findConfigTemplate(id string) (configTemplate, bool) {
-	for _, tmpl := range configTemplates {
+	for _, tmpl := range listConfigTemplates() {
 		if tmpl.ID == id {
 			return tmpl, true
 		}
@@ -280,8 +336,11 @@ func findConfigTemplate(id string) (configTemplate, bool) {
 	return configTemplate{}, false
 }
 
+// listConfigTemplates returns the embedded templates with user and team templates
+// (see userTemplateDirs) laid over them. Unusable template files are left out.
 func listConfigTemplates() []configTemplate {
-	return append([]configTemplate{}, configTemplates...)
+	user, _ := loadUserTemplates()
+	return mergeTemplates(configTemplates, user)
 }
 
 type templateSelector struct {
@@ -293,6 +352,10 @@ func registerTemplateFlags(fs *flag.FlagSet) *templateSelector {
 	for _, tmpl := range listConfigTemplates() {
 		for _, name := range tmpl.Flags {
 			flagName := strings.TrimPrefix(name, "--")
+			if fs.Lookup(flagName) != nil {
+				// A user template cannot take over --force, --auto, ...
+				continue
+			}
 			var b bool
 			fs.BoolVar(&b, flagName, false, "use "+tmpl.ID+" template")
 			sel.byID[tmpl.ID] = append(sel.byID[tmpl.ID], &b)
@@ -323,3 +386,18 @@ func (s *templateSelector) Selected() (string, error) {
 	}
 	return "", nil
 }
+
+// selectTemplate resolves the template flags, with --auto as one more (exclusive) choice.
+func selectTemplate(sel *templateSelector, auto bool) (string, error) {
+	templateID, err := sel.Selected()
+	if err != nil {
+		return "", err
+	}
+	if auto {
+		if templateID != "" {
+			return "", fmt.Errorf("--auto cannot be combined with --%s", templateID)
+		}
+		return autoTemplateID, nil
+	}
+	return templateID, nil
+}
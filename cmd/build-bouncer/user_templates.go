@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// templatePathEnv lists extra template directories, separated like PATH. They are
+// searched before the user's own directory, and the first template with an ID wins.
+const templatePathEnv = "BUILDBOUNCER_TEMPLATE_PATH"
+
+// embeddedTemplateOrigin is the origin shown for templates compiled into the binary.
+const embeddedTemplateOrigin = "embedded"
+
+var templateIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// templateFileMeta is the top-level `template:` block of a user template. Everything
+// else in the file is an ordinary config.
+type templateFileMeta struct {
+	ID      string            `yaml:"id"`
+	Summary string            `yaml:"summary"`
+	Flags   config.StringList `yaml:"flags"`
+	Markers config.StringList `yaml:"markers"`
+}
+
+// userTemplateDirs returns the directories searched for user and team templates,
+// highest precedence first: BUILDBOUNCER_TEMPLATE_PATH, then
+// $XDG_CONFIG_HOME/build-bouncer/templates (~/.config when XDG_CONFIG_HOME is unset).
+func userTemplateDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(templatePathEnv)) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	configHome := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME"))
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, "build-bouncer", "templates"))
+	}
+	return dirs
+}
+
+// loadUserTemplates reads every *.yaml/*.yml template in the user template directories.
+// Files that cannot be used are reported as errors and left out; a later file with an
+// ID already seen is shadowed by the earlier one.
+func loadUserTemplates() ([]configTemplate, []error) {
+	var out []configTemplate
+	var errs []error
+	seen := map[string]string{}
+	for _, dir := range userTemplateDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		var names []string
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			tmpl, err := parseTemplateFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			if _, dup := seen[tmpl.ID]; dup {
+				continue
+			}
+			seen[tmpl.ID] = path
+			out = append(out, tmpl)
+		}
+	}
+	return out, errs
+}
+
+// parseTemplateFile loads one user template. The ID defaults to the file name (minus a
+// config_ prefix) and the flags to the ID.
+func parseTemplateFile(path string) (configTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return configTemplate{}, err
+	}
+	meta, body, err := splitTemplateMeta(data)
+	if err != nil {
+		return configTemplate{}, err
+	}
+	if _, err := config.Parse(body); err != nil {
+		return configTemplate{}, err
+	}
+
+	id := strings.TrimSpace(meta.ID)
+	if id == "" {
+		id = strings.TrimPrefix(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), "config_")
+	}
+	if !templateIDPattern.MatchString(id) {
+		return configTemplate{}, fmt.Errorf("invalid template id %q (use lowercase letters, digits, '.', '_' or '-')", id)
+	}
+	var flags []string
+	for _, f := range meta.Flags {
+		if f = strings.TrimPrefix(strings.TrimSpace(f), "--"); f != "" {
+			flags = append(flags, f)
+		}
+	}
+	var markers []string
+	for _, m := range meta.Markers {
+		if m = strings.TrimSpace(m); m != "" {
+			markers = append(markers, m)
+		}
+	}
+
+	return configTemplate{
+		ID:      id,
+		File:    path,
+		Summary: strings.TrimSpace(meta.Summary),
+		Flags:   flags,
+		Markers: markers,
+		Origin:  path,
+		body:    body,
+	}, nil
+}
+
+// splitTemplateMeta separates the `template:` block from the config it describes.
+func splitTemplateMeta(data []byte) (templateFileMeta, []byte, error) {
+	var meta templateFileMeta
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return meta, nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return meta, nil, errors.New("template must be a YAML mapping")
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "template" {
+			continue
+		}
+		if err := root.Content[i+1].Decode(&meta); err != nil {
+			return meta, nil, fmt.Errorf("template: %w", err)
+		}
+		root.Content = append(root.Content[:i:i], root.Content[i+2:]...)
+		break
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return meta, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return meta, nil, err
+	}
+	return meta, buf.Bytes(), nil
+}
+
+// mergeTemplates lays user templates over the embedded ones. A user template with an
+// embedded ID replaces it in place, keeping any summary, flags or markers it leaves
+// out; new templates follow, minus flags another template already answers to.
+func mergeTemplates(embedded []configTemplate, user []configTemplate) []configTemplate {
+	out := make([]configTemplate, 0, len(embedded)+len(user))
+	index := map[string]int{}
+	for _, tmpl := range embedded {
+		tmpl.Origin = embeddedTemplateOrigin
+		index[tmpl.ID] = len(out)
+		out = append(out, tmpl)
+	}
+
+	var added []configTemplate
+	for _, tmpl := range user {
+		i, ok := index[tmpl.ID]
+		if !ok {
+			added = append(added, tmpl)
+			continue
+		}
+		base := out[i]
+		if tmpl.Summary == "" {
+			tmpl.Summary = base.Summary
+		}
+		if len(tmpl.Flags) == 0 {
+			tmpl.Flags = base.Flags
+		}
+		if len(tmpl.Markers) == 0 {
+			tmpl.Markers = base.Markers
+		}
+		out[i] = tmpl
+	}
+
+	taken := map[string]bool{}
+	for _, tmpl := range out {
+		for _, f := range tmpl.Flags {
+			taken[f] = true
+		}
+	}
+	for _, tmpl := range added {
+		if len(tmpl.Flags) == 0 {
+			tmpl.Flags = []string{tmpl.ID}
+		}
+		var flags []string
+		for _, f := range tmpl.Flags {
+			if !taken[f] {
+				taken[f] = true
+				flags = append(flags, f)
+			}
+		}
+		tmpl.Flags = flags
+		if tmpl.Summary == "" {
+			tmpl.Summary = "Custom template"
+		}
+		out = append(out, tmpl)
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/cli"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+const teamServiceTemplate = `template:
+  id: acme-service
+  summary: ACME services (make check)
+  flags: [acme, go]
+  markers: [acme.toml]
+version: 1
+checks:
+  - name: check
+    run: make check
+`
+
+func TestUserTemplatesShadowEmbeddedAndAddNewOnes(t *testing.T) {
+	team := t.TempDir()
+	xdg := t.TempDir()
+	writeRepoFiles(t, team, map[string]string{
+		"service.yaml": teamServiceTemplate,
+		"go.yml":       "template:\n  summary: Our Go setup\nversion: 1\nchecks:\n  - name: ci\n    run: make ci\n",
+		"broken.yaml":  "template:\n  id: Not Valid\nversion: 1\n",
+		"notes.txt":    "ignored",
+	})
+	writeRepoFiles(t, xdg, map[string]string{
+		"build-bouncer/templates/config_acme-service.yaml": "version: 1\nchecks:\n  - name: shadowed\n    run: 'true'\n",
+		"build-bouncer/templates/docs.yaml":                "version: 1\nchecks:\n  - name: docs\n    run: mkdocs build\n",
+	})
+	t.Setenv(templatePathEnv, team)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	byID := map[string]configTemplate{}
+	for _, tmpl := range listConfigTemplates() {
+		byID[tmpl.ID] = tmpl
+	}
+
+	goTmpl := byID["go"]
+	if goTmpl.Origin != filepath.Join(team, "go.yml") || goTmpl.Summary != "Our Go setup" {
+		t.Fatalf("expected go to be shadowed by the team template, got %+v", goTmpl)
+	}
+	if strings.Join(goTmpl.Flags, ",") != "go,golang" || strings.Join(goTmpl.Markers, ",") != "go.mod" {
+		t.Fatalf("shadowing template should keep embedded flags and markers, got %+v", goTmpl)
+	}
+
+	acme := byID["acme-service"]
+	if acme.Origin != filepath.Join(team, "service.yaml") {
+		t.Fatalf("BUILDBOUNCER_TEMPLATE_PATH should win over XDG, got origin %q", acme.Origin)
+	}
+	if strings.Join(acme.Flags, ",") != "acme" {
+		t.Fatalf("--go is taken by the go template; expected only --acme, got %v", acme.Flags)
+	}
+	if docs := byID["docs"]; strings.Join(docs.Flags, ",") != "docs" || !strings.HasSuffix(docs.Origin, "docs.yaml") {
+		t.Fatalf("expected docs template from XDG with its ID as flag, got %+v", docs)
+	}
+	if byID["react"].Origin != embeddedTemplateOrigin {
+		t.Fatalf("expected react to stay embedded, got %q", byID["react"].Origin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	printTemplateList(cli.Context{Stdout: &stdout, Stderr: &stderr})
+	if !strings.Contains(stdout.String(), "from: "+filepath.Join(team, "service.yaml")) || !strings.Contains(stdout.String(), "from: embedded") {
+		t.Fatalf("list should show origins:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "broken.yaml") {
+		t.Fatalf("expected the broken template to be reported, got %q", stderr.String())
+	}
+}
+
+func TestInitUsesUserTemplateAndDetectsItsMarkers(t *testing.T) {
+	team := t.TempDir()
+	writeRepoFiles(t, team, map[string]string{"service.yaml": teamServiceTemplate})
+	t.Setenv(templatePathEnv, team)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	repo := withTempRepo(t)
+	writeRepoFiles(t, repo, map[string]string{"svc/acme.toml": "name = 'svc'\n"})
+
+	var stdout, stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := newInitCommand().Run(ctx, []string{"--acme"}); code != exitOK {
+		t.Fatalf("init --acme exit=%d stderr=%q", code, stderr.String())
+	}
+	cfg, err := config.LoadFile(config.DefaultConfigPath(repo))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 1 || cfg.Checks[0].Run != "make check" || cfg.Meta.Template.ID != "acme-service" {
+		t.Fatalf("unexpected config from user template: %+v", cfg)
+	}
+
+	projects := detectProjects(repo)
+	if len(projects) != 1 || projects[0].TemplateID != "acme-service" || projects[0].Dir != "svc" {
+		t.Fatalf("expected acme-service in svc, got %+v", projects)
+	}
+}
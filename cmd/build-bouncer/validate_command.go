@@ -2,28 +2,31 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/berniemackie97/build-bouncer/internal/cli"
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/runner"
 )
 
 func newValidateCommand() cli.Command {
 	return cli.Command{
 		Name:    "validate",
-		Usage:   "validate [--config PATH]",
+		Usage:   "validate [--config PATH] [--resolved]",
 		Summary: "Validate .buildbouncer/config.yaml (or legacy .buildbouncer.yaml).",
 		Run: func(ctx cli.Context, args []string) int {
 			fs := cli.NewFlagSet(ctx, "validate")
 			cfgPath := fs.String("config", "", "path to .buildbouncer/config.yaml")
+			resolved := fs.Bool("resolved", false, "print the fully resolved config (always on when extends/include are used)")
 			if err := fs.Parse(args); err != nil {
 				return exitUsage
 			}
-			return runValidate(*cfgPath, ctx)
+			return runValidate(*cfgPath, *resolved, ctx)
 		},
 	}
 }
 
-func runValidate(cfgPath string, ctx cli.Context) int {
+func runValidate(cfgPath string, printResolved bool, ctx cli.Context) int {
 	if cfgPath == "" {
 		var err error
 		cfgPath, _, err = config.FindConfigFromCwd()
@@ -41,5 +44,36 @@ func runValidate(cfgPath string, ctx cli.Context) int {
 
 	fmt.Fprintln(ctx.Stdout, "Config OK:", cfgPath)
 	fmt.Fprintf(ctx.Stdout, "Checks: %d\n", len(cfg.Checks))
+	if len(cfg.Parsers) > 0 {
+		// Load has already compiled every parser regex.
+		fmt.Fprintf(ctx.Stdout, "Parsers: %d (regexes compile)\n", len(cfg.Parsers))
+	}
+	matcherFiles := 0
+	for _, check := range cfg.Checks {
+		if _, err := runner.LoadProblemMatchers(config.RootDir(cfgPath), check.ProblemMatchers); err != nil {
+			fmt.Fprintf(ctx.Stderr, "validate: checks %q: %v\n", check.Name, err)
+			return exitUsage
+		}
+		matcherFiles += len(check.ProblemMatchers)
+	}
+	if matcherFiles > 0 {
+		fmt.Fprintf(ctx.Stdout, "Problem matcher files: %d (patterns compile)\n", matcherFiles)
+	}
+
+	composed := len(cfg.Sources) > 1
+	if composed {
+		fmt.Fprintln(ctx.Stdout, "Sources:", strings.Join(cfg.Sources, " -> "))
+	}
+	if !printResolved && !composed {
+		return exitOK
+	}
+
+	out, err := config.MarshalWithOrigins(cfg)
+	if err != nil {
+		fmt.Fprintln(ctx.Stderr, "validate:", err)
+		return exitUsage
+	}
+	fmt.Fprintln(ctx.Stdout, "")
+	fmt.Fprint(ctx.Stdout, string(out))
 	return exitOK
 }
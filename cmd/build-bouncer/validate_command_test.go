@@ -30,7 +30,7 @@ checks:
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
-	if code := runValidate("", ctx); code != exitOK {
+	if code := runValidate("", false, ctx); code != exitOK {
 		t.Fatalf("validate exit=%d stderr=%q", code, stderr.String())
 	}
 	if !strings.Contains(stdout.String(), "Config OK:") {
@@ -40,3 +40,47 @@ checks:
 		t.Fatalf("expected checks count output, got %q", stdout.String())
 	}
 }
+
+func TestValidateCommandPrintsResolvedOrigins(t *testing.T) {
+	repo := withTempRepo(t)
+
+	cfgDir := filepath.Join(repo, ".buildbouncer")
+	if err := os.MkdirAll(filepath.Join(cfgDir, "checks"), 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	shared := `
+checks:
+  - name: "gosec"
+    run: "gosec ./..."
+`
+	if err := os.WriteFile(filepath.Join(cfgDir, "checks", "security.yaml"), []byte(shared), 0o644); err != nil {
+		t.Fatalf("write include: %v", err)
+	}
+	content := `
+version: 1
+include: [.buildbouncer/checks/*.yaml]
+checks:
+  - name: "lint"
+    run: "go vet ./..."
+`
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := cli.Context{Stdout: &stdout, Stderr: &stderr}
+	if code := runValidate("", false, ctx); code != exitOK {
+		t.Fatalf("validate exit=%d stderr=%q", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "Checks: 2") {
+		t.Fatalf("expected resolved checks count, got %q", out)
+	}
+	if !strings.Contains(out, "# origin: .buildbouncer/checks/security.yaml") {
+		t.Fatalf("expected include origin comment, got %q", out)
+	}
+	if !strings.Contains(out, "# origin: .buildbouncer/config.yaml") {
+		t.Fatalf("expected local origin comment, got %q", out)
+	}
+}
@@ -0,0 +1,344 @@
+// Package builtin implements checks that run inside build-bouncer (`builtin: <name>` in
+// config) instead of through a shell, so they behave the same with bash, pwsh and cmd.
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// Env is what a built-in check runs against.
+type Env struct {
+	Root string // repo root
+	Dir  string // the check's working directory (Root joined with cwd)
+	// PushRefs is the pre-push hook input (see git.PushRefsEnv); empty outside the hook.
+	PushRefs string
+	// Output receives anything worth keeping beyond the findings (diffs, progress).
+	Output io.Writer
+	// Environ is the environment for commands a check starts (nil means os.Environ()).
+	Environ []string
+	// Command builds the process for a shell command (a generator, say) the way `run:`
+	// commands are started. Nil falls back to the OS default shell.
+	Command func(ctx context.Context, shell string, command string) *exec.Cmd
+}
+
+func (env Env) environ() []string {
+	if env.Environ != nil {
+		return env.Environ
+	}
+	return os.Environ()
+}
+
+// command builds the process for a shell command run in env.Dir.
+func (env Env) command(ctx context.Context, shell string, command string) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch {
+	case env.Command != nil:
+		cmd = env.Command(ctx, shell, command)
+	case runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	default:
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	if cmd.Env == nil {
+		cmd.Env = env.environ()
+	}
+	cmd.Dir = env.Dir
+	return cmd
+}
+
+// Finding is one problem a built-in check found. File is repo-relative and slash-separated;
+// Line is 0 when the finding is about the whole file.
+type Finding struct {
+	File    string
+	Line    int
+	Rule    string
+	Message string
+}
+
+// String renders the finding as "file:line: message (rule)", the shape the headline and
+// why extractors already recognize.
+func (f Finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	text := f.Message
+	if f.Rule != "" {
+		text += " (" + f.Rule + ")"
+	}
+	if location == "" {
+		return text
+	}
+	return location + ": " + text
+}
+
+// Runner performs a configured check.
+type Runner func(ctx context.Context, env Env) ([]Finding, error)
+
+// Builtin is one registered check kind.
+type Builtin struct {
+	Name    string
+	Summary string
+	// Configure reads the check's `with:` options and returns the check to run. It must
+	// not touch the repo: `validate` calls it to reject bad options early.
+	Configure func(with map[string]any) (Runner, error)
+}
+
+var registry = map[string]Builtin{}
+
+func register(b Builtin) {
+	if _, dup := registry[b.Name]; dup {
+		panic("builtin: duplicate check " + b.Name)
+	}
+	registry[b.Name] = b
+}
+
+// Lookup returns the built-in check called name.
+func Lookup(name string) (Builtin, bool) {
+	b, ok := registry[strings.TrimSpace(name)]
+	return b, ok
+}
+
+// Names lists the registered built-in checks, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that name is registered and its options are usable.
+// It has the signature of config.BuiltinValidator.
+func Validate(name string, with map[string]any) error {
+	b, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown builtin %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	_, err := b.Configure(with)
+	return err
+}
+
+// Run performs check (which must have Builtin set) and writes its findings to
+// env.Output, one per line. It returns the findings and the error, if any, that stopped
+// the check; either one means the check failed.
+func Run(ctx context.Context, check config.Check, env Env) ([]Finding, error) {
+	if env.Output == nil {
+		env.Output = io.Discard
+	}
+	b, ok := Lookup(check.Builtin)
+	if !ok {
+		return nil, fmt.Errorf("unknown builtin %q", check.Builtin)
+	}
+	run, err := b.Configure(check.With)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := run(ctx, env)
+	for _, f := range findings {
+		fmt.Fprintln(env.Output, f.String())
+	}
+	if err != nil {
+		return findings, err
+	}
+	if len(findings) > 0 {
+		fmt.Fprintf(env.Output, "%s: %d %s\n", b.Name, len(findings), plural(len(findings), "finding", "findings"))
+	}
+	return findings, nil
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// options reads a `with:` map. The first type error sticks; done reports it, or any key
+// nothing asked for (typos should fail loudly, as they do in the rest of the config).
+type options struct {
+	with map[string]any
+	used map[string]bool
+	err  error
+}
+
+func newOptions(with map[string]any) *options {
+	return &options{with: with, used: map[string]bool{}}
+}
+
+func (o *options) value(key string) (any, bool) {
+	o.used[key] = true
+	v, ok := o.with[key]
+	return v, ok && v != nil
+}
+
+func (o *options) fail(key string, want string) {
+	if o.err == nil {
+		o.err = fmt.Errorf("with.%s: expected %s", key, want)
+	}
+}
+
+func (o *options) string(key string, def string) string {
+	v, ok := o.value(key)
+	if !ok {
+		return def
+	}
+	s, isString := v.(string)
+	if !isString {
+		o.fail(key, "a string")
+		return def
+	}
+	return strings.TrimSpace(s)
+}
+
+// list accepts a single string or a list of them.
+func (o *options) list(key string) []string {
+	v, ok := o.value(key)
+	if !ok {
+		return nil
+	}
+	switch val := v.(type) {
+	case string:
+		if s := strings.TrimSpace(val); s != "" {
+			return []string{s}
+		}
+		return nil
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			s, isString := item.(string)
+			if !isString {
+				o.fail(key, "a list of strings")
+				return nil
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return val
+	}
+	o.fail(key, "a string or a list of strings")
+	return nil
+}
+
+func (o *options) bool(key string, def bool) bool {
+	v, ok := o.value(key)
+	if !ok {
+		return def
+	}
+	b, isBool := v.(bool)
+	if !isBool {
+		o.fail(key, "true or false")
+		return def
+	}
+	return b
+}
+
+func (o *options) int(key string, def int) int {
+	v, ok := o.value(key)
+	if !ok {
+		return def
+	}
+	n, isInt := v.(int)
+	if !isInt || n < 0 {
+		o.fail(key, "a whole number")
+		return def
+	}
+	return n
+}
+
+func (o *options) float(key string, def float64) float64 {
+	v, ok := o.value(key)
+	if !ok {
+		return def
+	}
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	o.fail(key, "a number")
+	return def
+}
+
+// size reads a byte count: a number, or a string like "512KB", "5MB" or "1.5GB".
+func (o *options) size(key string, def int64) int64 {
+	v, ok := o.value(key)
+	if !ok {
+		return def
+	}
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case string:
+		if size, err := parseSize(n); err == nil {
+			return size
+		}
+	}
+	o.fail(key, `a size such as 500KB or 5MB`)
+	return def
+}
+
+func parseSize(text string) (int64, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix string
+		bytes  float64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", text)
+	}
+	return int64(n * multiplier), nil
+}
+
+// formatSize renders a byte count the way sizes are written in `with:`.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return strconv.FormatFloat(float64(n)/(1<<30), 'f', 1, 64) + "GB"
+	case n >= 1<<20:
+		return strconv.FormatFloat(float64(n)/(1<<20), 'f', 1, 64) + "MB"
+	case n >= 1<<10:
+		return strconv.FormatFloat(float64(n)/(1<<10), 'f', 1, 64) + "KB"
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+func (o *options) done() error {
+	if o.err != nil {
+		return o.err
+	}
+	var unknown []string
+	for key := range o.with {
+		if !o.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown option(s) in with: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
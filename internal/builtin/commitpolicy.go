@@ -0,0 +1,223 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+// defaultConventionalTypes are the types the Conventional Commits tooling
+// (commitlint's config-conventional) accepts out of the box.
+var defaultConventionalTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// defaultProtectedBranches get no fixup!/WIP commits.
+var defaultProtectedBranches = []string{"main", "master", "release/*"}
+
+var (
+	conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: \S`)
+	unfinishedSubject   = regexp.MustCompile(`(?i)^(fixup!|squash!|amend!|\[?wip\b)`)
+	trailerLine         = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*\S`)
+)
+
+func init() {
+	register(Builtin{
+		Name:      "commit-policy",
+		Summary:   "Check pushed commit messages and branch names (Conventional Commits, trailers, ...)",
+		Configure: configureCommitPolicy,
+	})
+}
+
+// commitPolicy is a configured commit-policy check.
+type commitPolicy struct {
+	pattern      *regexp.Regexp
+	conventional bool
+	types        []string
+	scopes       []string
+	maxSubject   int
+	trailers     []string
+	protected    []string
+	branch       *regexp.Regexp
+}
+
+func configureCommitPolicy(with map[string]any) (Runner, error) {
+	p, err := newCommitPolicy(with)
+	if err != nil {
+		return nil, err
+	}
+	return p.run, nil
+}
+
+func newCommitPolicy(with map[string]any) (*commitPolicy, error) {
+	opts := newOptions(with)
+	pattern := opts.string("pattern", "")
+	conventional := opts.bool("conventional", false)
+	types := opts.list("types")
+	scopes := opts.list("scopes")
+	maxSubject := opts.int("max-subject", 0)
+	trailers := opts.list("trailers")
+	protected := opts.list("protected")
+	branch := opts.string("branch", "")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+
+	p := &commitPolicy{
+		conventional: conventional || len(types) > 0 || len(scopes) > 0,
+		types:        types,
+		scopes:       scopes,
+		maxSubject:   maxSubject,
+		trailers:     trailers,
+		protected:    protected,
+	}
+	if len(p.types) == 0 {
+		p.types = defaultConventionalTypes
+	}
+	if _, isSet := with["protected"]; !isSet {
+		p.protected = defaultProtectedBranches
+	}
+	for _, glob := range p.protected {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("with.protected: invalid glob %q", glob)
+		}
+	}
+	var err error
+	if pattern != "" {
+		if p.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("with.pattern: %w", err)
+		}
+	}
+	if branch != "" {
+		if p.branch, err = regexp.Compile(branch); err != nil {
+			return nil, fmt.Errorf("with.branch: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *commitPolicy) run(ctx context.Context, env Env) ([]Finding, error) {
+	var findings []Finding
+	if p.branch != nil {
+		for _, branch := range git.PushBranches(env.Root, env.PushRefs) {
+			// Protected branches are named by the team, not by the branch-name policy.
+			if !p.isProtected(branch) && !p.branch.MatchString(branch) {
+				findings = append(findings, Finding{File: branch, Rule: "branch", Message: fmt.Sprintf("branch name does not match %q", p.branch.String())})
+			}
+		}
+	}
+
+	commits, ok := git.PushCommits(env.Root, env.PushRefs)
+	if !ok {
+		fmt.Fprintln(env.Output, "commit-policy: cannot tell which commits are being pushed; only the branch name was checked")
+		return findings, nil
+	}
+	for _, commit := range commits {
+		if err := ctx.Err(); err != nil {
+			return findings, err
+		}
+		for _, problem := range p.checkMessage(commit) {
+			problem.File = shortSHA(commit.SHA)
+			findings = append(findings, problem)
+		}
+	}
+	return findings, nil
+}
+
+// checkMessage returns the commit's violations, with File left for the caller.
+func (p *commitPolicy) checkMessage(commit git.Commit) []Finding {
+	subject, body, _ := strings.Cut(commit.Message, "\n")
+	subject = strings.TrimSpace(subject)
+	quoted := quoteSubject(subject)
+
+	if unfinishedSubject.MatchString(subject) {
+		// fixup!/WIP commits get squashed before they land, so only protected branches
+		// care about them, and their subjects are not held to the other rules.
+		if p.isProtected(commit.Branch) {
+			return []Finding{{Rule: "fixup", Message: fmt.Sprintf("unfinished commit %s pushed to protected branch %s", quoted, commit.Branch)}}
+		}
+		return nil
+	}
+
+	var problems []Finding
+	if p.pattern != nil && !p.pattern.MatchString(subject) {
+		problems = append(problems, Finding{Rule: "pattern", Message: fmt.Sprintf("subject %s does not match %q", quoted, p.pattern.String())})
+	}
+	if p.conventional {
+		if problem := p.checkConventional(subject); problem != "" {
+			problems = append(problems, Finding{Rule: "conventional", Message: fmt.Sprintf("subject %s %s", quoted, problem)})
+		}
+	}
+	if p.maxSubject > 0 && len([]rune(subject)) > p.maxSubject {
+		problems = append(problems, Finding{Rule: "max-subject", Message: fmt.Sprintf("subject %s is %d characters, over the %d limit", quoted, len([]rune(subject)), p.maxSubject)})
+	}
+	if len(p.trailers) > 0 {
+		present := messageTrailers(body)
+		for _, trailer := range p.trailers {
+			if !present[strings.ToLower(trailer)] {
+				problems = append(problems, Finding{Rule: "trailers", Message: fmt.Sprintf("commit %s has no %s trailer", quoted, trailer)})
+			}
+		}
+	}
+	return problems
+}
+
+// checkConventional returns what is wrong with subject as a Conventional Commit, or "".
+func (p *commitPolicy) checkConventional(subject string) string {
+	m := conventionalSubject.FindStringSubmatch(subject)
+	if m == nil {
+		return `is not a Conventional Commit ("type(scope): description")`
+	}
+	kind, scope := strings.ToLower(m[1]), m[2]
+	if !slices.Contains(p.types, kind) {
+		return fmt.Sprintf("has type %q (allowed: %s)", kind, strings.Join(p.types, ", "))
+	}
+	if len(p.scopes) > 0 && scope != "" && !slices.Contains(p.scopes, scope) {
+		return fmt.Sprintf("has scope %q (allowed: %s)", scope, strings.Join(p.scopes, ", "))
+	}
+	return ""
+}
+
+func (p *commitPolicy) isProtected(branch string) bool {
+	if branch == "" {
+		return false
+	}
+	for _, glob := range p.protected {
+		if ok, _ := path.Match(glob, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// messageTrailers returns the lower-cased trailer keys ("signed-off-by") of a commit
+// body: the "Key: value" lines of its last paragraph, as `git interpret-trailers` sees them.
+func messageTrailers(body string) map[string]bool {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n")), "\n\n")
+	keys := map[string]bool{}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if m := trailerLine.FindStringSubmatch(line); m != nil {
+			keys[strings.ToLower(m[1])] = true
+		}
+	}
+	return keys
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// quoteSubject quotes a subject for a finding, cut short so findings stay on one line.
+func quoteSubject(subject string) string {
+	const maxQuoted = 60
+	if runes := []rune(subject); len(runes) > maxQuoted {
+		subject = string(runes[:maxQuoted-3]) + "..."
+	}
+	return fmt.Sprintf("%q", subject)
+}
@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+func TestCommitPolicyMessageRules(t *testing.T) {
+	p, err := newCommitPolicy(map[string]any{
+		"types":       []any{"feat", "fix"},
+		"scopes":      []any{"api", "web"},
+		"max-subject": 30,
+		"trailers":    "Signed-off-by",
+	})
+	if err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+
+	signed := "\n\nSigned-off-by: Dev <dev@example.com>"
+	cases := []struct {
+		message string
+		branch  string
+		rules   string
+	}{
+		{"feat(api): add pagination" + signed, "feature/x", ""},
+		{"fix!: drop v1 endpoint" + signed, "feature/x", ""},
+		{"Feat(web): capitalised type" + signed, "feature/x", ""},
+		{"feat(db): add index" + signed, "feature/x", "conventional"},
+		{"docs: explain setup" + signed, "feature/x", "conventional"},
+		{"update stuff" + signed, "feature/x", "conventional"},
+		{"feat(api): add a much longer subject line than allowed" + signed, "feature/x", "max-subject"},
+		{"feat(api): unsigned\n\nSome body text.", "feature/x", "trailers"},
+		{"fixup! feat(api): add pagination", "feature/x", ""},
+		{"fixup! feat(api): add pagination", "main", "fixup"},
+		{"WIP: half done", "release/2.0", "fixup"},
+		{"Wipe caches", "main", "conventional,trailers"},
+	}
+	for _, tc := range cases {
+		var rules []string
+		for _, f := range p.checkMessage(git.Commit{SHA: "abc", Branch: tc.branch, Message: tc.message}) {
+			rules = append(rules, f.Rule)
+		}
+		if got := strings.Join(rules, ","); got != tc.rules {
+			t.Errorf("%q on %s: rules %q, want %q", tc.message, tc.branch, got, tc.rules)
+		}
+	}
+}
+
+func TestCommitPolicyChecksPushedCommitsAndBranches(t *testing.T) {
+	root := newGitRepo(t)
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "legacy commit from before the policy")
+	base := gitRun(t, root, "rev-parse", "HEAD")
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "feat: add login")
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "tweak login")
+	bad := gitRun(t, root, "rev-parse", "HEAD")
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "fixup! feat: add login")
+	head := gitRun(t, root, "rev-parse", "HEAD")
+
+	with := map[string]any{"conventional": true, "branch": `^feature/[A-Z]+-\d+-`}
+	env := Env{Root: root, Dir: root, PushRefs: strings.Join([]string{
+		"refs/heads/work " + head + " refs/heads/feature/JIRA-12-login " + base,
+		"refs/heads/work " + head + " refs/heads/login " + base,
+	}, "\n")}
+	want := strings.Join([]string{
+		`login: branch name does not match "^feature/[A-Z]+-\\d+-" (branch)`,
+		bad[:12] + `: subject "tweak login" is not a Conventional Commit ("type(scope): description") (conventional)`,
+	}, "\n")
+	if got := runFindings(t, env, "commit-policy", with); got != want {
+		t.Fatalf("findings:\n%s\nwant:\n%s", got, want)
+	}
+
+	env.PushRefs = "refs/heads/work " + head + " refs/heads/main " + base
+	if got := runFindings(t, env, "commit-policy", with); !strings.Contains(got, head[:12]+`: unfinished commit "fixup! feat: add login" pushed to protected branch main (fixup)`) {
+		t.Fatalf("expected a fixup finding on main, got:\n%s", got)
+	}
+}
+
+func TestCommitPolicyValidatesOptions(t *testing.T) {
+	for _, with := range []map[string]any{
+		{"pattern": "("},
+		{"branch": "[a-"},
+		{"max-subject": "72"},
+		{"protected": "["},
+		{"trailer": "Signed-off-by"},
+	} {
+		if err := Validate("commit-policy", with); err == nil {
+			t.Errorf("expected %v to be rejected", with)
+		}
+	}
+}
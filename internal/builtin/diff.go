@@ -0,0 +1,209 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+// maxScanFileBytes keeps full-tree scans away from large assets and dumps.
+const maxScanFileBytes = 2 << 20
+
+// addedLine is one line the push adds. File is repo-relative and slash-separated; Text
+// has no line ending.
+type addedLine struct {
+	File string
+	Line int
+	Text string
+	// CRLF is set when the line ended in "\r\n"; NoFinalNewline when it is the last
+	// line of the file and has no line ending at all.
+	CRLF           bool
+	NoFinalNewline bool
+}
+
+// pushedLines returns the lines the push adds (see git.PushDiff). When the push cannot
+// be worked out (no upstream, a CI checkout) every line of every tracked file counts,
+// the same way checks with `paths:` run when the changes are unknown. Only files under
+// env.Dir for which keep returns true are read.
+func pushedLines(ctx context.Context, env Env, keep func(file string) bool) ([]addedLine, error) {
+	scope := dirScope(env)
+	want := func(file string) bool {
+		return file != "" && (scope == "" || strings.HasPrefix(file, scope)) && keep(file)
+	}
+
+	if patch, ok := git.PushDiff(env.Root, env.PushRefs); ok {
+		return parseAddedLines(patch, want), nil
+	}
+
+	files, err := git.TrackedFiles(env.Root)
+	if err != nil {
+		return nil, err
+	}
+	var lines []addedLine
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !want(file) {
+			continue
+		}
+		data, ok := readTextFile(filepath.Join(env.Root, filepath.FromSlash(file)))
+		if !ok {
+			continue
+		}
+		lines = append(lines, fileLines(file, string(data))...)
+	}
+	return lines, nil
+}
+
+// pushedFiles returns the files the push changes that still exist, or every tracked
+// file when the push cannot be worked out. Only files under env.Dir for which keep
+// returns true are listed.
+func pushedFiles(env Env, keep func(file string) bool) ([]string, error) {
+	files, ok := git.ChangedFiles(env.Root, env.PushRefs)
+	if !ok {
+		var err error
+		if files, err = git.TrackedFiles(env.Root); err != nil {
+			return nil, err
+		}
+	}
+	scope := dirScope(env)
+	var out []string
+	for _, file := range files {
+		if scope != "" && !strings.HasPrefix(file, scope) || !keep(file) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(env.Root, filepath.FromSlash(file))); err != nil {
+			continue
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
+
+// excludeFilter compiles `with.exclude` globs (paths: syntax) into a keep function.
+func excludeFilter(patterns []string) (func(file string) bool, error) {
+	if len(patterns) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	filter, err := config.CompilePathFilter(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return func(file string) bool { return !filter.Match(file) }, nil
+}
+
+// fileLines splits a whole file into lines, as if every one of them were added.
+func fileLines(file string, content string) []addedLine {
+	if content == "" {
+		return nil
+	}
+	parts := strings.Split(content, "\n")
+	last := len(parts) - 1
+	noFinalNewline := parts[last] != ""
+	if !noFinalNewline {
+		parts = parts[:last]
+	}
+	lines := make([]addedLine, 0, len(parts))
+	for i, text := range parts {
+		line := addedLine{File: file, Line: i + 1, Text: strings.TrimSuffix(text, "\r"), CRLF: strings.HasSuffix(text, "\r")}
+		if i == len(parts)-1 {
+			line.NoFinalNewline = noFinalNewline
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// dirScope is env.Dir relative to the root with a trailing slash, or "" for the root.
+func dirScope(env Env) string {
+	if env.Dir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(env.Root, env.Dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// readTextFile reads a file unless it is too large or looks binary.
+func readTextFile(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxScanFileBytes {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || isBinary(data) {
+		return nil, false
+	}
+	return data, true
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// parseAddedLines reads the "+" lines out of a zero-context patch (`diff -U0` or
+// `log -p -U0`), numbered as in the new file.
+func parseAddedLines(patch string, want func(file string) bool) []addedLine {
+	var out []addedLine
+	file := ""
+	next := 0
+	inHunk := false
+	lastAdded := false // whether the previous patch line was a kept "+" line
+	for _, line := range strings.Split(patch, "\n") {
+		wasAdded := lastAdded
+		lastAdded = false
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			file, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(line, "+++ ")
+			if file == "/dev/null" {
+				file = ""
+			}
+			file = strings.TrimPrefix(file, "b/")
+		case strings.HasPrefix(line, "@@ "):
+			next, inHunk = hunkStart(line), true
+		case inHunk && strings.HasPrefix(line, "+"):
+			if want(file) {
+				text := line[1:]
+				out = append(out, addedLine{File: file, Line: next, Text: strings.TrimSuffix(text, "\r"), CRLF: strings.HasSuffix(text, "\r")})
+				lastAdded = true
+			}
+			next++
+		case inHunk && strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file" after an added line: that line ends the file.
+			if wasAdded {
+				out[len(out)-1].NoFinalNewline = true
+			}
+		case inHunk && strings.HasPrefix(line, " "):
+			next++
+		}
+	}
+	return out
+}
+
+// hunkStart returns the new-file line number of a "@@ -a,b +c,d @@" header.
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,218 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+// Checks for the two classic Go "works on my machine" pushes: a go.mod/go.sum that
+// `go mod tidy` would change, and generated code that is older than its generator.
+
+func init() {
+	register(Builtin{
+		Name:      "go-mod-tidy",
+		Summary:   "Fail when `go mod tidy` would change go.mod or go.sum",
+		Configure: configureGoModTidy,
+	})
+	register(Builtin{
+		Name:      "generated-fresh",
+		Summary:   "Fail when a generator (with.run) changes tracked files",
+		Configure: configureGeneratedFresh,
+	})
+}
+
+func configureGoModTidy(with map[string]any) (Runner, error) {
+	opts := newOptions(with)
+	modules := opts.list("modules")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		dirs, err := goModules(env, modules)
+		if err != nil {
+			return nil, err
+		}
+		tmp, err := os.MkdirTemp("", "build-bouncer-tidy-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+
+		var findings []Finding
+		for _, dir := range dirs {
+			changed, err := tidyModule(ctx, env, tmp, dir)
+			if err != nil {
+				return findings, err
+			}
+			for _, file := range changed {
+				findings = append(findings, Finding{File: file, Rule: "go-mod-tidy", Message: "not tidy; run `go mod tidy`"})
+			}
+		}
+		return findings, nil
+	}, nil
+}
+
+// tidyModule runs `go mod tidy` on copies of dir's go.mod and go.sum (tmp/b/<dir>, via
+// -modfile) so the working tree is never touched, writes the diff against the originals
+// (tmp/a/<dir>) to env.Output and returns the files that would change.
+func tidyModule(ctx context.Context, env Env, tmp string, dir string) ([]string, error) {
+	names := []string{"go.mod", "go.sum"}
+	for _, side := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(tmp, side, filepath.FromSlash(dir)), 0o755); err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			data, err := os.ReadFile(filepath.Join(env.Root, filepath.FromSlash(dir), name))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(tmp, side, filepath.FromSlash(dir), name), data, 0o644); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "mod", "tidy", "-modfile="+filepath.Join(tmp, "b", filepath.FromSlash(dir), "go.mod"))
+	cmd.Dir = filepath.Join(env.Root, filepath.FromSlash(dir))
+	// A go.work would make tidy consider the whole workspace.
+	cmd.Env = append(env.environ(), "GOWORK=off")
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		_, _ = env.Output.Write(output.Bytes())
+		return nil, fmt.Errorf("go mod tidy in %s: %w", dir, err)
+	}
+
+	var changed []string
+	for _, name := range names {
+		file := path.Join(dir, name)
+		before, _ := os.ReadFile(filepath.Join(tmp, "a", filepath.FromSlash(file)))
+		after, _ := os.ReadFile(filepath.Join(tmp, "b", filepath.FromSlash(file)))
+		if bytes.Equal(before, after) {
+			continue
+		}
+		changed = append(changed, file)
+		diff, err := git.DiffNoIndex(tmp, path.Join("a", file), path.Join("b", file))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(env.Output, diff)
+	}
+	return changed, nil
+}
+
+// goModules returns the repo-relative directories of the modules to check: the ones
+// named in with.modules (relative to the check's cwd), or else every tracked go.mod
+// under the cwd outside vendor/ and testdata/.
+func goModules(env Env, modules []string) ([]string, error) {
+	var dirs []string
+	if len(modules) > 0 {
+		for _, module := range modules {
+			full := filepath.Join(env.Dir, filepath.FromSlash(module))
+			rel, err := filepath.Rel(env.Root, full)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("with.modules: %s is outside the repo", module)
+			}
+			if _, err := os.Stat(filepath.Join(full, "go.mod")); err != nil {
+				return nil, fmt.Errorf("with.modules: no go.mod in %s", module)
+			}
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return dirs, nil
+	}
+
+	files, err := git.TrackedFiles(env.Root)
+	if err != nil {
+		return nil, err
+	}
+	scope := dirScope(env)
+	for _, file := range files {
+		if path.Base(file) != "go.mod" || (scope != "" && !strings.HasPrefix(file, scope)) {
+			continue
+		}
+		if inDir("vendor", file) || inDir("testdata", file) {
+			continue
+		}
+		dirs = append(dirs, path.Dir(file))
+	}
+	if len(dirs) == 0 {
+		return nil, errors.New("no go.mod found")
+	}
+	return dirs, nil
+}
+
+// inDir reports whether a slash-separated path has a directory called name.
+func inDir(name string, file string) bool {
+	return strings.HasPrefix(file, name+"/") || strings.Contains(file, "/"+name+"/")
+}
+
+func configureGeneratedFresh(with map[string]any) (Runner, error) {
+	opts := newOptions(with)
+	command := opts.string("run", "")
+	shell := opts.string("shell", "")
+	exclude := opts.list("exclude")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	if command == "" {
+		return nil, errors.New("with.run: the generator command is required")
+	}
+	keep, err := excludeFilter(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("with.exclude: %w", err)
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		return runGenerator(ctx, env, shell, command, keep)
+	}, nil
+}
+
+// runGenerator runs command, reports the files it changed or created, and puts the
+// working tree back the way it was, uncommitted changes included.
+func runGenerator(ctx context.Context, env Env, shell string, command string, keep func(string) bool) ([]Finding, error) {
+	snapshot, err := git.SnapshotWorktree(env.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := env.command(ctx, shell, command)
+	cmd.Stdout = env.Output
+	cmd.Stderr = env.Output
+	runErr := cmd.Run()
+
+	changes, err := snapshot.Changes()
+	if err != nil {
+		return nil, err
+	}
+	diff, diffErr := git.WorktreeDiff(env.Root, changes.Changed)
+	fmt.Fprint(env.Output, diff)
+
+	var findings []Finding
+	add := func(files []string, message string) {
+		for _, file := range files {
+			if keep(file) {
+				findings = append(findings, Finding{File: file, Rule: "generated-fresh", Message: message})
+			}
+		}
+	}
+	add(changes.Changed, "changed by the generator; commit the regenerated file")
+	add(changes.Edited, "changed by the generator (it already had uncommitted changes)")
+	add(changes.Created, "created by the generator; commit it or ignore it")
+
+	if err := snapshot.Restore(changes); err != nil {
+		return findings, fmt.Errorf("restoring the working tree: %w", err)
+	}
+	if runErr != nil {
+		return findings, fmt.Errorf("generator failed: %w", runErr)
+	}
+	return findings, diffErr
+}
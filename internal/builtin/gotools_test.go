@@ -0,0 +1,103 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func TestGoModTidyReportsDriftWithoutTouchingTheTree(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	root := newGitRepo(t)
+	untidy := "module example.com/untidy\ngo 1.21\n"
+	writeRepoFile(t, root, "go.mod", "module example.com/root\n\ngo 1.21\n")
+	writeRepoFile(t, root, "main.go", "package main\n\nfunc main() {}\n")
+	writeRepoFile(t, root, "tools/go.mod", untidy)
+	writeRepoFile(t, root, "tools/tool.go", "package tools\n")
+	writeRepoFile(t, root, "testdata/broken/go.mod", "not a go.mod\n")
+	gitRun(t, root, "add", "-A")
+	gitRun(t, root, "commit", "-q", "-m", "base")
+
+	var out bytes.Buffer
+	env := Env{Root: root, Dir: root, Output: &out, Environ: append(os.Environ(), "GOPROXY=off", "GOTOOLCHAIN=local", "GOFLAGS=")}
+	findings, err := Run(context.Background(), config.Check{Name: "tidy", Builtin: "go-mod-tidy"}, env)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	if len(findings) != 1 || findings[0].String() != "tools/go.mod: not tidy; run `go mod tidy` (go-mod-tidy)" {
+		t.Fatalf("unexpected findings %v\n%s", findings, out.String())
+	}
+	if !strings.Contains(out.String(), "--- a/tools/go.mod\n+++ b/tools/go.mod") {
+		t.Fatalf("expected a diff of tools/go.mod in the output:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "tools", "go.mod")); string(data) != untidy {
+		t.Fatalf("go.mod was modified: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "tools", "go.sum")); err == nil {
+		t.Fatalf("go.sum should not have been created")
+	}
+}
+
+func TestGeneratedFreshReportsAndRestoresChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("generator fixture uses sh")
+	}
+	root := newGitRepo(t)
+	writeRepoFile(t, root, "gen/api.go", "// v1\n")
+	writeRepoFile(t, root, "gen/stable.go", "// stable\n")
+	writeRepoFile(t, root, "notes.txt", "committed\n")
+	gitRun(t, root, "add", "-A")
+	gitRun(t, root, "commit", "-q", "-m", "base")
+	writeRepoFile(t, root, "notes.txt", "work in progress\n")
+	writeRepoFile(t, root, "scratch.txt", "mine\n")
+
+	generator := `printf '// v2\n' > gen/api.go; printf '// stable\n' > gen/stable.go; printf 'new\n' > gen/extra.go; printf 'regenerated\n' > notes.txt; echo generated`
+	var out bytes.Buffer
+	env := Env{Root: root, Dir: root, Output: &out}
+	check := config.Check{Name: "gen", Builtin: "generated-fresh", With: map[string]any{"run": generator}}
+	findings, err := Run(context.Background(), check, env)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	want := []string{
+		"gen/api.go: changed by the generator; commit the regenerated file (generated-fresh)",
+		"notes.txt: changed by the generator (it already had uncommitted changes) (generated-fresh)",
+		"gen/extra.go: created by the generator; commit it or ignore it (generated-fresh)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if text := out.String(); !strings.Contains(text, "generated\n") || !strings.Contains(text, "-// v1\n+// v2\n") {
+		t.Fatalf("expected generator output and diff:\n%s", text)
+	}
+
+	for rel, content := range map[string]string{"gen/api.go": "// v1\n", "notes.txt": "work in progress\n", "scratch.txt": "mine\n"} {
+		if data, _ := os.ReadFile(filepath.Join(root, rel)); string(data) != content {
+			t.Errorf("%s = %q after the run, want %q", rel, data, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "gen", "extra.go")); err == nil {
+		t.Errorf("gen/extra.go should have been removed")
+	}
+
+	check.With = map[string]any{"run": "true"}
+	if findings, err := Run(context.Background(), check, env); err != nil || len(findings) != 0 {
+		t.Fatalf("a no-op generator should pass, got %v, %v", findings, err)
+	}
+	if err := Validate("generated-fresh", nil); err == nil {
+		t.Fatalf("expected with.run to be required")
+	}
+}
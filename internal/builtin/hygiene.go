@@ -0,0 +1,378 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+// Repository hygiene checks: cheap, and easy to get subtly wrong as shell one-liners
+// that have to work in bash, pwsh and cmd alike.
+
+const defaultMaxFileSize = 5 << 20
+
+// defaultLFSPatterns are file types that bloat a repo's history when committed directly.
+var defaultLFSPatterns = []string{
+	"*.psd", "*.ai", "*.sketch", "*.fig", "*.blend", "*.fbx", "*.unitypackage",
+	"*.zip", "*.7z", "*.rar", "*.tar", "*.tar.gz", "*.tgz", "*.iso", "*.dmg",
+	"*.mp4", "*.mov", "*.avi", "*.mkv", "*.mp3", "*.wav", "*.flac",
+	"*.exe", "*.dll", "*.so", "*.dylib", "*.jar", "*.nupkg",
+}
+
+// defaultCRLFPatterns are files Windows tools expect with CRLF whatever the policy.
+var defaultCRLFPatterns = []string{"*.bat", "*.cmd"}
+
+func init() {
+	register(Builtin{
+		Name:      "conflict-markers",
+		Summary:   "Block merge conflict markers (<<<<<<<, |||||||, >>>>>>>)",
+		Configure: configureConflictMarkers,
+	})
+	register(Builtin{
+		Name:      "large-files",
+		Summary:   "Block files over a size limit (with.max, default 5MB)",
+		Configure: configureLargeFiles,
+	})
+	register(Builtin{
+		Name:      "lfs",
+		Summary:   "Require Git LFS for binary assets and archives",
+		Configure: configureLFS,
+	})
+	register(Builtin{
+		Name:      "case-collisions",
+		Summary:   "Block paths that differ only in case (they clash on macOS and Windows)",
+		Configure: configureCaseCollisions,
+	})
+	register(Builtin{
+		Name:      "broken-symlinks",
+		Summary:   "Block symlinks whose target does not exist",
+		Configure: configureBrokenSymlinks,
+	})
+	register(Builtin{
+		Name:      "line-endings",
+		Summary:   "Enforce LF or CRLF line endings (with.eol)",
+		Configure: configureLineEndings,
+	})
+	register(Builtin{
+		Name:      "final-newline",
+		Summary:   "Require a newline at the end of text files",
+		Configure: configureFinalNewline,
+	})
+}
+
+// configureExcludeOnly is for checks whose only option is `exclude`.
+func configureExcludeOnly(with map[string]any) (func(file string) bool, error) {
+	opts := newOptions(with)
+	exclude := opts.list("exclude")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	keep, err := excludeFilter(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("with.exclude: %w", err)
+	}
+	return keep, nil
+}
+
+var conflictMarkers = []string{"<<<<<<<", "|||||||", ">>>>>>>"}
+
+func configureConflictMarkers(with map[string]any) (Runner, error) {
+	keep, err := configureExcludeOnly(with)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		lines, err := pushedLines(ctx, env, keep)
+		if err != nil {
+			return nil, err
+		}
+		var findings []Finding
+		for _, line := range lines {
+			for _, marker := range conflictMarkers {
+				// "=======" is left out: it is also a Markdown/RST underline.
+				if line.Text == marker || strings.HasPrefix(line.Text, marker+" ") {
+					findings = append(findings, Finding{File: line.File, Line: line.Line, Rule: "conflict-markers", Message: "merge conflict marker " + marker})
+					break
+				}
+			}
+		}
+		return findings, nil
+	}, nil
+}
+
+func configureLargeFiles(with map[string]any) (Runner, error) {
+	opts := newOptions(with)
+	limit := opts.size("max", defaultMaxFileSize)
+	exclude := opts.list("exclude")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	keep, err := excludeFilter(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("with.exclude: %w", err)
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		files, err := pushedFiles(env, keep)
+		if err != nil {
+			return nil, err
+		}
+		// LFS files are small pointers in git; only the checkout is large.
+		filters, err := git.CheckAttr(env.Root, "filter", files)
+		if err != nil {
+			return nil, err
+		}
+		var findings []Finding
+		for _, file := range files {
+			if filters[file] == "lfs" {
+				continue
+			}
+			info, err := os.Lstat(filepath.Join(env.Root, filepath.FromSlash(file)))
+			if err != nil || !info.Mode().IsRegular() || info.Size() <= limit {
+				continue
+			}
+			findings = append(findings, Finding{File: file, Rule: "large-files", Message: fmt.Sprintf("%s is over the %s limit", formatSize(info.Size()), formatSize(limit))})
+		}
+		return findings, nil
+	}, nil
+}
+
+func configureLFS(with map[string]any) (Runner, error) {
+	opts := newOptions(with)
+	patterns := opts.list("patterns")
+	limit := opts.size("max", 0)
+	exclude := opts.list("exclude")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	if _, isSet := with["patterns"]; !isSet {
+		patterns = defaultLFSPatterns
+	}
+	keep, err := excludeFilter(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("with.exclude: %w", err)
+	}
+	match, err := optionalPathFilter("patterns", patterns)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		files, err := pushedFiles(env, keep)
+		if err != nil {
+			return nil, err
+		}
+		filters, err := git.CheckAttr(env.Root, "filter", files)
+		if err != nil {
+			return nil, err
+		}
+		var findings []Finding
+		for _, file := range files {
+			if filters[file] == "lfs" {
+				continue
+			}
+			reason := ""
+			if match(file) {
+				reason = "should be tracked with Git LFS"
+			} else if limit > 0 {
+				info, err := os.Lstat(filepath.Join(env.Root, filepath.FromSlash(file)))
+				if err == nil && info.Mode().IsRegular() && info.Size() > limit {
+					reason = fmt.Sprintf("is %s; files over %s should be tracked with Git LFS", formatSize(info.Size()), formatSize(limit))
+				}
+			}
+			if reason == "" {
+				continue
+			}
+			hint := "*" + path.Ext(file)
+			if path.Ext(file) == "" {
+				hint = file
+			}
+			findings = append(findings, Finding{File: file, Rule: "lfs", Message: fmt.Sprintf("%s (git lfs track %q)", reason, hint)})
+		}
+		return findings, nil
+	}, nil
+}
+
+func configureCaseCollisions(with map[string]any) (Runner, error) {
+	keep, err := configureExcludeOnly(with)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		// A collision is between any two tracked paths, not just the pushed ones.
+		files, err := git.TrackedFiles(env.Root)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		scope := dirScope(env)
+		seen := map[string]string{} // lower-cased path or directory -> first spelling
+		reported := map[string]bool{}
+		var findings []Finding
+		for _, file := range files {
+			if !keep(file) {
+				continue
+			}
+			parts := strings.Split(file, "/")
+			for i := range parts {
+				p := strings.Join(parts[:i+1], "/")
+				lower := strings.ToLower(p)
+				first, ok := seen[lower]
+				if !ok {
+					seen[lower] = p
+					continue
+				}
+				if first == p || reported[p] || (scope != "" && !strings.HasPrefix(p, scope)) {
+					continue
+				}
+				reported[p] = true
+				findings = append(findings, Finding{File: p, Rule: "case-collisions", Message: "differs only in case from " + first})
+			}
+		}
+		return findings, nil
+	}, nil
+}
+
+func configureBrokenSymlinks(with map[string]any) (Runner, error) {
+	keep, err := configureExcludeOnly(with)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		files, err := pushedFiles(env, keep)
+		if err != nil {
+			return nil, err
+		}
+		var findings []Finding
+		for _, file := range files {
+			full := filepath.Join(env.Root, filepath.FromSlash(file))
+			info, err := os.Lstat(full)
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				continue
+			}
+			if _, err := os.Stat(full); err == nil {
+				continue
+			}
+			target, _ := os.Readlink(full)
+			findings = append(findings, Finding{File: file, Rule: "broken-symlinks", Message: fmt.Sprintf("symlink target %q does not exist", target)})
+		}
+		return findings, nil
+	}, nil
+}
+
+func configureLineEndings(with map[string]any) (Runner, error) {
+	opts := newOptions(with)
+	eol := strings.ToLower(opts.string("eol", "lf"))
+	crlf := opts.list("crlf")
+	lf := opts.list("lf")
+	exclude := opts.list("exclude")
+	if err := opts.done(); err != nil {
+		return nil, err
+	}
+	if eol != "lf" && eol != "crlf" {
+		return nil, fmt.Errorf("with.eol: expected lf or crlf, got %q", eol)
+	}
+	if _, isSet := with["crlf"]; !isSet {
+		crlf = defaultCRLFPatterns
+	}
+	keep, err := excludeFilter(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("with.exclude: %w", err)
+	}
+	wantCRLF, err := optionalPathFilter("crlf", crlf)
+	if err != nil {
+		return nil, err
+	}
+	wantLF, err := optionalPathFilter("lf", lf)
+	if err != nil {
+		return nil, err
+	}
+	policy := func(file string) string {
+		switch {
+		case wantLF(file):
+			return "lf"
+		case wantCRLF(file):
+			return "crlf"
+		}
+		return eol
+	}
+
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		lines, err := pushedLines(ctx, env, keep)
+		if err != nil {
+			return nil, err
+		}
+		type violation struct {
+			first Finding
+			count int
+		}
+		var order []string
+		byFile := map[string]*violation{}
+		for _, line := range lines {
+			if line.NoFinalNewline {
+				continue // no line ending to judge
+			}
+			want := policy(line.File)
+			if line.CRLF == (want == "crlf") {
+				continue
+			}
+			v, ok := byFile[line.File]
+			if !ok {
+				got := "LF"
+				if line.CRLF {
+					got = "CRLF"
+				}
+				v = &violation{first: Finding{File: line.File, Line: line.Line, Rule: "line-endings", Message: fmt.Sprintf("%s line ending, expected %s", got, strings.ToUpper(want))}}
+				byFile[line.File] = v
+				order = append(order, line.File)
+			}
+			v.count++
+		}
+		findings := make([]Finding, 0, len(order))
+		for _, file := range order {
+			v := byFile[file]
+			if v.count > 1 {
+				v.first.Message += fmt.Sprintf(" (%d lines)", v.count)
+			}
+			findings = append(findings, v.first)
+		}
+		return findings, nil
+	}, nil
+}
+
+// optionalPathFilter compiles a `with:` glob list; an empty list matches nothing.
+func optionalPathFilter(key string, patterns []string) (func(file string) bool, error) {
+	if len(patterns) == 0 {
+		return func(string) bool { return false }, nil
+	}
+	filter, err := config.CompilePathFilter(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("with.%s: %w", key, err)
+	}
+	return filter.Match, nil
+}
+
+func configureFinalNewline(with map[string]any) (Runner, error) {
+	keep, err := configureExcludeOnly(with)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, env Env) ([]Finding, error) {
+		lines, err := pushedLines(ctx, env, keep)
+		if err != nil {
+			return nil, err
+		}
+		var findings []Finding
+		for _, line := range lines {
+			if line.NoFinalNewline {
+				findings = append(findings, Finding{File: line.File, Line: line.Line, Rule: "final-newline", Message: "no newline at end of file"})
+			}
+		}
+		return findings, nil
+	}, nil
+}
@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// pushFixture commits base, then files, and returns the env for pushing the second commit.
+func pushFixture(t *testing.T, base map[string]string, files map[string]string) Env {
+	t.Helper()
+	root := newGitRepo(t)
+	for rel, content := range base {
+		writeRepoFile(t, root, rel, content)
+	}
+	gitRun(t, root, "add", "-A")
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "base")
+	baseSHA := gitRun(t, root, "rev-parse", "HEAD")
+	for rel, content := range files {
+		writeRepoFile(t, root, rel, content)
+	}
+	gitRun(t, root, "add", "-A")
+	gitRun(t, root, "commit", "-q", "-m", "push")
+	head := gitRun(t, root, "rev-parse", "HEAD")
+	return Env{Root: root, Dir: root, PushRefs: "refs/heads/main " + head + " refs/heads/main " + baseSHA}
+}
+
+// runFindings runs a builtin and renders its findings one per line.
+func runFindings(t *testing.T, env Env, name string, with map[string]any) string {
+	t.Helper()
+	findings, err := Run(context.Background(), config.Check{Name: name, Builtin: name, With: with}, env)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLineBasedHygieneChecks(t *testing.T) {
+	env := pushFixture(t, map[string]string{
+		"old.txt": "no newline, but already pushed",
+	}, map[string]string{
+		"merge.go":    "package main\n<<<<<<< HEAD\na := 1\n=======\na := 2\n>>>>>>> feature\n",
+		"docs/api.md": "Title\n=======\n",
+		"win.txt":     "one\r\ntwo\r\nthree\r\n",
+		"run.bat":     "@echo off\r\n",
+		"tool.sh":     "echo hi",
+	})
+
+	if got, want := runFindings(t, env, "conflict-markers", nil), "merge.go:2: merge conflict marker <<<<<<< (conflict-markers)\nmerge.go:6: merge conflict marker >>>>>>> (conflict-markers)"; got != want {
+		t.Errorf("conflict-markers:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := runFindings(t, env, "line-endings", nil), "win.txt:1: CRLF line ending, expected LF (3 lines) (line-endings)"; got != want {
+		t.Errorf("line-endings:\n%s\nwant:\n%s", got, want)
+	}
+	if got := runFindings(t, env, "line-endings", map[string]any{"eol": "crlf", "exclude": "*.go"}); !strings.Contains(got, "docs/api.md:1: LF line ending, expected CRLF (2 lines)") || strings.Contains(got, "win.txt") || strings.Contains(got, "merge.go") {
+		t.Errorf("line-endings with eol crlf:\n%s", got)
+	}
+	if got, want := runFindings(t, env, "final-newline", nil), "tool.sh:1: no newline at end of file (final-newline)"; got != want {
+		t.Errorf("final-newline:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFileBasedHygieneChecks(t *testing.T) {
+	env := pushFixture(t, map[string]string{
+		"README.md":           "# readme\n",
+		"Docs/guide.md":       "guide\n",
+		".gitattributes":      "*.bin filter=lfs diff=lfs merge=lfs -text\n",
+		"assets/tracked.bin":  strings.Repeat("x", 64),
+		"assets/already.psd":  "old",
+		"assets/already2.zip": "old",
+	}, map[string]string{
+		"readme.md":       "# again\n",
+		"docs/notes.md":   "notes\n",
+		"assets/hero.psd": strings.Repeat("p", 64),
+		"dump.sql":        strings.Repeat("d", 2048),
+	})
+	if err := os.Symlink("missing-target", filepath.Join(env.Root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink("README.md", filepath.Join(env.Root, "good-link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	gitRun(t, env.Root, "add", "-A")
+	gitRun(t, env.Root, "commit", "-q", "-m", "links")
+	env.PushRefs = strings.Replace(env.PushRefs, strings.Fields(env.PushRefs)[1], gitRun(t, env.Root, "rev-parse", "HEAD"), 1)
+
+	if got, want := runFindings(t, env, "large-files", map[string]any{"max": "1KB"}), "dump.sql: 2.0KB is over the 1.0KB limit (large-files)"; got != want {
+		t.Errorf("large-files:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := runFindings(t, env, "lfs", nil), `assets/hero.psd: should be tracked with Git LFS (git lfs track "*.psd") (lfs)`; got != want {
+		t.Errorf("lfs:\n%s\nwant:\n%s", got, want)
+	}
+	if got := runFindings(t, env, "lfs", map[string]any{"patterns": []any{}, "max": 1000}); !strings.HasPrefix(got, "dump.sql: is 2.0KB; files over 1000B should be tracked with Git LFS") {
+		t.Errorf("lfs by size:\n%s", got)
+	}
+	if got, want := runFindings(t, env, "case-collisions", nil), "docs: differs only in case from Docs (case-collisions)\nreadme.md: differs only in case from README.md (case-collisions)"; got != want {
+		t.Errorf("case-collisions:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := runFindings(t, env, "broken-symlinks", nil), `link: symlink target "missing-target" does not exist (broken-symlinks)`; got != want {
+		t.Errorf("broken-symlinks:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHygieneOptionsAreValidated(t *testing.T) {
+	for name, with := range map[string]map[string]any{
+		"large-files":   {"max": "lots"},
+		"line-endings":  {"eol": "cr"},
+		"final-newline": {"exclude": 3},
+		"lfs":           {"pattern": "*.psd"},
+	} {
+		if err := Validate(name, with); err == nil {
+			t.Errorf("%s: expected %v to be rejected", name, with)
+		}
+	}
+	if size, err := parseSize("1.5 MB"); err != nil || size != 3<<19 {
+		t.Fatalf("parseSize(1.5 MB) = %d, %v", size, err)
+	}
+}
@@ -0,0 +1,506 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+var azurePipelineFiles = []string{"azure-pipelines.yml", "azure-pipelines.yaml", ".azure-pipelines.yml"}
+
+type azurePipelinesProvider struct{}
+
+func (azurePipelinesProvider) Name() string { return "azure" }
+
+func (azurePipelinesProvider) Detect(root string) bool {
+	_, ok := firstExisting(root, azurePipelineFiles...)
+	return ok
+}
+
+// Checks turns script/bash/pwsh/powershell steps into checks. Step, job, and stage
+// templates in the same repo are expanded with their parameters; tasks, checkouts,
+// deployment jobs, and templates from other repos are skipped.
+func (p azurePipelinesProvider) Checks(root string) ([]config.Check, error) {
+	path, ok := firstExisting(root, azurePipelineFiles...)
+	if !ok {
+		return nil, nil
+	}
+	imp := &azureImporter{root: root, currentOS: currentRunnerOS(), active: map[string]bool{}}
+	doc, err := imp.loadTemplate(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	if err := imp.pipeline(doc, filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return uniqueCheckNames(imp.checks), nil
+}
+
+type azureImporter struct {
+	root      string
+	currentOS string
+	active    map[string]bool
+	checks    []config.Check
+}
+
+var (
+	azureParameterPattern = regexp.MustCompile(`\$\{\{\s*parameters\.([A-Za-z0-9_]+)\s*\}\}`)
+	azureConditionOS      = regexp.MustCompile(`(?i)\b(eq|ne)\(\s*variables(?:\[\s*'agent\.os'\s*\]|\.agent\.os)\s*,\s*'([^']+)'\s*\)`)
+)
+
+// loadTemplate reads a pipeline or template file and substitutes ${{ parameters.x }}
+// with the caller's values, falling back to the template's declared defaults. Values are
+// substituted into the parsed strings, so a value holding `:` or a newline stays a value.
+func (imp *azureImporter) loadTemplate(path string, params map[string]string) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if imp.active[abs] {
+		return nil, fmt.Errorf("template cycle at %s", filepath.Base(path))
+	}
+	imp.active[abs] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	values := azureParameterDefaults(raw["parameters"])
+	for k, v := range params {
+		values[k] = v
+	}
+	for key, node := range raw {
+		if key != "parameters" {
+			raw[key] = substituteAzureParameters(node, values)
+		}
+	}
+	return raw, nil
+}
+
+// substituteAzureParameters replaces ${{ parameters.x }} in every string under node.
+// Parameters without a value are left for stepCheck to report.
+func substituteAzureParameters(node interface{}, values map[string]string) interface{} {
+	switch v := node.(type) {
+	case string:
+		return azureParameterPattern.ReplaceAllStringFunc(v, func(match string) string {
+			if value, ok := values[azureParameterPattern.FindStringSubmatch(match)[1]]; ok {
+				return value
+			}
+			return match
+		})
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = substituteAzureParameters(child, values)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = substituteAzureParameters(child, values)
+		}
+	}
+	return node
+}
+
+func (imp *azureImporter) release(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		delete(imp.active, abs)
+	}
+}
+
+// azureParameterDefaults reads `parameters:` in either the list ({name, default}) or
+// the legacy mapping (name: default) form.
+func azureParameterDefaults(raw interface{}) map[string]string {
+	out := map[string]string{}
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			m := asMap(item)
+			if name := asString(m["name"]); name != "" && m["default"] != nil {
+				out[name] = asString(m["default"])
+			}
+		}
+	case map[string]interface{}:
+		for name, def := range v {
+			if def != nil {
+				if _, isMap := def.(map[string]interface{}); !isMap {
+					out[name] = asString(def)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// resolveTemplate returns the local template file referenced by ref, or false when the
+// template lives in another repository.
+func (imp *azureImporter) resolveTemplate(ref string, baseDir string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.Contains(ref, "@") {
+		return "", false
+	}
+	if strings.HasPrefix(ref, "/") {
+		return filepath.Join(imp.root, filepath.FromSlash(strings.TrimPrefix(ref, "/"))), true
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(ref)), true
+}
+
+func templateParams(raw interface{}) map[string]string {
+	m := asMap(raw)
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		out[k] = asString(v)
+	}
+	return out
+}
+
+func (imp *azureImporter) pipeline(doc map[string]interface{}, baseDir string) error {
+	if ext := asMap(doc["extends"]); ext != nil {
+		path, ok := imp.resolveTemplate(asString(ext["template"]), baseDir)
+		if !ok {
+			return nil
+		}
+		tmpl, err := imp.loadTemplate(path, templateParams(ext["parameters"]))
+		if err != nil {
+			return err
+		}
+		defer imp.release(path)
+		return imp.pipeline(tmpl, filepath.Dir(path))
+	}
+
+	vars := azureVariables(doc["variables"])
+	switch {
+	case doc["stages"] != nil:
+		return imp.stages(asList(doc["stages"]), vars, baseDir)
+	case doc["jobs"] != nil:
+		return imp.jobs(asList(doc["jobs"]), vars, baseDir)
+	case doc["steps"] != nil:
+		job := map[string]interface{}{"job": "job", "steps": doc["steps"], "pool": doc["pool"]}
+		return imp.job(job, vars, baseDir)
+	}
+	return nil
+}
+
+func (imp *azureImporter) stages(stages []interface{}, vars map[string]string, baseDir string) error {
+	for _, item := range stages {
+		stage := asMap(item)
+		if ref := asString(stage["template"]); ref != "" {
+			path, ok := imp.resolveTemplate(ref, baseDir)
+			if !ok {
+				continue
+			}
+			tmpl, err := imp.loadTemplate(path, templateParams(stage["parameters"]))
+			if err != nil {
+				return err
+			}
+			err = imp.stages(asList(tmpl["stages"]), vars, filepath.Dir(path))
+			imp.release(path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if known, allowed := evalAzureConditionOS(asString(stage["condition"]), imp.currentOS); known && !allowed {
+			continue
+		}
+		if err := imp.jobs(asList(stage["jobs"]), mergeEnv(vars, azureVariables(stage["variables"])), baseDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *azureImporter) jobs(jobs []interface{}, vars map[string]string, baseDir string) error {
+	for _, item := range jobs {
+		job := asMap(item)
+		if ref := asString(job["template"]); ref != "" {
+			path, ok := imp.resolveTemplate(ref, baseDir)
+			if !ok {
+				continue
+			}
+			tmpl, err := imp.loadTemplate(path, templateParams(job["parameters"]))
+			if err != nil {
+				return err
+			}
+			err = imp.jobs(asList(tmpl["jobs"]), vars, filepath.Dir(path))
+			imp.release(path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if job["deployment"] != nil {
+			continue
+		}
+		if err := imp.job(job, vars, baseDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *azureImporter) job(job map[string]interface{}, vars map[string]string, baseDir string) error {
+	if !azurePoolMatches(job["pool"], imp.currentOS) {
+		return nil
+	}
+	if known, allowed := evalAzureConditionOS(asString(job["condition"]), imp.currentOS); known && !allowed {
+		return nil
+	}
+	jobLabel := sanitizeLabel(asString(job["job"]))
+	if name := asString(job["displayName"]); name != "" {
+		jobLabel = sanitizeLabel(name)
+	}
+	return imp.steps(asList(job["steps"]), jobLabel, mergeEnv(vars, azureVariables(job["variables"])), baseDir)
+}
+
+func (imp *azureImporter) steps(steps []interface{}, jobLabel string, env map[string]string, baseDir string) error {
+	for _, item := range steps {
+		step := asMap(item)
+		if ref := asString(step["template"]); ref != "" {
+			path, ok := imp.resolveTemplate(ref, baseDir)
+			if !ok {
+				continue
+			}
+			tmpl, err := imp.loadTemplate(path, templateParams(step["parameters"]))
+			if err != nil {
+				return err
+			}
+			err = imp.steps(asList(tmpl["steps"]), jobLabel, env, filepath.Dir(path))
+			imp.release(path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if check, ok := imp.stepCheck(step, jobLabel, env); ok {
+			imp.checks = append(imp.checks, check)
+		}
+	}
+	return nil
+}
+
+func (imp *azureImporter) stepCheck(step map[string]interface{}, jobLabel string, env map[string]string) (config.Check, bool) {
+	var run, shellName string
+	switch {
+	case step["script"] != nil:
+		run = asString(step["script"])
+		shellName = "bash"
+		if imp.currentOS == "windows" {
+			shellName = "cmd"
+		}
+	case step["bash"] != nil:
+		run, shellName = asString(step["bash"]), "bash"
+	case step["pwsh"] != nil:
+		run, shellName = asString(step["pwsh"]), "pwsh"
+	case step["powershell"] != nil:
+		run, shellName = asString(step["powershell"]), "powershell"
+		if imp.currentOS != "windows" {
+			shellName = "pwsh"
+		}
+	default:
+		return config.Check{}, false
+	}
+	if run == "" {
+		return config.Check{}, false
+	}
+	if enabled, ok := step["enabled"].(bool); ok && !enabled {
+		return config.Check{}, false
+	}
+	if known, allowed := evalAzureConditionOS(asString(step["condition"]), imp.currentOS); known && !allowed {
+		return config.Check{}, false
+	}
+
+	env = mergeEnv(env, stringMap(step["env"]))
+	local := azureLocalizer{vars: env, shell: shellName}
+	check := config.Check{
+		Name:   fmt.Sprintf("ci:azure:%s:%s", jobLabel, firstLineLabel(asString(step["displayName"]), run)),
+		Run:    local.text(run),
+		Shell:  shellName,
+		Cwd:    azureWorkingDirectory(local.expressions(asString(step["workingDirectory"]))),
+		OS:     config.StringList{imp.currentOS},
+		Source: "azure",
+	}
+	for key, value := range env {
+		// Values still holding a macro ($(secret), $(Build.BuildId)) come from the
+		// developer's own environment instead.
+		if value = local.expressions(value); !strings.Contains(value, "$(") {
+			if check.Env == nil {
+				check.Env = map[string]string{}
+			}
+			check.Env[key] = value
+		}
+	}
+	if len(local.dropped) > 0 {
+		check.Note = "dropped unresolved template expressions: " + strings.Join(local.dropped, ", ")
+	}
+	return check, true
+}
+
+// azureLocalizer rewrites the expressions Azure expands before a step runs into
+// something a local shell can run.
+type azureLocalizer struct {
+	vars    map[string]string
+	shell   string
+	dropped []string
+}
+
+var (
+	azureExpressionPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+	azureVariableReference = regexp.MustCompile(`^variables(?:\.([A-Za-z0-9_.]+)|\[\s*'([^']+)'\s*\])$`)
+	azureMacroPattern      = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_.]*)\)`)
+	azureWorkspaceMacros   = map[string]bool{"system.defaultworkingdirectory": true, "build.sourcesdirectory": true, "build.repository.localpath": true}
+)
+
+// text resolves template expressions and then $(Var) macros in a step script.
+func (l *azureLocalizer) text(run string) string {
+	return azureMacroPattern.ReplaceAllStringFunc(l.expressions(run), func(match string) string {
+		name := azureMacroPattern.FindStringSubmatch(match)[1]
+		if value, ok := l.lookup(name); ok {
+			return value
+		}
+		if azureWorkspaceMacros[strings.ToLower(name)] {
+			return "${{ repo.root }}"
+		}
+		if strings.ToLower(name) == name && !strings.Contains(name, ".") {
+			// $(pwd), $(nproc): Azure leaves undefined macros alone, so these are
+			// command substitutions in the script itself.
+			return match
+		}
+		envName := strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+		switch l.shell {
+		case "cmd":
+			return "%" + envName + "%"
+		case "pwsh", "powershell":
+			return "$env:" + envName
+		}
+		return "${" + envName + "}"
+	})
+}
+
+// expressions substitutes ${{ variables.x }} from the step's variables and drops any
+// other ${{ }} expression (a parameter with no value, a runtime-only variable), which
+// would otherwise fail config validation.
+func (l *azureLocalizer) expressions(text string) string {
+	return azureExpressionPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := azureVariableReference.FindStringSubmatch(azureExpressionPattern.FindStringSubmatch(match)[1])
+		if m != nil {
+			if value, ok := l.lookup(m[1] + m[2]); ok {
+				return value
+			}
+		}
+		if !slices.Contains(l.dropped, match) {
+			l.dropped = append(l.dropped, match)
+		}
+		return ""
+	})
+}
+
+// lookup finds a variable by name; Azure variable names are case-insensitive.
+func (l *azureLocalizer) lookup(name string) (string, bool) {
+	if value, ok := l.vars[name]; ok {
+		return value, true
+	}
+	for key, value := range l.vars {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// azureVariables reads `variables:` as a mapping or as a list of {name, value};
+// variable groups and variable templates cannot be resolved locally and are ignored.
+func azureVariables(raw interface{}) map[string]string {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		return stringMap(v)
+	case []interface{}:
+		out := map[string]string{}
+		for _, item := range v {
+			m := asMap(item)
+			if name := asString(m["name"]); name != "" {
+				out[name] = asString(m["value"])
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+	return nil
+}
+
+func azurePoolMatches(raw interface{}, currentOS string) bool {
+	image := ""
+	switch v := raw.(type) {
+	case string:
+		image = v
+	case map[string]interface{}:
+		image = asString(v["vmImage"])
+	}
+	os := osFromValue(image)
+	return os == "" || os == currentOS
+}
+
+func azureWorkingDirectory(dir string) string {
+	for _, prefix := range []string{"$(System.DefaultWorkingDirectory)", "$(Build.SourcesDirectory)", "$(Pipeline.Workspace)/s"} {
+		if strings.HasPrefix(dir, prefix) {
+			dir = strings.TrimLeft(strings.TrimPrefix(dir, prefix), `/\`)
+			break
+		}
+	}
+	return dir
+}
+
+// evalAzureConditionOS evaluates Agent.OS comparisons in a condition: all of them must
+// hold, or any of them inside or(...). known is false when the condition does not
+// mention Agent.OS.
+func evalAzureConditionOS(condition string, currentOS string) (known bool, allowed bool) {
+	matches := azureConditionOS.FindAllStringSubmatch(condition, -1)
+	if len(matches) == 0 {
+		return false, true
+	}
+	anyOf := strings.Contains(strings.ToLower(condition), "or(")
+	for _, m := range matches {
+		os := azureAgentOS(m[2])
+		if os == "" {
+			return false, true
+		}
+		holds := strings.EqualFold(m[1], "eq") == (os == currentOS)
+		if anyOf && holds {
+			return true, true
+		}
+		if !anyOf && !holds {
+			return true, false
+		}
+	}
+	return true, !anyOf
+}
+
+func azureAgentOS(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "windows_nt":
+		return "windows"
+	case "linux":
+		return "linux"
+	case "darwin":
+		return "macos"
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAzurePipelinesChecks(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, "azure-pipelines.yml", `
+variables:
+  GOFLAGS: -mod=readonly
+stages:
+  - stage: build
+    jobs:
+      - job: test
+        variables:
+          - name: CGO_ENABLED
+            value: "0"
+        steps:
+          - checkout: self
+          - task: GoTool@0
+          - script: go vet ./...
+            displayName: Vet
+          - bash: go test ./...
+            workingDirectory: $(System.DefaultWorkingDirectory)/svc
+            env:
+              RACE: "1"
+          - template: templates/lint.yml
+            parameters:
+              target: ./cmd/...
+          - template: shared/steps.yml@tools
+      - deployment: release
+        steps:
+          - script: ./deploy.sh
+      - template: templates/job.yml
+`)
+	writeCIFile(t, root, "templates/lint.yml", `
+parameters:
+  - name: target
+    default: ./...
+  - name: linter
+    default: golangci-lint
+steps:
+  - pwsh: ${{ parameters.linter }} run ${{ parameters.target }}
+    displayName: Lint
+`)
+	writeCIFile(t, root, "templates/job.yml", `
+jobs:
+  - job: docs
+    steps:
+      - script: make docs
+        condition: eq(variables['Agent.OS'], 'Plan9')
+      - script: make spelling
+`)
+
+	checks, err := azurePipelinesProvider{}.Checks(root)
+	if err != nil {
+		t.Fatalf("azure checks: %v", err)
+	}
+
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	want := []string{"ci:azure:test:vet", "ci:azure:test:go-test-.-...", "ci:azure:test:lint", "ci:azure:docs:make-docs", "ci:azure:docs:make-spelling"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+
+	test := checks[1]
+	if test.Cwd != "svc" || test.Shell != "bash" || test.Source != "azure" {
+		t.Fatalf("unexpected bash step: %+v", test)
+	}
+	if test.Env["GOFLAGS"] != "-mod=readonly" || test.Env["CGO_ENABLED"] != "0" || test.Env["RACE"] != "1" {
+		t.Fatalf("expected pipeline, job, and step variables, got %v", test.Env)
+	}
+	if lint := checks[2]; lint.Run != "golangci-lint run ./cmd/..." || lint.Shell != "pwsh" {
+		t.Fatalf("expected template parameters applied, got %+v", lint)
+	}
+}
+
+func TestAzurePipelinesLocalizesExpressions(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, "azure-pipelines.yml", `
+parameters:
+  - name: channel
+    type: string
+variables:
+  BuildConfiguration: Release
+steps:
+  - bash: dotnet build -c ${{ variables.BuildConfiguration }} ${{ parameters.channel }}
+    displayName: Build
+  - bash: echo $(buildConfiguration) $(Build.BuildId) $(pwd)
+    displayName: Macros
+    env:
+      TOKEN: $(SecretToken)
+      MODE: ${{ variables.BuildConfiguration }}
+  - bash: cd $(Build.SourcesDirectory)/app && make
+    displayName: Workspace
+  - template: templates/echo.yml
+    parameters:
+      message: "key: value"
+  - template: templates/echo.yml
+    parameters:
+      message: |
+        first
+        second
+`)
+	writeCIFile(t, root, "templates/echo.yml", `
+parameters:
+  - name: message
+steps:
+  - bash: echo "${{ parameters.message }}"
+    displayName: Echo
+`)
+
+	checks, err := azurePipelinesProvider{}.Checks(root)
+	if err != nil {
+		t.Fatalf("azure checks: %v", err)
+	}
+	if len(checks) != 5 {
+		t.Fatalf("expected 5 checks, got %+v", checks)
+	}
+
+	build := checks[0]
+	if build.Run != "dotnet build -c Release " || !strings.Contains(build.Note, "${{ parameters.channel }}") {
+		t.Fatalf("expected variables resolved and the unset parameter dropped, got %+v", build)
+	}
+	macros := checks[1]
+	if macros.Run != "echo Release ${BUILD_BUILDID} $(pwd)" {
+		t.Fatalf("unexpected macro translation: %q", macros.Run)
+	}
+	if _, ok := macros.Env["TOKEN"]; ok || macros.Env["MODE"] != "Release" {
+		t.Fatalf("expected macro env dropped and variables resolved, got %v", macros.Env)
+	}
+	if run := checks[2].Run; run != "cd ${{ repo.root }}/app && make" {
+		t.Fatalf("expected the sources directory mapped to the repo root, got %q", run)
+	}
+	if run := checks[3].Run; run != `echo "key: value"` {
+		t.Fatalf("expected a parameter holding a colon kept intact, got %q", run)
+	}
+	if run := checks[4].Run; run != "echo \"first\nsecond\"" {
+		t.Fatalf("expected a multi-line parameter kept intact, got %q", run)
+	}
+}
+
+func TestEvalAzureConditionOS(t *testing.T) {
+	cases := []struct {
+		condition string
+		known     bool
+		allowed   bool
+	}{
+		{"succeeded()", false, true},
+		{"eq(variables['Agent.OS'], 'Linux')", true, true},
+		{"ne(variables['Agent.OS'], 'Linux')", true, false},
+		{"and(succeeded(), eq(variables.agent.os, 'Windows_NT'))", true, false},
+		{"or(eq(variables['Agent.OS'], 'Darwin'), eq(variables['Agent.OS'], 'Linux'))", true, true},
+	}
+	for _, tc := range cases {
+		known, allowed := evalAzureConditionOS(tc.condition, "linux")
+		if known != tc.known || allowed != tc.allowed {
+			t.Fatalf("%s: got (%v, %v), want (%v, %v)", tc.condition, known, allowed, tc.known, tc.allowed)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ci
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
+)
+
+const bitbucketPipelinesFile = "bitbucket-pipelines.yml"
+
+type bitbucketPipelinesProvider struct{}
+
+func (bitbucketPipelinesProvider) Name() string { return "bitbucket" }
+
+func (bitbucketPipelinesProvider) Detect(root string) bool {
+	return fileExists(filepath.Join(root, bitbucketPipelinesFile))
+}
+
+// Checks imports the pipeline a push of the current branch would run: the first
+// `branches:` pattern matching the branch, else `default`. Steps with services, manual
+// triggers, or only pipes are skipped; pipes inside scripts are dropped.
+func (bitbucketPipelinesProvider) Checks(root string) ([]config.Check, error) {
+	path := filepath.Join(root, bitbucketPipelinesFile)
+	if !fileExists(path) {
+		return nil, nil
+	}
+	doc, err := readYAMLMap(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", bitbucketPipelinesFile, err)
+	}
+
+	pipelines := asMap(doc["pipelines"])
+	label, items := selectBitbucketPipeline(pipelines, git.CurrentBranch(root))
+	if items == nil {
+		return nil, nil
+	}
+
+	currentOS := currentRunnerOS()
+	var out []config.Check
+	for _, step := range bitbucketSteps(items) {
+		if check, ok := bitbucketStepCheck(step, label, currentOS); ok {
+			out = append(out, check)
+		}
+	}
+	return uniqueCheckNames(out), nil
+}
+
+// selectBitbucketPipeline picks the branch pipeline for branch (exact names before
+// glob patterns), falling back to the default pipeline.
+func selectBitbucketPipeline(pipelines map[string]interface{}, branch string) (string, []interface{}) {
+	branches := asMap(pipelines["branches"])
+	if branch != "" && len(branches) > 0 {
+		if items, ok := branches[branch].([]interface{}); ok {
+			return "branch-" + sanitizeLabel(branch), items
+		}
+		patterns := make([]string, 0, len(branches))
+		for pattern := range branches {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			if bitbucketGlobMatch(pattern, branch) {
+				return "branch-" + sanitizeLabel(pattern), asList(branches[pattern])
+			}
+		}
+	}
+	if items, ok := pipelines["default"].([]interface{}); ok {
+		return "default", items
+	}
+	return "", nil
+}
+
+// bitbucketGlobMatch matches Bitbucket branch globs: `*` stays within a path segment,
+// `**` crosses segments, and {a,b} alternates.
+func bitbucketGlobMatch(pattern string, branch string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '{':
+			b.WriteString("(?:")
+		case c == '}':
+			b.WriteString(")")
+		case c == ',':
+			b.WriteString("|")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(branch)
+}
+
+// bitbucketSteps flattens step, parallel, and stage entries into steps in run order.
+func bitbucketSteps(items []interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, item := range items {
+		entry := asMap(item)
+		switch {
+		case entry["step"] != nil:
+			out = append(out, asMap(entry["step"]))
+		case entry["parallel"] != nil:
+			parallel := entry["parallel"]
+			if m := asMap(parallel); m != nil {
+				parallel = m["steps"]
+			}
+			out = append(out, bitbucketSteps(asList(parallel))...)
+		case entry["stage"] != nil:
+			out = append(out, bitbucketSteps(asList(asMap(entry["stage"])["steps"]))...)
+		}
+	}
+	return out
+}
+
+func bitbucketStepCheck(step map[string]interface{}, pipelineLabel string, currentOS string) (config.Check, bool) {
+	if len(asList(step["services"])) > 0 || asString(step["trigger"]) == "manual" {
+		return config.Check{}, false
+	}
+	for _, label := range asList(step["runs-on"]) {
+		if os := osFromValue(asString(label)); os != "" && os != currentOS {
+			return config.Check{}, false
+		}
+	}
+
+	var lines []string
+	for _, line := range asList(step["script"]) {
+		if s, ok := line.(string); ok && strings.TrimSpace(s) != "" {
+			lines = append(lines, strings.TrimSpace(s))
+		}
+	}
+	if len(lines) == 0 {
+		return config.Check{}, false
+	}
+	run := strings.Join(lines, "\n")
+
+	return config.Check{
+		Name:   fmt.Sprintf("ci:bitbucket:%s:%s", pipelineLabel, firstLineLabel(asString(step["name"]), run)),
+		Run:    run,
+		Shell:  "bash",
+		OS:     config.StringList{currentOS},
+		Source: "bitbucket",
+	}, true
+}
@@ -0,0 +1,80 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+const bitbucketFixture = `
+image: golang:1.22
+definitions:
+  steps:
+    - step: &vet
+        name: Vet
+        script:
+          - go vet ./...
+pipelines:
+  default:
+    - step: *vet
+    - parallel:
+        - step:
+            name: Unit
+            script:
+              - go test ./...
+        - step:
+            name: Integration
+            services: [postgres]
+            script:
+              - go test -tags integration ./...
+    - step:
+        name: Deploy
+        trigger: manual
+        script:
+          - ./deploy.sh
+  branches:
+    "release/**":
+      - stage:
+          name: Release
+          steps:
+            - step:
+                name: Build
+                script:
+                  - make release
+                  - pipe: atlassian/slack-notify:2.0.0
+`
+
+func TestBitbucketPipelinesDefault(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".git/HEAD", "ref: refs/heads/feature/x\n")
+	writeCIFile(t, root, bitbucketPipelinesFile, bitbucketFixture)
+
+	checks, err := bitbucketPipelinesProvider{}.Checks(root)
+	if err != nil {
+		t.Fatalf("bitbucket checks: %v", err)
+	}
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	want := []string{"ci:bitbucket:default:vet", "ci:bitbucket:default:unit"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+	if checks[0].Source != "bitbucket" || checks[0].Shell != "bash" {
+		t.Fatalf("unexpected check: %+v", checks[0])
+	}
+}
+
+func TestBitbucketPipelinesBranchPattern(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".git/HEAD", "ref: refs/heads/release/2024/q1\n")
+	writeCIFile(t, root, bitbucketPipelinesFile, bitbucketFixture)
+
+	checks, err := bitbucketPipelinesProvider{}.Checks(root)
+	if err != nil {
+		t.Fatalf("bitbucket checks: %v", err)
+	}
+	if len(checks) != 1 || checks[0].Name != "ci:bitbucket:branch-release:build" || checks[0].Run != "make release" {
+		t.Fatalf("expected release branch pipeline without pipes, got %+v", checks)
+	}
+}
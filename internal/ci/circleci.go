@@ -0,0 +1,273 @@
+package ci
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+var circleCIFiles = []string{".circleci/config.yml", ".circleci/config.yaml"}
+
+// circleCIBuiltinSteps are steps that only manage the CI environment.
+var circleCIBuiltinSteps = map[string]bool{
+	"checkout": true, "save_cache": true, "restore_cache": true, "persist_to_workspace": true,
+	"attach_workspace": true, "store_artifacts": true, "store_test_results": true,
+	"setup_remote_docker": true, "add_ssh_keys": true, "when": true, "unless": true,
+}
+
+var circleCIParameterPattern = regexp.MustCompile(`<<\s*parameters\.([A-Za-z0-9_-]+)\s*>>`)
+
+type circleCIProvider struct{}
+
+func (circleCIProvider) Name() string { return "circleci" }
+
+func (circleCIProvider) Detect(root string) bool {
+	_, ok := firstExisting(root, circleCIFiles...)
+	return ok
+}
+
+// Checks turns `run` steps of each job into checks, inlining reusable `commands:` with
+// their parameters. Orb commands, jobs with service containers (extra docker images),
+// and jobs for another OS are skipped.
+func (circleCIProvider) Checks(root string) ([]config.Check, error) {
+	path, ok := firstExisting(root, circleCIFiles...)
+	if !ok {
+		return nil, nil
+	}
+	doc, err := readYAMLMap(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.ToSlash(circleCIFiles[0]), err)
+	}
+
+	imp := circleCIImporter{
+		commands:  asMap(doc["commands"]),
+		executors: asMap(doc["executors"]),
+		currentOS: currentRunnerOS(),
+	}
+	jobs := asMap(doc["jobs"])
+
+	var out []config.Check
+	for _, jobName := range yamlMappingKeys(path, "jobs") {
+		job := asMap(jobs[jobName])
+		if job == nil {
+			continue
+		}
+		checks, err := imp.jobChecks(jobName, job)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, checks...)
+	}
+	return uniqueCheckNames(out), nil
+}
+
+type circleCIImporter struct {
+	commands  map[string]interface{}
+	executors map[string]interface{}
+	currentOS string
+}
+
+func (imp circleCIImporter) jobChecks(jobName string, job map[string]interface{}) ([]config.Check, error) {
+	settings := job
+	if executor := imp.executor(job["executor"]); executor != nil {
+		settings = mergeCircleCIMaps(executor, job)
+	}
+	if len(asList(settings["docker"])) > 1 {
+		return nil, nil
+	}
+	jobOS := circleCIJobOS(settings, asString(job["executor"]))
+	if jobOS != imp.currentOS {
+		return nil, nil
+	}
+	env := stringMap(settings["environment"])
+
+	params := circleCIParameterDefaults(job["parameters"])
+	steps, err := imp.expandSteps(asList(job["steps"]), params, 0)
+	if err != nil {
+		return nil, fmt.Errorf("circleci job %s: %w", jobName, err)
+	}
+
+	jobShell := asString(settings["shell"])
+	jobDir := circleCIWorkingDirectory(asString(settings["working_directory"]))
+
+	var out []config.Check
+	for _, step := range steps {
+		command, name, stepEnv, stepDir, stepShell := "", "", map[string]string(nil), "", ""
+		switch run := step["run"].(type) {
+		case string:
+			command = strings.TrimSpace(run)
+		case map[string]interface{}:
+			command = asString(run["command"])
+			name = asString(run["name"])
+			stepEnv = stringMap(run["environment"])
+			stepDir = circleCIWorkingDirectory(asString(run["working_directory"]))
+			stepShell = asString(run["shell"])
+			if asString(run["background"]) == "true" {
+				continue
+			}
+		}
+		if command == "" {
+			continue
+		}
+		cwd := stepDir
+		if cwd == "" {
+			cwd = jobDir
+		}
+		out = append(out, config.Check{
+			Name:   fmt.Sprintf("ci:circleci:%s:%s", sanitizeLabel(jobName), firstLineLabel(name, command)),
+			Run:    command,
+			Shell:  circleCIShell(stepShell, jobShell, jobOS),
+			Cwd:    cwd,
+			Env:    mergeEnv(env, stepEnv),
+			OS:     config.StringList{jobOS},
+			Source: "circleci",
+		})
+	}
+	return out, nil
+}
+
+func (imp circleCIImporter) executor(raw interface{}) map[string]interface{} {
+	name := asString(raw)
+	if m := asMap(raw); m != nil {
+		name = asString(m["name"])
+	}
+	return asMap(imp.executors[name])
+}
+
+// expandSteps normalizes steps to single-key maps and inlines reusable commands.
+func (imp circleCIImporter) expandSteps(steps []interface{}, params map[string]string, depth int) ([]map[string]interface{}, error) {
+	if depth > 16 {
+		return nil, fmt.Errorf("commands nested too deeply")
+	}
+	var out []map[string]interface{}
+	for _, item := range steps {
+		item = substituteCircleCIParams(item, params)
+
+		name, args := "", map[string]interface{}(nil)
+		switch v := item.(type) {
+		case string:
+			name = strings.TrimSpace(v)
+		case map[string]interface{}:
+			for k, value := range v {
+				name = k
+				if k == "run" {
+					out = append(out, map[string]interface{}{"run": value})
+					name = ""
+				} else {
+					args = asMap(value)
+				}
+			}
+		}
+		if name == "" || circleCIBuiltinSteps[name] || strings.Contains(name, "/") {
+			continue
+		}
+		command := asMap(imp.commands[name])
+		if command == nil {
+			continue
+		}
+		commandParams := circleCIParameterDefaults(command["parameters"])
+		for k, v := range args {
+			commandParams[k] = asString(v)
+		}
+		inner, err := imp.expandSteps(asList(command["steps"]), commandParams, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inner...)
+	}
+	return out, nil
+}
+
+func circleCIParameterDefaults(raw interface{}) map[string]string {
+	out := map[string]string{}
+	for name, spec := range asMap(raw) {
+		if def, ok := asMap(spec)["default"]; ok {
+			out[name] = asString(def)
+		}
+	}
+	return out
+}
+
+// substituteCircleCIParams replaces << parameters.x >> in every string of a step.
+func substituteCircleCIParams(raw interface{}, params map[string]string) interface{} {
+	switch v := raw.(type) {
+	case string:
+		return circleCIParameterPattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := circleCIParameterPattern.FindStringSubmatch(match)[1]
+			if value, ok := params[name]; ok {
+				return value
+			}
+			return match
+		})
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substituteCircleCIParams(item, params)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = substituteCircleCIParams(item, params)
+		}
+		return out
+	}
+	return raw
+}
+
+func mergeCircleCIMaps(base map[string]interface{}, top map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(top))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range top {
+		out[k] = v
+	}
+	return out
+}
+
+func circleCIJobOS(settings map[string]interface{}, executorName string) string {
+	if settings["macos"] != nil {
+		return "macos"
+	}
+	if machine := asMap(settings["machine"]); machine != nil {
+		if os := osFromValue(asString(machine["image"])); os != "" {
+			return os
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(executorName), "win") || strings.Contains(strings.ToLower(executorName), "windows") {
+		return "windows"
+	}
+	return "linux"
+}
+
+func circleCIShell(stepShell string, jobShell string, jobOS string) string {
+	candidate := stepShell
+	if candidate == "" {
+		candidate = jobShell
+	}
+	if fields := strings.Fields(candidate); len(fields) > 0 {
+		return filepath.Base(filepath.ToSlash(fields[0]))
+	}
+	if jobOS == "windows" {
+		return "powershell"
+	}
+	return "bash"
+}
+
+// circleCIWorkingDirectory makes paths relative to the checkout (CircleCI checks out
+// into ~/project by default).
+func circleCIWorkingDirectory(dir string) string {
+	for _, prefix := range []string{"~/project", "/home/circleci/project"} {
+		if strings.HasPrefix(dir, prefix) {
+			return strings.TrimLeft(strings.TrimPrefix(dir, prefix), "/")
+		}
+	}
+	if strings.HasPrefix(dir, "~") || filepath.IsAbs(dir) {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,81 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCircleCIChecks(t *testing.T) {
+	if currentRunnerOS() != "linux" {
+		t.Skip("fixture jobs target linux executors")
+	}
+	root := t.TempDir()
+	writeCIFile(t, root, ".circleci/config.yml", `
+version: 2.1
+orbs:
+  node: circleci/node@5
+executors:
+  go:
+    docker:
+      - image: cimg/go:1.22
+    environment:
+      GOFLAGS: -mod=readonly
+commands:
+  gotest:
+    parameters:
+      pkgs:
+        type: string
+        default: ./...
+    steps:
+      - run:
+          name: Go tests
+          command: go test << parameters.pkgs >>
+jobs:
+  test:
+    executor: go
+    working_directory: ~/project/svc
+    steps:
+      - checkout
+      - restore_cache:
+          keys: [deps]
+      - node/install-packages
+      - run: go vet ./...
+      - gotest:
+          pkgs: ./internal/...
+      - gotest
+  integration:
+    docker:
+      - image: cimg/go:1.22
+      - image: cimg/postgres:16
+    steps:
+      - run: go test -tags integration ./...
+  mac:
+    macos:
+      xcode: "15.0"
+    steps:
+      - run: xcodebuild test
+workflows:
+  main:
+    jobs: [test, integration, mac]
+`)
+
+	checks, err := circleCIProvider{}.Checks(root)
+	if err != nil {
+		t.Fatalf("circleci checks: %v", err)
+	}
+
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	want := []string{"ci:circleci:test:go-vet-.-...", "ci:circleci:test:go-tests", "ci:circleci:test:go-tests:2"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+	if checks[1].Run != "go test ./internal/..." || checks[2].Run != "go test ./..." {
+		t.Fatalf("expected command parameters applied, got %q / %q", checks[1].Run, checks[2].Run)
+	}
+	if checks[0].Cwd != "svc" || checks[0].Env["GOFLAGS"] != "-mod=readonly" || checks[0].Source != "circleci" {
+		t.Fatalf("expected executor env and cwd, got %+v", checks[0])
+	}
+}
@@ -0,0 +1,315 @@
+package ci
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// GeneratedMarker starts every file written by `ci export`. Importers skip such files so
+// exported checks are not imported back as CI checks.
+const GeneratedMarker = "# Generated by build-bouncer ci export."
+
+// InstallPackage is the `go install` path for build-bouncer.
+const InstallPackage = "github.com/berniemackie97/build-bouncer/cmd/build-bouncer"
+
+// ExportOptions controls how a config is rendered as a CI file.
+type ExportOptions struct {
+	// Version pins build-bouncer in the install step ("latest" when empty).
+	Version string
+	// Expand emits one native step per check instead of running `build-bouncer check`.
+	Expand bool
+	// Profile is passed to `build-bouncer check --profile` when set.
+	Profile string
+}
+
+// ExportProviders lists the providers `ci export` can render.
+var ExportProviders = []string{"github", "gitlab"}
+
+// DefaultExportPath is where a provider's exported file lives, relative to the repo root.
+func DefaultExportPath(provider string) string {
+	switch provider {
+	case "github":
+		return ".github/workflows/build-bouncer.yml"
+	case "gitlab":
+		return GitLabCIFile
+	}
+	return ""
+}
+
+// Export renders cfg as a CI file for provider. cfg should be fully loaded (config.Load)
+// so inherited and matrix checks are included. Output is deterministic.
+func Export(provider string, cfg *config.Config, opts ExportOptions) ([]byte, error) {
+	var doc interface{}
+	var err error
+	switch provider {
+	case "github":
+		doc, err = exportGitHub(cfg, opts)
+	case "gitlab":
+		doc, err = exportGitLab(cfg, opts)
+	default:
+		return nil, fmt.Errorf("unknown export provider %q (available: %s)", provider, strings.Join(ExportProviders, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s Do not edit by hand;\n", GeneratedMarker)
+	fmt.Fprintf(&buf, "# change .buildbouncer/config.yaml and re-run `build-bouncer ci export --provider %s`.\n", provider)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IsGenerated reports whether data was written by `ci export`.
+func IsGenerated(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), []byte(GeneratedMarker))
+}
+
+func isGeneratedFile(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && IsGenerated(data)
+}
+
+func installVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
+
+func checkCommand(opts ExportOptions) string {
+	cmd := "build-bouncer check --ci"
+	if strings.TrimSpace(opts.Profile) != "" {
+		cmd += " --profile " + strings.TrimSpace(opts.Profile)
+	}
+	return cmd
+}
+
+// exportChecks returns the checks an expanded export runs, per OS. Checks imported from
+// CI already run there and are left out; checks without os: run on linux.
+func exportChecks(cfg *config.Config, osName string) []config.Check {
+	var out []config.Check
+	for _, check := range cfg.Checks {
+		// Built-in checks have no command to copy; they need build-bouncer itself.
+		if ProviderForCheck(check) != "" || check.Builtin != "" {
+			continue
+		}
+		targets := check.OS
+		if len(targets) == 0 {
+			targets = config.StringList{"linux"}
+		}
+		for _, target := range targets {
+			if strings.EqualFold(strings.TrimSpace(target), osName) {
+				out = append(out, check)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// OmittedBuiltins names the built-in checks an expanded export leaves out, so callers
+// can warn about them.
+func OmittedBuiltins(cfg *config.Config) []string {
+	var names []string
+	for _, check := range cfg.Checks {
+		if check.Builtin != "" {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+var exportOSes = []string{"linux", "macos", "windows"}
+
+func timeoutMinutes(check config.Check) int {
+	if check.Timeout <= 0 {
+		return 0
+	}
+	return int(math.Ceil(check.Timeout.Minutes()))
+}
+
+// ---- GitHub Actions ----
+
+type ghExportWorkflow struct {
+	Name string                 `yaml:"name"`
+	On   ghExportTriggers       `yaml:"on"`
+	Jobs map[string]ghExportJob `yaml:"jobs"`
+}
+
+type ghExportTriggers struct {
+	Push        struct{} `yaml:"push"`
+	PullRequest struct{} `yaml:"pull_request"`
+}
+
+type ghExportJob struct {
+	Name   string         `yaml:"name,omitempty"`
+	RunsOn string         `yaml:"runs-on"`
+	Steps  []ghExportStep `yaml:"steps"`
+}
+
+type ghExportStep struct {
+	Name             string            `yaml:"name,omitempty"`
+	Uses             string            `yaml:"uses,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
+	Run              string            `yaml:"run,omitempty"`
+	Shell            string            `yaml:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+	TimeoutMinutes   int               `yaml:"timeout-minutes,omitempty"`
+}
+
+var gitHubRunners = map[string]string{
+	"linux":   "ubuntu-latest",
+	"macos":   "macos-latest",
+	"windows": "windows-latest",
+}
+
+func exportGitHub(cfg *config.Config, opts ExportOptions) (ghExportWorkflow, error) {
+	wf := ghExportWorkflow{Name: "build-bouncer", Jobs: map[string]ghExportJob{}}
+	checkout := ghExportStep{Uses: "actions/checkout@v4"}
+
+	if !opts.Expand {
+		wf.Jobs["build-bouncer"] = ghExportJob{
+			RunsOn: gitHubRunners["linux"],
+			Steps: []ghExportStep{
+				checkout,
+				{Uses: "actions/setup-go@v5", With: map[string]string{"go-version": "stable"}},
+				{Name: "Install build-bouncer", Run: "go install " + InstallPackage + "@" + installVersion(opts.Version)},
+				{Name: "Run checks", Run: checkCommand(opts)},
+			},
+		}
+		return wf, nil
+	}
+
+	for _, osName := range exportOSes {
+		checks := exportChecks(cfg, osName)
+		if len(checks) == 0 {
+			continue
+		}
+		ctx := config.Interpolation{
+			RepoRoot:  "${{ github.workspace }}",
+			Branch:    "${{ github.ref_name }}",
+			OS:        osName,
+			LookupEnv: func(name string) (string, bool) { return "${{ env." + name + " }}", true },
+		}
+		job := ghExportJob{RunsOn: gitHubRunners[osName], Steps: []ghExportStep{checkout}}
+		for _, raw := range checks {
+			check, err := cfg.ExpandCheck(raw, ctx)
+			if err != nil {
+				return wf, err
+			}
+			job.Steps = append(job.Steps, ghExportStep{
+				Name:             check.Name,
+				Run:              check.Run,
+				Shell:            gitHubShell(check.Shell),
+				WorkingDirectory: check.Cwd,
+				Env:              check.Env,
+				TimeoutMinutes:   timeoutMinutes(check),
+			})
+		}
+		wf.Jobs[osName] = job
+	}
+	if len(wf.Jobs) == 0 {
+		return wf, fmt.Errorf("no checks to export")
+	}
+	return wf, nil
+}
+
+// gitHubShell maps a check shell onto `shell:`; shells Actions doesn't know are run
+// through the custom-shell form.
+func gitHubShell(shell string) string {
+	shell = strings.TrimSpace(shell)
+	switch shell {
+	case "", "bash", "pwsh", "powershell", "sh", "cmd", "python":
+		return shell
+	}
+	return shell + " {0}"
+}
+
+// ---- GitLab CI ----
+
+type glExportJob struct {
+	Image     string            `yaml:"image,omitempty"`
+	Tags      []string          `yaml:"tags,omitempty"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Timeout   string            `yaml:"timeout,omitempty"`
+	Script    []string          `yaml:"script"`
+}
+
+// GitLab.com's hosted runners for non-Linux jobs.
+var gitLabRunnerTags = map[string][]string{
+	"macos":   {"saas-macos-medium-m1"},
+	"windows": {"saas-windows-medium-amd64"},
+}
+
+// gitLabJobPrefix namespaces expanded job keys away from GitLab's reserved keywords.
+const gitLabJobPrefix = "bb:"
+
+func exportGitLab(cfg *config.Config, opts ExportOptions) (map[string]glExportJob, error) {
+	jobs := map[string]glExportJob{}
+	if !opts.Expand {
+		jobs["build-bouncer"] = glExportJob{
+			Image: "golang:latest",
+			Script: []string{
+				"go install " + InstallPackage + "@" + installVersion(opts.Version),
+				checkCommand(opts),
+			},
+		}
+		return jobs, nil
+	}
+
+	for _, osName := range exportOSes {
+		ctx := config.Interpolation{
+			RepoRoot:  "$CI_PROJECT_DIR",
+			Branch:    "$CI_COMMIT_REF_NAME",
+			OS:        osName,
+			LookupEnv: func(name string) (string, bool) { return "$" + name, true },
+		}
+		for _, raw := range exportChecks(cfg, osName) {
+			check, err := cfg.ExpandCheck(raw, ctx)
+			if err != nil {
+				return nil, err
+			}
+			var script []string
+			if cwd := strings.TrimSpace(check.Cwd); cwd != "" {
+				script = append(script, "cd "+cwd)
+			}
+			script = append(script, check.Run)
+			job := glExportJob{
+				Tags:      gitLabRunnerTags[osName],
+				Variables: check.Env,
+				Script:    script,
+			}
+			if minutes := timeoutMinutes(check); minutes > 0 {
+				job.Timeout = fmt.Sprintf("%dm", minutes)
+			}
+
+			name := check.Name
+			if len(check.OS) > 1 {
+				name = fmt.Sprintf("%s [%s]", check.Name, osName)
+			}
+			// Top-level keys double as GitLab keywords (image, stages, default, ...) and a
+			// leading "." hides a job, so a check name cannot be used as the key on its own.
+			jobs[gitLabJobPrefix+name] = job
+		}
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no checks to export")
+	}
+	return jobs, nil
+}
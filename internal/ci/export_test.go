@@ -0,0 +1,177 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const exportConfig = `
+version: 1
+vars:
+  pkgs: ./...
+checks:
+  - name: "tests"
+    run: "go test ${{ vars.pkgs }}"
+    cwd: "app"
+    env:
+      CGO_ENABLED: "0"
+    timeout: 90s
+  - name: "windows-build"
+    run: "go build ./..."
+    shell: pwsh
+    os: [windows]
+  - name: "ci:ci:build:lint"
+    source: "ci:ci"
+    run: "go vet ./..."
+`
+
+func TestExportGitHubInstallsAndRunsCheck(t *testing.T) {
+	cfg, err := config.Parse([]byte(exportConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	data, err := Export("github", cfg, ExportOptions{Version: "v1.2.3", Profile: "ci"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	out := string(data)
+	if !IsGenerated(data) {
+		t.Fatalf("expected generated marker, got:\n%s", out)
+	}
+	for _, want := range []string{
+		"runs-on: ubuntu-latest",
+		"run: go install " + InstallPackage + "@v1.2.3",
+		"run: build-bouncer check --ci --profile ci",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	again, err := Export("github", cfg, ExportOptions{Version: "v1.2.3", Profile: "ci"})
+	if err != nil || string(again) != out {
+		t.Fatalf("expected identical output on re-export (err=%v)", err)
+	}
+}
+
+func TestExportGitHubExpandedMapsCheckFields(t *testing.T) {
+	cfg, err := config.Parse([]byte(exportConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	data, err := Export("github", cfg, ExportOptions{Expand: true})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	want := GeneratedMarker + ` Do not edit by hand;
+# change .buildbouncer/config.yaml and re-run ` + "`build-bouncer ci export --provider github`" + `.
+name: build-bouncer
+"on":
+  push: {}
+  pull_request: {}
+jobs:
+  linux:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: tests
+        run: go test ./...
+        working-directory: app
+        env:
+          CGO_ENABLED: "0"
+        timeout-minutes: 2
+  windows:
+    runs-on: windows-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: windows-build
+        run: go build ./...
+        shell: pwsh
+`
+	if string(data) != want {
+		t.Fatalf("unexpected workflow:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestExportGitLabExpanded(t *testing.T) {
+	cfg, err := config.Parse([]byte(exportConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	data, err := Export("gitlab", cfg, ExportOptions{Expand: true})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"bb:tests:\n  variables:\n    CGO_ENABLED: \"0\"\n  timeout: 2m\n  script:\n    - cd app\n    - go test ./...",
+		"bb:windows-build:\n  tags:\n    - saas-windows-medium-amd64",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "go vet") {
+		t.Fatalf("expected CI-imported checks to be left out:\n%s", out)
+	}
+}
+
+func TestExportGitLabPrefixesReservedJobNames(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+version: 1
+checks:
+  - name: "image"
+    run: "docker build ."
+  - name: ".hidden"
+    run: "make hidden"
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	data, err := Export("gitlab", cfg, ExportOptions{Expand: true})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("parse export: %v\n%s", err, data)
+	}
+	for _, key := range []string{"bb:image", "bb:.hidden"} {
+		if _, ok := doc[key].(map[string]any); !ok {
+			t.Fatalf("expected job %q in:\n%s", key, data)
+		}
+	}
+	if _, ok := doc["image"]; ok {
+		t.Fatalf("expected no top-level image keyword:\n%s", data)
+	}
+}
+
+func TestImportersSkipExportedFiles(t *testing.T) {
+	cfg, err := config.Parse([]byte(exportConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	root := t.TempDir()
+	for _, provider := range ExportProviders {
+		data, err := Export(provider, cfg, ExportOptions{Expand: true})
+		if err != nil {
+			t.Fatalf("export %s: %v", provider, err)
+		}
+		writeCIFile(t, root, DefaultExportPath(provider), string(data))
+	}
+
+	if checks, err := ChecksFromGitHubActions(root); err != nil || len(checks) != 0 {
+		t.Fatalf("expected exported workflow to be skipped, got %+v (err=%v)", checks, err)
+	}
+	if checks, err := ChecksFromGitLabCI(root); err != nil || len(checks) != 0 {
+		t.Fatalf("expected exported .gitlab-ci.yml to be skipped, got %+v (err=%v)", checks, err)
+	}
+}
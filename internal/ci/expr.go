@@ -0,0 +1,763 @@
+package ci
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements the GitHub Actions expression language (`${{ }}` and `if:`)
+// with one addition: values can be unknown. The importer evaluates conditions against
+// what a local push knows (event, ref, OS, matrix values, env); anything else —
+// secrets, step outputs, event payloads — is unknown, and unknown propagates unless an
+// operator can decide without it (false && x, true || x).
+
+type exprKind int
+
+const (
+	exprUnknown exprKind = iota
+	exprNull
+	exprBool
+	exprNumber
+	exprString
+	exprArray
+	exprObject
+)
+
+type exprValue struct {
+	kind exprKind
+	b    bool
+	n    float64
+	s    string
+	arr  []exprValue
+	obj  map[string]exprValue
+
+	// partial objects only know some of their keys; missing keys are unknown, not null.
+	partial bool
+}
+
+var (
+	unknownValue = exprValue{kind: exprUnknown}
+	nullValue    = exprValue{kind: exprNull}
+)
+
+func boolValue(b bool) exprValue                   { return exprValue{kind: exprBool, b: b} }
+func numberValue(n float64) exprValue              { return exprValue{kind: exprNumber, n: n} }
+func stringValue(s string) exprValue               { return exprValue{kind: exprString, s: s} }
+func objectValue(m map[string]exprValue) exprValue { return exprValue{kind: exprObject, obj: m} }
+
+// exprContext is the local stand-in for the contexts a workflow run would see.
+type exprContext struct {
+	contexts map[string]exprValue
+}
+
+// newExprContext describes a local push of branch on currentOS. matrix holds the values
+// the job would run with locally (unknown where the importer cannot pick one); env holds
+// the workflow/job env in scope, where values that are themselves expressions are unknown.
+func newExprContext(branch string, currentOS string, matrix map[string]exprValue, env map[string]string) exprContext {
+	github := map[string]exprValue{
+		"event_name": stringValue("push"),
+		"ref_type":   stringValue("branch"),
+		"head_ref":   stringValue(""),
+		"base_ref":   stringValue(""),
+	}
+	if branch != "" {
+		github["ref"] = stringValue("refs/heads/" + branch)
+		github["ref_name"] = stringValue(branch)
+	}
+
+	runner := map[string]exprValue{}
+	switch currentOS {
+	case "windows":
+		runner["os"] = stringValue("Windows")
+	case "macos":
+		runner["os"] = stringValue("macOS")
+	case "linux":
+		runner["os"] = stringValue("Linux")
+	}
+
+	envValues := make(map[string]exprValue, len(env))
+	for k, v := range env {
+		if strings.Contains(v, "${{") {
+			envValues[k] = unknownValue
+			continue
+		}
+		envValues[k] = stringValue(v)
+	}
+
+	return exprContext{contexts: map[string]exprValue{
+		"github": {kind: exprObject, obj: github, partial: true},
+		"runner": {kind: exprObject, obj: runner, partial: true},
+		"matrix": objectValue(matrix),
+		"env":    objectValue(envValues),
+	}}
+}
+
+// evalCondition evaluates an `if:` condition. decided is false when the result depends
+// on something unknown locally.
+func evalCondition(condition string, ctx exprContext) (decided bool, result bool, err error) {
+	expr := strings.TrimSpace(condition)
+	if expr == "" {
+		return true, true, nil
+	}
+	if strings.HasPrefix(expr, "${{") && strings.HasSuffix(expr, "}}") && strings.Count(expr, "${{") == 1 {
+		expr = strings.TrimSpace(expr[3 : len(expr)-2])
+	}
+	value, err := evalExpression(expr, ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if value.kind == exprUnknown {
+		return false, false, nil
+	}
+	return true, value.truthy(), nil
+}
+
+func evalExpression(expr string, ctx exprContext) (exprValue, error) {
+	tokens, err := lexExpression(expr)
+	if err != nil {
+		return unknownValue, err
+	}
+	p := &exprParser{tokens: tokens, ctx: ctx}
+	value := p.parseOr()
+	if p.err != nil {
+		return unknownValue, p.err
+	}
+	if p.pos < len(p.tokens) {
+		return unknownValue, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return value, nil
+}
+
+// ---- lexer ----
+
+type exprTokenKind int
+
+const (
+	tokIdent exprTokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+func lexExpression(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for {
+				if j >= len(expr) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if expr[j] == '\'' {
+					if j+1 < len(expr) && expr[j+1] == '\'' {
+						b.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(expr[j])
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: b.String()})
+			i = j + 1
+		case c >= '0' && c <= '9', c == '-' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			j := i + 1
+			for j < len(expr) && (isIdentChar(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: expr[i:j]})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdentChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: expr[i:j]})
+			i = j
+		default:
+			two := ""
+			if i+1 < len(expr) {
+				two = expr[i : i+2]
+			}
+			switch two {
+			case "&&", "||", "==", "!=", "<=", ">=":
+				tokens = append(tokens, exprToken{kind: tokOp, text: two})
+				i += 2
+				continue
+			}
+			if strings.IndexByte("()[].,!<>*", c) < 0 {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
+
+// ---- parser / evaluator ----
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	ctx    exprContext
+	err    error
+}
+
+func (p *exprParser) peekOp(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokOp && p.tokens[p.pos].text == op
+}
+
+func (p *exprParser) expectOp(op string) {
+	if !p.peekOp(op) {
+		if p.err == nil {
+			p.err = fmt.Errorf("expected %q", op)
+		}
+		return
+	}
+	p.pos++
+}
+
+// parseOr and parseAnd return the deciding operand, like GitHub does, so
+// `x || 'default'` yields a string rather than a bool.
+func (p *exprParser) parseOr() exprValue {
+	left := p.parseAnd()
+	for p.peekOp("||") && p.err == nil {
+		p.pos++
+		right := p.parseAnd()
+		switch {
+		case left.kind != exprUnknown && left.truthy():
+		case left.kind != exprUnknown:
+			left = right
+		case right.kind != exprUnknown && right.truthy():
+			left = right
+		default:
+			left = unknownValue
+		}
+	}
+	return left
+}
+
+func (p *exprParser) parseAnd() exprValue {
+	left := p.parseEquality()
+	for p.peekOp("&&") && p.err == nil {
+		p.pos++
+		right := p.parseEquality()
+		switch {
+		case left.kind != exprUnknown && !left.truthy():
+		case left.kind != exprUnknown:
+			left = right
+		case right.kind != exprUnknown && !right.truthy():
+			left = right
+		default:
+			left = unknownValue
+		}
+	}
+	return left
+}
+
+func (p *exprParser) parseEquality() exprValue {
+	left := p.parseComparison()
+	for (p.peekOp("==") || p.peekOp("!=")) && p.err == nil {
+		op := p.tokens[p.pos].text
+		p.pos++
+		right := p.parseComparison()
+		if left.kind == exprUnknown || right.kind == exprUnknown {
+			left = unknownValue
+			continue
+		}
+		equal := exprEqual(left, right)
+		left = boolValue(equal == (op == "=="))
+	}
+	return left
+}
+
+func (p *exprParser) parseComparison() exprValue {
+	left := p.parseUnary()
+	for (p.peekOp("<") || p.peekOp("<=") || p.peekOp(">") || p.peekOp(">=")) && p.err == nil {
+		op := p.tokens[p.pos].text
+		p.pos++
+		right := p.parseUnary()
+		if left.kind == exprUnknown || right.kind == exprUnknown {
+			left = unknownValue
+			continue
+		}
+		cmp, ok := exprCompare(left, right)
+		if !ok {
+			left = boolValue(false)
+			continue
+		}
+		switch op {
+		case "<":
+			left = boolValue(cmp < 0)
+		case "<=":
+			left = boolValue(cmp <= 0)
+		case ">":
+			left = boolValue(cmp > 0)
+		default:
+			left = boolValue(cmp >= 0)
+		}
+	}
+	return left
+}
+
+func (p *exprParser) parseUnary() exprValue {
+	if p.peekOp("!") {
+		p.pos++
+		v := p.parseUnary()
+		if v.kind == exprUnknown {
+			return unknownValue
+		}
+		return boolValue(!v.truthy())
+	}
+	return p.parsePostfix(p.parsePrimary())
+}
+
+func (p *exprParser) parsePostfix(v exprValue) exprValue {
+	for p.err == nil {
+		switch {
+		case p.peekOp("."):
+			p.pos++
+			if p.peekOp("*") {
+				p.pos++
+				v = v.filter()
+				continue
+			}
+			if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokIdent {
+				p.err = fmt.Errorf("expected property name")
+				return unknownValue
+			}
+			v = v.property(p.tokens[p.pos].text)
+			p.pos++
+		case p.peekOp("["):
+			p.pos++
+			if p.peekOp("*") {
+				p.pos++
+				p.expectOp("]")
+				v = v.filter()
+				continue
+			}
+			index := p.parseOr()
+			p.expectOp("]")
+			v = v.index(index)
+		default:
+			return v
+		}
+	}
+	return unknownValue
+}
+
+func (p *exprParser) parsePrimary() exprValue {
+	if p.pos >= len(p.tokens) {
+		p.err = fmt.Errorf("unexpected end of expression")
+		return unknownValue
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokString:
+		return stringValue(tok.text)
+	case tokNumber:
+		return numberValue(parseExprNumber(tok.text))
+	case tokOp:
+		if tok.text == "(" {
+			v := p.parseOr()
+			p.expectOp(")")
+			return v
+		}
+		p.err = fmt.Errorf("unexpected %q", tok.text)
+		return unknownValue
+	}
+
+	switch tok.text {
+	case "true":
+		return boolValue(true)
+	case "false":
+		return boolValue(false)
+	case "null":
+		return nullValue
+	}
+
+	if p.peekOp("(") {
+		p.pos++
+		var args []exprValue
+		for !p.peekOp(")") && p.err == nil {
+			args = append(args, p.parseOr())
+			if !p.peekOp(",") {
+				break
+			}
+			p.pos++
+		}
+		p.expectOp(")")
+		if p.err != nil {
+			return unknownValue
+		}
+		v, err := callExprFunction(tok.text, args)
+		if err != nil {
+			p.err = err
+		}
+		return v
+	}
+
+	if v, ok := p.ctx.contexts[strings.ToLower(tok.text)]; ok {
+		return v
+	}
+	return unknownValue
+}
+
+func parseExprNumber(text string) float64 {
+	if strings.HasPrefix(text, "0x") {
+		if n, err := strconv.ParseInt(text[2:], 16, 64); err == nil {
+			return float64(n)
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return n
+}
+
+// ---- values ----
+
+func (v exprValue) truthy() bool {
+	switch v.kind {
+	case exprBool:
+		return v.b
+	case exprNumber:
+		return v.n != 0 && !math.IsNaN(v.n)
+	case exprString:
+		return v.s != ""
+	case exprArray, exprObject:
+		return true
+	}
+	return false
+}
+
+func (v exprValue) number() float64 {
+	switch v.kind {
+	case exprNull:
+		return 0
+	case exprBool:
+		if v.b {
+			return 1
+		}
+		return 0
+	case exprNumber:
+		return v.n
+	case exprString:
+		s := strings.TrimSpace(v.s)
+		if s == "" {
+			return 0
+		}
+		return parseExprNumber(s)
+	}
+	return math.NaN()
+}
+
+func (v exprValue) String() string {
+	switch v.kind {
+	case exprNull:
+		return ""
+	case exprBool:
+		return strconv.FormatBool(v.b)
+	case exprNumber:
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	case exprString:
+		return v.s
+	case exprArray:
+		return "Array"
+	case exprObject:
+		return "Object"
+	}
+	return ""
+}
+
+// property looks up a key case-insensitively; missing keys on known objects are null.
+func (v exprValue) property(name string) exprValue {
+	switch v.kind {
+	case exprUnknown:
+		return unknownValue
+	case exprObject:
+		if child, ok := v.obj[name]; ok {
+			return child
+		}
+		for k, child := range v.obj {
+			if strings.EqualFold(k, name) {
+				return child
+			}
+		}
+		if v.partial {
+			return unknownValue
+		}
+		return nullValue
+	case exprArray:
+		return v.filter().property(name)
+	}
+	return nullValue
+}
+
+func (v exprValue) index(i exprValue) exprValue {
+	if v.kind == exprUnknown || i.kind == exprUnknown {
+		return unknownValue
+	}
+	if v.kind == exprArray {
+		n := i.number()
+		if math.IsNaN(n) || n < 0 || int(n) >= len(v.arr) {
+			return nullValue
+		}
+		return v.arr[int(n)]
+	}
+	return v.property(i.String())
+}
+
+// filter implements `.*`: the values of an object or array, flattened one level for
+// subsequent property access.
+func (v exprValue) filter() exprValue {
+	switch v.kind {
+	case exprUnknown:
+		return unknownValue
+	case exprArray:
+		return v
+	case exprObject:
+		if v.partial {
+			return unknownValue
+		}
+		keys := make([]string, 0, len(v.obj))
+		for k := range v.obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]exprValue, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, v.obj[k])
+		}
+		return exprValue{kind: exprArray, arr: out}
+	}
+	return exprValue{kind: exprArray}
+}
+
+// exprEqual follows GitHub's loose equality: strings compare case-insensitively,
+// mismatched primitive types are coerced to numbers, arrays/objects never equal.
+func exprEqual(a exprValue, b exprValue) bool {
+	if a.kind == b.kind {
+		switch a.kind {
+		case exprNull:
+			return true
+		case exprBool:
+			return a.b == b.b
+		case exprNumber:
+			return a.n == b.n
+		case exprString:
+			return strings.EqualFold(a.s, b.s)
+		}
+		return false
+	}
+	if a.kind == exprArray || a.kind == exprObject || b.kind == exprArray || b.kind == exprObject {
+		return false
+	}
+	return a.number() == b.number()
+}
+
+func exprCompare(a exprValue, b exprValue) (int, bool) {
+	if a.kind == exprString && b.kind == exprString {
+		return strings.Compare(strings.ToLower(a.s), strings.ToLower(b.s)), true
+	}
+	x, y := a.number(), b.number()
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+// ---- functions ----
+
+func callExprFunction(name string, args []exprValue) (exprValue, error) {
+	lower := strings.ToLower(name)
+	arity := map[string][2]int{
+		"success": {0, 0}, "always": {0, 0}, "cancelled": {0, 0}, "failure": {0, 0},
+		"contains": {2, 2}, "startswith": {2, 2}, "endswith": {2, 2},
+		"format": {1, math.MaxInt}, "join": {1, 2}, "tojson": {1, 1}, "fromjson": {1, 1},
+		"hashfiles": {1, math.MaxInt},
+	}
+	bounds, ok := arity[lower]
+	if !ok {
+		return unknownValue, fmt.Errorf("unknown function %s()", name)
+	}
+	if len(args) < bounds[0] || len(args) > bounds[1] {
+		return unknownValue, fmt.Errorf("%s() called with %d arguments", name, len(args))
+	}
+
+	// Status checks: a local run only gets this far when earlier steps succeeded.
+	switch lower {
+	case "success", "always":
+		return boolValue(true), nil
+	case "cancelled", "failure":
+		return boolValue(false), nil
+	case "hashfiles":
+		return unknownValue, nil
+	}
+
+	for _, arg := range args {
+		if arg.kind == exprUnknown {
+			return unknownValue, nil
+		}
+	}
+
+	switch lower {
+	case "contains":
+		if args[0].kind == exprArray {
+			for _, item := range args[0].arr {
+				if exprEqual(item, args[1]) {
+					return boolValue(true), nil
+				}
+			}
+			return boolValue(false), nil
+		}
+		return boolValue(strings.Contains(strings.ToLower(args[0].String()), strings.ToLower(args[1].String()))), nil
+	case "startswith":
+		return boolValue(strings.HasPrefix(strings.ToLower(args[0].String()), strings.ToLower(args[1].String()))), nil
+	case "endswith":
+		return boolValue(strings.HasSuffix(strings.ToLower(args[0].String()), strings.ToLower(args[1].String()))), nil
+	case "format":
+		out, err := formatExpr(args[0].String(), args[1:])
+		return stringValue(out), err
+	case "join":
+		sep := ","
+		if len(args) == 2 {
+			sep = args[1].String()
+		}
+		if args[0].kind != exprArray {
+			return stringValue(args[0].String()), nil
+		}
+		parts := make([]string, 0, len(args[0].arr))
+		for _, item := range args[0].arr {
+			parts = append(parts, item.String())
+		}
+		return stringValue(strings.Join(parts, sep)), nil
+	case "tojson":
+		b, err := json.Marshal(args[0].toInterface())
+		if err != nil {
+			return unknownValue, err
+		}
+		return stringValue(string(b)), nil
+	case "fromjson":
+		var raw interface{}
+		if err := json.Unmarshal([]byte(args[0].String()), &raw); err != nil {
+			return unknownValue, fmt.Errorf("fromJSON: %w", err)
+		}
+		return valueFromInterface(raw), nil
+	}
+	return unknownValue, nil
+}
+
+func formatExpr(format string, args []exprValue) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '{' && i+1 < len(format) && format[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(format) && format[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("format: unclosed {")
+			}
+			n, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || n < 0 || n >= len(args) {
+				return "", fmt.Errorf("format: bad placeholder %q", format[i:i+end+1])
+			}
+			b.WriteString(args[n].String())
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func (v exprValue) toInterface() interface{} {
+	switch v.kind {
+	case exprBool:
+		return v.b
+	case exprNumber:
+		return v.n
+	case exprString:
+		return v.s
+	case exprArray:
+		out := make([]interface{}, len(v.arr))
+		for i, item := range v.arr {
+			out[i] = item.toInterface()
+		}
+		return out
+	case exprObject:
+		out := make(map[string]interface{}, len(v.obj))
+		for k, item := range v.obj {
+			out[k] = item.toInterface()
+		}
+		return out
+	}
+	return nil
+}
+
+func valueFromInterface(raw interface{}) exprValue {
+	switch v := raw.(type) {
+	case nil:
+		return nullValue
+	case bool:
+		return boolValue(v)
+	case float64:
+		return numberValue(v)
+	case int:
+		return numberValue(float64(v))
+	case string:
+		return stringValue(v)
+	case []interface{}:
+		out := make([]exprValue, len(v))
+		for i, item := range v {
+			out[i] = valueFromInterface(item)
+		}
+		return exprValue{kind: exprArray, arr: out}
+	case map[string]interface{}:
+		out := make(map[string]exprValue, len(v))
+		for k, item := range v {
+			out[k] = valueFromInterface(item)
+		}
+		return objectValue(out)
+	}
+	return stringValue(fmt.Sprint(raw))
+}
@@ -0,0 +1,99 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	matrix := map[string]exprValue{
+		"os": stringValue("ubuntu-latest"),
+		"go": unknownValue,
+	}
+	ctx := newExprContext("main", "linux", matrix, map[string]string{"MODE": "release", "TOKEN": "${{ secrets.TOKEN }}"})
+
+	cases := []struct {
+		expr    string
+		decided bool
+		want    bool
+	}{
+		{expr: "", decided: true, want: true},
+		{expr: "runner.os == 'Linux'", decided: true, want: true},
+		{expr: "${{ runner.os == 'windows' }}", decided: true, want: false},
+		{expr: "github.event_name == 'push' && github.ref == 'refs/heads/main'", decided: true, want: true},
+		{expr: "github.event_name == 'pull_request'", decided: true, want: false},
+		{expr: "startsWith(github.ref, 'refs/tags/')", decided: true, want: false},
+		{expr: "contains(fromJSON('[\"main\", \"develop\"]'), github.ref_name)", decided: true, want: true},
+		{expr: "format('{0}-{1}', matrix.os, env.MODE) == 'ubuntu-latest-release'", decided: true, want: true},
+		{expr: "endsWith(matrix.os, '-latest') && !cancelled()", decided: true, want: true},
+		{expr: "matrix.go == '1.22'", decided: false},
+		{expr: "matrix.go == '1.22' && runner.os == 'Windows'", decided: true, want: false},
+		{expr: "secrets.DEPLOY_KEY != ''", decided: false},
+		{expr: "secrets.DEPLOY_KEY != '' || github.event_name == 'push'", decided: true, want: true},
+		{expr: "env.TOKEN != ''", decided: false},
+		{expr: "github.event.pull_request.draft == false", decided: false},
+		{expr: "hashFiles('**/go.sum') != ''", decided: false},
+		{expr: "(1 < 2) && '3' == 3 && null == 0", decided: true, want: true},
+		{expr: "toJSON(matrix.os) == '\"ubuntu-latest\"'", decided: true, want: true},
+		{expr: "matrix.missing == ''", decided: true, want: true},
+	}
+	for _, tc := range cases {
+		decided, got, err := evalCondition(tc.expr, ctx)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.expr, err)
+		}
+		if decided != tc.decided || (decided && got != tc.want) {
+			t.Fatalf("%q: got decided=%v result=%v, want decided=%v result=%v", tc.expr, decided, got, tc.decided, tc.want)
+		}
+	}
+}
+
+func TestEvalConditionRejectsMalformed(t *testing.T) {
+	ctx := newExprContext("main", "linux", nil, nil)
+	for _, expr := range []string{"runner.os ==", "nope('x')", "'unterminated", "format('{1}', 'a')"} {
+		if _, _, err := evalCondition(expr, ctx); err == nil {
+			t.Fatalf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestChecksFromGitHubActionsAnnotatesUndecidedConditions(t *testing.T) {
+	root := t.TempDir()
+	workflowDir := filepath.Join(root, ".github", "workflows")
+	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+		t.Fatalf("create workflows dir: %v", err)
+	}
+
+	content := `
+jobs:
+  build:
+    steps:
+      - name: test
+        run: go test ./...
+      - name: pr only
+        if: github.event_name == 'pull_request'
+        run: echo pr
+      - name: deploy
+        if: ${{ secrets.DEPLOY_KEY != '' }}
+        run: ./deploy.sh
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "ci.yml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+
+	checks, err := ChecksFromGitHubActions(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitHubActions error: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("expected test and deploy checks, got %+v", checks)
+	}
+	if checks[0].Note != "" {
+		t.Fatalf("expected no note on unconditional step, got %q", checks[0].Note)
+	}
+	if !strings.Contains(checks[1].Note, "undecided CI condition") || !strings.Contains(checks[1].Note, "secrets.DEPLOY_KEY") {
+		t.Fatalf("expected undecided note on deploy, got %q", checks[1].Note)
+	}
+}
@@ -7,16 +7,20 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
 	"github.com/berniemackie97/build-bouncer/internal/shell"
 	"gopkg.in/yaml.v3"
 )
 
 type Workflow struct {
-	Name string         `yaml:"name"`
-	Jobs map[string]Job `yaml:"jobs"`
+	Name string                 `yaml:"name"`
+	On   yaml.Node              `yaml:"on"`
+	Env  map[string]interface{} `yaml:"env"`
+	Jobs map[string]Job         `yaml:"jobs"`
 }
 
 type Job struct {
@@ -27,6 +31,8 @@ type Job struct {
 	RunsOn   RunsOn                 `yaml:"runs-on"`
 	If       string                 `yaml:"if"`
 	Strategy Strategy               `yaml:"strategy"`
+	Uses     string                 `yaml:"uses"`
+	With     map[string]interface{} `yaml:"with"`
 }
 
 type Defaults struct {
@@ -51,6 +57,8 @@ type Step struct {
 
 type Strategy struct {
 	Matrix map[string]interface{} `yaml:"matrix"`
+	// MatrixAxes lists the matrix keys in file order, without include/exclude.
+	MatrixAxes []string `yaml:"-"`
 }
 
 type RunsOn struct {
@@ -97,6 +105,7 @@ func ChecksFromGitHubActions(root string) ([]config.Check, error) {
 		return nil, err
 	}
 
+	branch := git.CurrentBranch(root)
 	var checks []config.Check
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -109,7 +118,7 @@ func ChecksFromGitHubActions(root string) ([]config.Check, error) {
 		}
 
 		path := filepath.Join(workflowDir, name)
-		fileChecks, err := checksFromWorkflowFile(path)
+		fileChecks, err := checksFromWorkflowFile(root, path, branch)
 		if err != nil {
 			return nil, fmt.Errorf("parse workflow %s: %w", path, err)
 		}
@@ -118,93 +127,295 @@ func ChecksFromGitHubActions(root string) ([]config.Check, error) {
 
 	// Normalize checks for local execution
 	for i := range checks {
-		checks[i].Cwd = normalizeWorkingDirectory(checks[i].Cwd)
+		checks[i].Cwd = normalizeGitHubCwd(checks[i].Cwd)
 		checks[i].Run = normalizeGitHubActionsTemplates(checks[i].Run)
+		checks[i].Env = normalizeGitHubEnv(checks[i].Env)
 	}
 
-	return checks, nil
+	return uniqueCheckNames(checks), nil
 }
 
-func checksFromWorkflowFile(path string) ([]config.Check, error) {
-	data, err := os.ReadFile(path)
+func checksFromWorkflowFile(root string, path string, branch string) ([]config.Check, error) {
+	if isGeneratedFile(path) {
+		return nil, nil
+	}
+	wf, err := readWorkflow(path)
 	if err != nil {
 		return nil, err
 	}
+	// Workflows that only run via workflow_call are imported through their callers.
+	if wf.reusableOnly() {
+		return nil, nil
+	}
 
+	imp := &workflowImport{
+		root:      root,
+		branch:    branch,
+		currentOS: currentRunnerOS(),
+		label:     labelFromWorkflow(path, wf.Name),
+		stack:     []string{filepath.Clean(path)},
+	}
+	return imp.workflowChecks(wf, nil, nil)
+}
+
+func readWorkflow(path string) (Workflow, error) {
 	var wf Workflow
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return wf, err
+	}
 	if err := yaml.Unmarshal(data, &wf); err != nil {
-		return nil, err
+		return wf, err
 	}
+	return wf, nil
+}
+
+// workflowImport carries the state shared by one top-level workflow while its jobs,
+// reusable workflows, and composite actions are flattened into checks.
+type workflowImport struct {
+	root      string
+	branch    string
+	currentOS string
+	label     string
+	// stack holds the workflow and action files being expanded, to catch cycles.
+	stack []string
+}
+
+// workflowChecks imports every job of wf. jobPrefix labels the calling job when wf is a
+// reusable workflow; inputs are its resolved workflow_call inputs.
+func (w *workflowImport) workflowChecks(wf Workflow, jobPrefix []string, inputs map[string]string) ([]config.Check, error) {
+	workflowEnv := substituteKnownInEnv(normalizeEnv(wf.Env), knownContext(inputs, nil))
 
-	workflowLabel := labelFromWorkflow(path, wf.Name)
 	var out []config.Check
-	currentOS := currentRunnerOS()
+	for _, jobKey := range sortedJobKeys(wf.Jobs) {
+		job := wf.Jobs[jobKey]
+		jobLabel := append(append([]string{}, jobPrefix...), labelFromJob(jobKey, job.Name))
 
-	for jobKey, job := range wf.Jobs {
-		if !jobAppliesToOS(job, currentOS) {
+		combos, expandable := jobMatrixCombos(job.Strategy)
+		if !expandable {
+			if !runsOnMatches(job.RunsOn, job.Strategy.Matrix, w.currentOS) {
+				continue
+			}
+			matrix := localMatrixContext(job.Strategy.Matrix, w.currentOS)
+			checks, err := w.jobChecks(job, jobLabel, workflowEnv, inputs, matrix, knownContext(inputs, nil))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, checks...)
 			continue
 		}
-		usage := detectToolsUsed(job.Steps)
-		jobLabel := labelFromJob(jobKey, job.Name)
-		jobEnv := normalizeEnv(job.Env)
-		jobDir := strings.TrimSpace(job.Defaults.Run.WorkingDirectory)
 
-		for _, step := range job.Steps {
-			if !stepAppliesToOS(step, currentOS) {
+		for _, combo := range combos {
+			if !runsOnMatches(job.RunsOn, singleComboMatrix(combo), w.currentOS) {
 				continue
 			}
+			matrix := make(map[string]exprValue, len(combo.Values))
+			for k, v := range combo.Values {
+				matrix[k] = stringValue(v)
+			}
+			checks, err := w.jobChecks(job, jobLabel, workflowEnv, inputs, matrix, knownContext(inputs, combo.Values))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, matrixComboChecks(checks, combo)...)
+		}
+	}
 
-			if strings.TrimSpace(step.Run) == "" && strings.TrimSpace(step.Uses) == "" {
-				continue
+	return out, nil
+}
+
+// jobChecks imports one run of a job. matrix is what `if:` conditions see; known holds
+// the inputs and matrix values substituted into run, env, and working-directory.
+func (w *workflowImport) jobChecks(job Job, jobLabel []string, workflowEnv map[string]string, inputs map[string]string, matrix map[string]exprValue, known exprContext) ([]config.Check, error) {
+	jobCtx := newExprContext(w.branch, w.currentOS, matrix, workflowEnv).withInputs(inputs)
+	jobApplies, jobNote := conditionApplies(job.If, jobCtx)
+	if !jobApplies {
+		return nil, nil
+	}
+
+	if isLocalUses(job.Uses) {
+		return w.reusableWorkflowChecks(job, jobLabel, jobNote, known)
+	}
+
+	usage := detectToolsUsed(job.Steps)
+	jobEnv := substituteKnownInEnv(normalizeEnv(job.Env), known)
+	jobDir := substituteKnown(strings.TrimSpace(job.Defaults.Run.WorkingDirectory), known)
+	stepCtx := newExprContext(w.branch, w.currentOS, matrix, mergeEnv(workflowEnv, jobEnv)).withInputs(inputs)
+
+	var out []config.Check
+	for _, step := range job.Steps {
+		stepApplies, stepNote := conditionApplies(step.If, stepCtx)
+		if !stepApplies {
+			continue
+		}
+		note := joinNotes(jobNote, stepNote)
+
+		if strings.TrimSpace(step.Run) == "" && strings.TrimSpace(step.Uses) == "" {
+			continue
+		}
+
+		if isLocalUses(step.Uses) {
+			checks, err := w.compositeActionChecks(step, jobLabel, mergeEnv(workflowEnv, jobEnv), note, known)
+			if err != nil {
+				return nil, err
 			}
+			out = append(out, checks...)
+			continue
+		}
 
-			if strings.TrimSpace(step.Run) == "" {
-				if check := checkFromUsesStep(workflowLabel, jobLabel, jobEnv, jobDir, job.Defaults, step, usage, currentOS); check != nil {
-					out = append(out, *check)
-				}
-				continue
+		if strings.TrimSpace(step.Run) == "" {
+			if check := checkFromUsesStep(w.label, strings.Join(jobLabel, ":"), jobEnv, jobDir, job.Defaults, step, usage, w.currentOS); check != nil {
+				check.Note = note
+				out = append(out, *check)
 			}
+			continue
+		}
 
-			stepLabel := labelFromStep(step)
-			stepEnv := normalizeEnv(step.Env)
-			env := mergeEnv(jobEnv, stepEnv)
+		cwd := strings.TrimSpace(step.WorkingDirectory)
+		if cwd == "" {
+			cwd = jobDir
+		}
+		out = append(out, w.runCheck(append(jobLabel, labelFromStep(step)), step, mergeEnv(jobEnv, normalizeEnv(step.Env)), cwd, job.Defaults.Run.Shell, note, known))
+	}
+	return out, nil
+}
 
-			name := fmt.Sprintf("ci:%s:%s:%s", workflowLabel, jobLabel, stepLabel)
-			cwd := strings.TrimSpace(step.WorkingDirectory)
-			if cwd == "" {
-				cwd = jobDir
-			}
-			shell := resolveShell(step.Shell, job.Defaults.Run.Shell, currentOS)
-			out = append(out, config.Check{
-				Name:   name,
-				Run:    strings.TrimSpace(step.Run),
-				Shell:  shell,
-				Cwd:    cwd,
-				Env:    env,
-				OS:     config.StringList{currentOS},
-				Source: "ci:" + workflowLabel,
-			})
+// runCheck turns a `run:` step into a check named ci:<workflow>:<labels...>.
+func (w *workflowImport) runCheck(labels []string, step Step, env map[string]string, cwd string, defaultShell string, note string, known exprContext) config.Check {
+	return config.Check{
+		Name:   fmt.Sprintf("ci:%s:%s", w.label, strings.Join(labels, ":")),
+		Run:    substituteKnown(strings.TrimSpace(step.Run), known),
+		Shell:  resolveShell(step.Shell, defaultShell, w.currentOS),
+		Cwd:    substituteKnown(cwd, known),
+		Env:    substituteKnownInEnv(env, known),
+		OS:     config.StringList{w.currentOS},
+		Source: "ci:" + w.label,
+		Note:   note,
+	}
+}
+
+func sortedJobKeys(jobs map[string]Job) []string {
+	keys := make([]string, 0, len(jobs))
+	for k := range jobs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// conditionApplies evaluates an `if:` condition for a local push. Conditions that depend
+// on something only CI knows (secrets, step outputs, event payloads) are kept, with a
+// note so the user can see why the check was imported.
+func conditionApplies(condition string, ctx exprContext) (bool, string) {
+	condition = strings.TrimSpace(condition)
+	decided, result, err := evalCondition(condition, ctx)
+	if err != nil {
+		return true, fmt.Sprintf("could not evaluate CI condition %q: %v", condition, err)
+	}
+	if !decided {
+		return true, fmt.Sprintf("undecided CI condition: %s", condition)
+	}
+	return result, ""
+}
+
+func joinNotes(notes ...string) string {
+	var out []string
+	for _, note := range notes {
+		if strings.TrimSpace(note) != "" {
+			out = append(out, note)
 		}
 	}
+	return strings.Join(out, "; ")
+}
 
-	return out, nil
+// knownContext holds the values the importer can substitute into commands: the inputs
+// of a reusable workflow or composite action and the values of one matrix combination.
+func knownContext(inputs map[string]string, matrix map[string]string) exprContext {
+	contexts := map[string]exprValue{}
+	if inputs != nil {
+		contexts["inputs"] = inputsValue(inputs)
+	}
+	if matrix != nil {
+		values := make(map[string]exprValue, len(matrix))
+		for k, v := range matrix {
+			values[k] = stringValue(v)
+		}
+		contexts["matrix"] = objectValue(values)
+	}
+	return exprContext{contexts: contexts}
 }
 
-func jobAppliesToOS(job Job, currentOS string) bool {
-	if !runsOnMatches(job.RunsOn, job.Strategy.Matrix, currentOS) {
-		return false
+var substitutionPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+// substituteKnown replaces `${{ }}` expressions that known can decide on its own
+// (`${{ inputs.version }}`, `${{ format('go{0}', matrix.go) }}`); everything else is left
+// for normalizeGitHubActionsTemplates.
+func substituteKnown(text string, known exprContext) string {
+	if len(known.contexts) == 0 || !strings.Contains(text, "${{") {
+		return text
 	}
-	if known, allowed := evalIfOS(job.If, currentOS); known && !allowed {
-		return false
+	return substitutionPattern.ReplaceAllStringFunc(text, func(match string) string {
+		expr := substitutionPattern.FindStringSubmatch(match)[1]
+		value, err := evalExpression(expr, known)
+		if err != nil || value.kind == exprUnknown {
+			return match
+		}
+		return value.String()
+	})
+}
+
+func substituteKnownInEnv(env map[string]string, known exprContext) map[string]string {
+	if len(known.contexts) == 0 || len(env) == 0 {
+		return env
 	}
-	return true
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = substituteKnown(v, known)
+	}
+	return out
 }
 
-func stepAppliesToOS(step Step, currentOS string) bool {
-	if known, allowed := evalIfOS(step.If, currentOS); known && !allowed {
-		return false
+// localMatrixContext picks the matrix values a local run would use. Single-valued axes
+// and OS axes (resolved to the current OS) are known; anything that would fan out into
+// several jobs is unknown.
+func localMatrixContext(matrix map[string]interface{}, currentOS string) map[string]exprValue {
+	out := make(map[string]exprValue, len(matrix))
+	for key, raw := range matrix {
+		if key == "include" || key == "exclude" {
+			continue
+		}
+		values := matrixValues(matrix, key)
+		if _, isList := raw.([]interface{}); !isList {
+			out[key] = unknownValue
+			continue
+		}
+		out[key] = unknownValue
+		if len(values) == 1 {
+			out[key] = stringValue(values[0])
+			continue
+		}
+		for _, v := range values {
+			if osFromValue(v) == currentOS {
+				out[key] = stringValue(v)
+				break
+			}
+		}
 	}
-	return true
+	// include entries can introduce keys of their own; their values depend on the combo.
+	if includes, ok := matrix["include"].([]interface{}); ok {
+		for _, entry := range includes {
+			m, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key := range m {
+				if _, seen := out[key]; !seen {
+					out[key] = unknownValue
+				}
+			}
+		}
+	}
+	return out
 }
 
 func labelFromWorkflow(path string, workflowName string) string {
@@ -539,61 +750,7 @@ func expandRunsOn(values []string, matrix map[string]interface{}) ([]string, boo
 	return out, unknown
 }
 
-var (
-	reIfEq       = regexp.MustCompile(`(?i)\b(?:runner\.os|matrix\.os)\s*==\s*['"]([^'"]+)['"]`)
-	reIfNe       = regexp.MustCompile(`(?i)\b(?:runner\.os|matrix\.os)\s*!=\s*['"]([^'"]+)['"]`)
-	reIfContains = regexp.MustCompile(`(?i)contains\(\s*(?:runner\.os|matrix\.os)\s*,\s*['"]([^'"]+)['"]\s*\)`)
-	reMatrixVar  = regexp.MustCompile(`(?i)matrix\.([a-z0-9_-]+)`)
-)
-
-func evalIfOS(expr string, currentOS string) (bool, bool) {
-	expr = strings.TrimSpace(expr)
-	if expr == "" {
-		return false, true
-	}
-	lower := strings.ToLower(expr)
-	if !strings.Contains(lower, "runner.os") && !strings.Contains(lower, "matrix.os") {
-		return false, true
-	}
-
-	var eqMatches []string
-	for _, m := range reIfEq.FindAllStringSubmatch(expr, -1) {
-		eqMatches = append(eqMatches, m[1])
-	}
-	for _, m := range reIfContains.FindAllStringSubmatch(expr, -1) {
-		eqMatches = append(eqMatches, m[1])
-	}
-
-	var neMatches []string
-	for _, m := range reIfNe.FindAllStringSubmatch(expr, -1) {
-		neMatches = append(neMatches, m[1])
-	}
-
-	if len(eqMatches) == 0 && len(neMatches) == 0 {
-		return false, true
-	}
-
-	if len(eqMatches) > 0 {
-		allowed := false
-		for _, v := range eqMatches {
-			if os := osFromValue(v); os != "" && os == currentOS {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			return true, false
-		}
-	}
-
-	for _, v := range neMatches {
-		if os := osFromValue(v); os != "" && os == currentOS {
-			return true, false
-		}
-	}
-
-	return true, true
-}
+var reMatrixVar = regexp.MustCompile(`(?i)matrix\.([a-z0-9_-]+)`)
 
 func matrixKeyFromExpr(value string) string {
 	if m := reMatrixVar.FindStringSubmatch(value); len(m) == 2 {
@@ -674,6 +831,39 @@ func normalizeWorkingDirectory(cwd string) string {
 	return normalized
 }
 
+// normalizeGitHubCwd turns a step's working-directory into a repo-relative cwd. Expressions
+// that survive substituteKnown (`${{ inputs.dir }}` without a default, say) are dropped
+// along with the separator they leave behind, so the cwd never becomes absolute.
+func normalizeGitHubCwd(cwd string) string {
+	normalized := normalizeWorkingDirectory(cwd)
+	if !strings.Contains(normalized, "${{") {
+		return normalized
+	}
+	normalized = strings.TrimLeft(normalizeGitHubActionsTemplates(normalized), `/\`)
+	if normalized = path.Clean(filepath.ToSlash(normalized)); normalized == "." {
+		return ""
+	}
+	return normalized
+}
+
+// normalizeGitHubEnv drops env entries whose values still hold GitHub expressions after
+// substituteKnown: `${{ secrets.GITHUB_TOKEN }}`, `${{ github.* }}`, `${{ steps.* }}`,
+// `${{ needs.* }}` and the like have no local value, and an empty or mangled override
+// would hide whatever the developer's environment provides.
+func normalizeGitHubEnv(env map[string]string) map[string]string {
+	var out map[string]string
+	for key, value := range env {
+		if strings.Contains(value, "${{") {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(env))
+		}
+		out[key] = value
+	}
+	return out
+}
+
 // normalizeGitHubActionsTemplates transforms GitHub Actions template expressions into executable local commands.
 //
 // This function handles the following transformations:
@@ -7,6 +7,18 @@ import (
 	"testing"
 )
 
+// writeCIFile writes a CI config file under root, creating its directories.
+func writeCIFile(t *testing.T, root string, rel string, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
 func TestChecksFromGitHubActions(t *testing.T) {
 	root := t.TempDir()
 	workflowDir := filepath.Join(root, ".github", "workflows")
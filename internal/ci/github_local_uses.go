@@ -0,0 +1,225 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Local `uses:` references (`./.github/actions/lint`, `./.github/workflows/test.yml`)
+// point at files in the repo, so their steps can be imported like any other run step.
+
+// ActionMetadata is the subset of action.yml the importer reads.
+type ActionMetadata struct {
+	Name   string                 `yaml:"name"`
+	Inputs map[string]ActionInput `yaml:"inputs"`
+	Runs   ActionRuns             `yaml:"runs"`
+}
+
+type ActionInput struct {
+	Default interface{} `yaml:"default"`
+}
+
+type ActionRuns struct {
+	Using string `yaml:"using"`
+	Steps []Step `yaml:"steps"`
+}
+
+// maxLocalUsesDepth bounds nesting of local actions and reusable workflows; GitHub
+// itself stops at four levels of reusable workflows.
+const maxLocalUsesDepth = 8
+
+func isLocalUses(uses string) bool {
+	return strings.HasPrefix(strings.TrimSpace(uses), "./")
+}
+
+func localUsesPath(root string, uses string) string {
+	rel := strings.TrimPrefix(strings.TrimSpace(uses), "./")
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// enter pushes path onto the expansion stack, rejecting cycles and runaway nesting.
+func (w *workflowImport) enter(path string) error {
+	path = filepath.Clean(path)
+	for _, seen := range w.stack {
+		if seen == path {
+			return fmt.Errorf("local uses cycle through %s", path)
+		}
+	}
+	if len(w.stack) > maxLocalUsesDepth {
+		return fmt.Errorf("local uses nested deeper than %d levels at %s", maxLocalUsesDepth, path)
+	}
+	w.stack = append(w.stack, path)
+	return nil
+}
+
+func (w *workflowImport) leave() {
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+// reusableWorkflowChecks expands a job that calls a local reusable workflow. The called
+// workflow's jobs are labelled under the calling job.
+func (w *workflowImport) reusableWorkflowChecks(job Job, jobLabel []string, note string, known exprContext) ([]config.Check, error) {
+	path := localUsesPath(w.root, job.Uses)
+	called, err := readWorkflow(path)
+	if err != nil {
+		return nil, fmt.Errorf("reusable workflow %s: %w", job.Uses, err)
+	}
+	if err := w.enter(path); err != nil {
+		return nil, err
+	}
+	defer w.leave()
+
+	inputs := called.workflowCallInputDefaults()
+	for k, v := range job.With {
+		inputs[k] = substituteKnown(asString(v), known)
+	}
+
+	checks, err := w.workflowChecks(called, jobLabel, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checks {
+		checks[i].Note = joinNotes(note, checks[i].Note)
+	}
+	return checks, nil
+}
+
+// compositeActionChecks expands a step that uses a local composite action. Other kinds
+// of local action (node, docker) have no run steps to import and are skipped.
+func (w *workflowImport) compositeActionChecks(step Step, labels []string, env map[string]string, note string, known exprContext) ([]config.Check, error) {
+	dir := localUsesPath(w.root, step.Uses)
+	path, ok := firstExisting(dir, "action.yml", "action.yaml")
+	if !ok {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var action ActionMetadata
+	if err := yaml.Unmarshal(data, &action); err != nil {
+		return nil, fmt.Errorf("parse action %s: %w", path, err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(action.Runs.Using), "composite") {
+		return nil, nil
+	}
+	if err := w.enter(path); err != nil {
+		return nil, err
+	}
+	defer w.leave()
+
+	inputs := make(map[string]string, len(action.Inputs))
+	for name, input := range action.Inputs {
+		inputs[name] = asString(input.Default)
+	}
+	for k, v := range step.With {
+		inputs[k] = substituteKnown(asString(v), known)
+	}
+	inner := knownContext(inputs, nil)
+
+	stepLabel := labelFromUses(step, actionShortName(strings.TrimSuffix(step.Uses, "/")))
+	labels = append(append([]string{}, labels...), stepLabel)
+	env = mergeEnv(env, substituteKnownInEnv(normalizeEnv(step.Env), known))
+	ctx := newExprContext(w.branch, w.currentOS, nil, env).withInputs(inputs)
+	usage := detectToolsUsed(action.Runs.Steps)
+
+	var out []config.Check
+	for _, actionStep := range action.Runs.Steps {
+		applies, stepNote := conditionApplies(actionStep.If, ctx)
+		if !applies {
+			continue
+		}
+		stepNote = joinNotes(note, stepNote)
+
+		switch {
+		case isLocalUses(actionStep.Uses):
+			checks, err := w.compositeActionChecks(actionStep, labels, env, stepNote, inner)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, checks...)
+		case strings.TrimSpace(actionStep.Run) != "":
+			// Composite steps start in the workspace root; job defaults do not apply.
+			out = append(out, w.runCheck(append(labels, labelFromStep(actionStep)), actionStep, mergeEnv(env, normalizeEnv(actionStep.Env)), actionStep.WorkingDirectory, "", stepNote, inner))
+		case strings.TrimSpace(actionStep.Uses) != "":
+			if check := checkFromUsesStep(w.label, strings.Join(labels, ":"), env, "", Defaults{}, actionStep, usage, w.currentOS); check != nil {
+				check.Note = stepNote
+				out = append(out, *check)
+			}
+		}
+	}
+	return out, nil
+}
+
+// triggers returns the workflow's `on:` events, whichever YAML shape they use.
+func (wf Workflow) triggers() map[string]interface{} {
+	if wf.On.Kind == 0 {
+		return nil
+	}
+	var raw interface{}
+	if err := wf.On.Decode(&raw); err != nil {
+		return nil
+	}
+	out := map[string]interface{}{}
+	switch v := raw.(type) {
+	case string:
+		out[v] = nil
+	case []interface{}:
+		for _, item := range v {
+			out[asString(item)] = nil
+		}
+	case map[string]interface{}:
+		out = v
+	}
+	return out
+}
+
+func (wf Workflow) reusableOnly() bool {
+	triggers := wf.triggers()
+	_, ok := triggers["workflow_call"]
+	return ok && len(triggers) == 1
+}
+
+func (wf Workflow) workflowCallInputDefaults() map[string]string {
+	out := map[string]string{}
+	call := asMap(wf.triggers()["workflow_call"])
+	for name, raw := range asMap(call["inputs"]) {
+		out[name] = asString(asMap(raw)["default"])
+	}
+	return out
+}
+
+// withInputs exposes a reusable workflow's or composite action's inputs as `inputs.*`.
+func (c exprContext) withInputs(inputs map[string]string) exprContext {
+	if inputs == nil {
+		return c
+	}
+	contexts := make(map[string]exprValue, len(c.contexts)+1)
+	for k, v := range c.contexts {
+		contexts[k] = v
+	}
+	contexts["inputs"] = inputsValue(inputs)
+	return exprContext{contexts: contexts}
+}
+
+// inputsValue builds the inputs context. "true"/"false" become booleans so that boolean
+// inputs (`if: inputs.race`) behave as they do on GitHub.
+func inputsValue(inputs map[string]string) exprValue {
+	values := make(map[string]exprValue, len(inputs))
+	for k, v := range inputs {
+		switch {
+		case strings.Contains(v, "${{"):
+			values[k] = unknownValue
+		case v == "true" || v == "false":
+			values[k] = boolValue(v == "true")
+		default:
+			values[k] = stringValue(v)
+		}
+	}
+	return objectValue(values)
+}
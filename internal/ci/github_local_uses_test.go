@@ -0,0 +1,136 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksFromGitHubActionsExpandsCompositeAction(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".github/workflows/ci.yml", `
+name: CI
+jobs:
+  lint:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: ./.github/actions/lint
+        with:
+          target: ./cmd/...
+`)
+	writeCIFile(t, root, ".github/actions/lint/action.yml", `
+name: Lint
+inputs:
+  target:
+    default: ./...
+  flags:
+    default: -v
+runs:
+  using: composite
+  steps:
+    - name: vet
+      shell: bash
+      run: go vet ${{ inputs.flags }} ${{ inputs.target }}
+    - name: skipped on push
+      if: github.event_name == 'pull_request'
+      shell: bash
+      run: echo pr
+    - uses: ./.github/actions/fmt
+`)
+	writeCIFile(t, root, ".github/actions/fmt/action.yaml", `
+runs:
+  using: composite
+  steps:
+    - name: fmt
+      shell: bash
+      working-directory: src
+      run: gofmt -l .
+`)
+	writeCIFile(t, root, ".github/actions/node-only/action.yml", `
+runs:
+  using: node20
+  main: index.js
+`)
+
+	checks, err := ChecksFromGitHubActions(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitHubActions error: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("expected vet and fmt checks, got %+v", checks)
+	}
+	if checks[0].Name != "ci:ci:lint:lint:vet" || checks[0].Run != "go vet -v ./cmd/..." {
+		t.Fatalf("unexpected vet check: %+v", checks[0])
+	}
+	if checks[1].Name != "ci:ci:lint:lint:fmt:fmt" || checks[1].Cwd != "src" || checks[1].Source != "ci:ci" {
+		t.Fatalf("unexpected fmt check: %+v", checks[1])
+	}
+}
+
+func TestChecksFromGitHubActionsExpandsReusableWorkflow(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".github/workflows/ci.yml", `
+name: CI
+on: push
+jobs:
+  test:
+    uses: ./.github/workflows/go-test.yml
+    with:
+      packages: ./internal/...
+`)
+	writeCIFile(t, root, ".github/workflows/go-test.yml", `
+on:
+  workflow_call:
+    inputs:
+      packages:
+        type: string
+        default: ./...
+      race:
+        type: boolean
+        default: false
+jobs:
+  unit:
+    env:
+      PKGS: ${{ inputs.packages }}
+    steps:
+      - name: test
+        run: go test ${{ inputs.packages }}
+      - name: race
+        if: inputs.race
+        run: go test -race ./...
+`)
+
+	checks, err := ChecksFromGitHubActions(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitHubActions error: %v", err)
+	}
+	if len(checks) != 1 {
+		t.Fatalf("expected only the caller's expansion, got %+v", checks)
+	}
+	check := checks[0]
+	if check.Name != "ci:ci:test:unit:test" || check.Run != "go test ./internal/..." {
+		t.Fatalf("unexpected reusable workflow check: %+v", check)
+	}
+	if check.Env["PKGS"] != "./internal/..." {
+		t.Fatalf("expected inputs substituted into env, got %v", check.Env)
+	}
+}
+
+func TestChecksFromGitHubActionsRejectsLocalUsesCycle(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".github/workflows/ci.yml", `
+jobs:
+  build:
+    steps:
+      - uses: ./.github/actions/a
+`)
+	writeCIFile(t, root, ".github/actions/a/action.yml", `
+runs:
+  using: composite
+  steps:
+    - uses: ./.github/actions/a
+`)
+
+	if _, err := ChecksFromGitHubActions(root); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
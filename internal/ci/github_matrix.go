@@ -0,0 +1,138 @@
+package ci
+
+import (
+	"fmt"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML keeps the matrix axis order so expanded check names are stable. A matrix
+// given as an expression (`${{ fromJSON(...) }}`) is left empty: its values are unknown.
+func (s *Strategy) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.Matrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	if err := raw.Matrix.Decode(&s.Matrix); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(raw.Matrix.Content); i += 2 {
+		key := raw.Matrix.Content[i].Value
+		if key != "include" && key != "exclude" {
+			s.MatrixAxes = append(s.MatrixAxes, key)
+		}
+	}
+	return nil
+}
+
+// jobMatrixCombos expands a job's matrix with GitHub's include/exclude rules. It reports
+// false when there is no matrix or when it cannot be expanded locally (values that are
+// expressions or objects); such jobs are imported once, as before.
+func jobMatrixCombos(strategy Strategy) ([]config.MatrixCombo, bool) {
+	if len(strategy.Matrix) == 0 {
+		return nil, false
+	}
+
+	m := config.Matrix{}
+	for _, name := range strategy.MatrixAxes {
+		items, ok := strategy.Matrix[name].([]interface{})
+		if !ok {
+			return nil, false
+		}
+		axis := config.MatrixAxis{Name: name}
+		for _, item := range items {
+			value, ok := scalarMatrixValue(item)
+			if !ok {
+				return nil, false
+			}
+			axis.Values = append(axis.Values, value)
+		}
+		m.Axes = append(m.Axes, axis)
+	}
+
+	var ok bool
+	if m.Include, ok = matrixEntries(strategy.Matrix["include"]); !ok {
+		return nil, false
+	}
+	if m.Exclude, ok = matrixEntries(strategy.Matrix["exclude"]); !ok {
+		return nil, false
+	}
+
+	combos := config.ExpandMatrix(m)
+	return combos, len(combos) > 0
+}
+
+func matrixEntries(raw interface{}) ([]map[string]string, bool) {
+	if raw == nil {
+		return nil, true
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]map[string]string, 0, len(list))
+	for _, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		values := make(map[string]string, len(entry))
+		for k, v := range entry {
+			value, ok := scalarMatrixValue(v)
+			if !ok {
+				return nil, false
+			}
+			values[k] = value
+		}
+		out = append(out, values)
+	}
+	return out, true
+}
+
+func scalarMatrixValue(raw interface{}) (string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return v, true
+	case int, int64, float64, bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// singleComboMatrix presents one combination in the shape runsOnMatches expects.
+func singleComboMatrix(combo config.MatrixCombo) map[string]interface{} {
+	out := make(map[string]interface{}, len(combo.Values))
+	for k, v := range combo.Values {
+		out[k] = []interface{}{v}
+	}
+	return out
+}
+
+// matrixComboChecks suffixes checks imported for one combination the same way config
+// matrices are named: "name (k=v, ...)". MatrixValues lets check IDs carry the
+// combination too.
+func matrixComboChecks(checks []config.Check, combo config.MatrixCombo) []config.Check {
+	for i := range checks {
+		parent := checks[i].Name
+		if checks[i].MatrixParent != "" {
+			parent = checks[i].MatrixParent
+		}
+		values := make(map[string]string, len(checks[i].MatrixValues)+len(combo.Values))
+		for k, v := range checks[i].MatrixValues {
+			values[k] = v
+		}
+		for k, v := range combo.Values {
+			values[k] = v
+		}
+		checks[i].Name = fmt.Sprintf("%s (%s)", checks[i].Name, combo.Label(", "))
+		checks[i].MatrixParent = parent
+		checks[i].MatrixValues = values
+	}
+	return checks
+}
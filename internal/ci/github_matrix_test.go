@@ -0,0 +1,90 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksFromGitHubActionsExpandsMatrix(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".github/workflows/test.yml", `
+name: Test
+jobs:
+  test:
+    runs-on: ${{ matrix.os }}
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest, macos-latest]
+        go: ["1.21", "1.22"]
+        tags: [unit, integration]
+        exclude:
+          - go: "1.21"
+            tags: integration
+        include:
+          - go: "1.22"
+            tags: unit
+            race: "-race"
+    defaults:
+      run:
+        working-directory: ${{ matrix.tags }}
+    env:
+      GOTOOLCHAIN: go${{ matrix.go }}
+    steps:
+      - name: test
+        run: go test ${{ matrix.race }} -tags ${{ matrix.tags }} ./...
+      - name: integration only
+        if: matrix.tags == 'integration'
+        run: make integration
+`)
+
+	checks, err := ChecksFromGitHubActions(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitHubActions error: %v", err)
+	}
+
+	osValue := map[string]string{"linux": "ubuntu-latest", "windows": "windows-latest", "macos": "macos-latest"}[currentRunnerOS()]
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	want := []string{
+		"ci:test:test:test (os=" + osValue + ", go=1.21, tags=unit)",
+		"ci:test:test:test (os=" + osValue + ", go=1.22, tags=unit, race=-race)",
+		"ci:test:test:test (os=" + osValue + ", go=1.22, tags=integration)",
+		"ci:test:test:integration-only (os=" + osValue + ", go=1.22, tags=integration)",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+
+	race := checks[1]
+	if race.Run != "go test -race -tags unit ./..." || race.Cwd != "unit" || race.Env["GOTOOLCHAIN"] != "go1.22" {
+		t.Fatalf("expected matrix values substituted, got %+v", race)
+	}
+	if race.MatrixParent != "ci:test:test:test" || race.MatrixValues["race"] != "-race" {
+		t.Fatalf("expected matrix metadata, got %q %v", race.MatrixParent, race.MatrixValues)
+	}
+	if strings.Contains(checks[0].Run, "${{") || checks[0].Run != "go test  -tags unit ./..." {
+		t.Fatalf("expected missing matrix key to substitute empty, got %q", checks[0].Run)
+	}
+}
+
+func TestChecksFromGitHubActionsKeepsExpressionMatrixAsOneJob(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".github/workflows/ci.yml", `
+jobs:
+  build:
+    strategy:
+      matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+    steps:
+      - run: make ${{ matrix.target }}
+`)
+
+	checks, err := ChecksFromGitHubActions(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitHubActions error: %v", err)
+	}
+	if len(checks) != 1 || checks[0].MatrixParent != "" {
+		t.Fatalf("expected a single unexpanded check, got %+v", checks)
+	}
+}
@@ -0,0 +1,736 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// GitLabCIFile is the pipeline definition the GitLab importer reads from the repo root.
+const GitLabCIFile = ".gitlab-ci.yml"
+
+// gitLabGlobalKeys are top-level keywords that are not jobs.
+var gitLabGlobalKeys = map[string]bool{
+	"image": true, "services": true, "stages": true, "types": true,
+	"before_script": true, "after_script": true, "variables": true, "cache": true,
+	"include": true, "default": true, "workflow": true, "spec": true,
+}
+
+var gitLabDefaultStages = []string{".pre", "build", "test", "deploy", ".post"}
+
+// gitLabDocument is a .gitlab-ci.yml with local includes merged in. Keys keeps
+// document order so jobs come out in the order they were written.
+type gitLabDocument struct {
+	Keys    []string
+	Entries map[string]interface{}
+}
+
+// ChecksFromGitLabCI turns the script/before_script of each job in .gitlab-ci.yml into
+// a check. extends:, default:, variables:, and local include: files are resolved; rules
+// are honored when they only depend on what a local push knows (branch, OS, file
+// variables). Jobs that need services:, a custom image:, triggers, or manual runs are skipped.
+func ChecksFromGitLabCI(root string) ([]config.Check, error) {
+	path := filepath.Join(root, GitLabCIFile)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	doc, err := loadGitLabDocument(root, path, map[string]bool{})
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", GitLabCIFile, err)
+	}
+
+	currentOS := currentRunnerOS()
+	known := gitLabKnownVariables(root)
+	globalVars := gitLabVariables(doc.Entries["variables"])
+	defaults := gitLabDefaults(doc)
+	stageOrder := gitLabStageOrder(doc.Entries["stages"])
+
+	type stagedCheck struct {
+		stage int
+		check config.Check
+	}
+	var staged []stagedCheck
+
+	for _, jobName := range doc.Keys {
+		if gitLabGlobalKeys[jobName] || strings.HasPrefix(jobName, ".") {
+			continue
+		}
+		if _, ok := doc.Entries[jobName].(map[string]interface{}); !ok {
+			continue
+		}
+
+		job, err := resolveGitLabJob(doc, jobName, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		job = applyGitLabDefaults(job, defaults)
+
+		if !gitLabJobRunsLocally(job, currentOS) {
+			continue
+		}
+
+		var env map[string]string
+		if inheritsGitLab(job, "variables") {
+			env = globalVars
+		}
+		env = mergeEnv(env, gitLabVariables(job["variables"]))
+
+		vars := make(map[string]string, len(known)+len(env))
+		for k, v := range known {
+			vars[k] = v
+		}
+		for k, v := range env {
+			vars[k] = v
+		}
+		if !gitLabRulesAllow(job["rules"], vars) {
+			continue
+		}
+
+		script := gitLabScript(job["script"])
+		if len(script) == 0 {
+			continue
+		}
+		lines := append(gitLabScript(job["before_script"]), script...)
+
+		stage := "test"
+		if raw, ok := job["stage"].(string); ok && strings.TrimSpace(raw) != "" {
+			stage = strings.TrimSpace(raw)
+		}
+		stageIndex, ok := stageOrder[stage]
+		if !ok {
+			stageIndex = len(stageOrder)
+		}
+
+		staged = append(staged, stagedCheck{
+			stage: stageIndex,
+			check: config.Check{
+				Name:   "ci:gitlab:" + sanitizeLabel(jobName),
+				Run:    strings.Join(lines, "\n"),
+				Shell:  defaultGitLabShell(currentOS),
+				Env:    env,
+				OS:     config.StringList{currentOS},
+				Source: "gitlab",
+			},
+		})
+	}
+
+	sort.SliceStable(staged, func(i, j int) bool { return staged[i].stage < staged[j].stage })
+	out := make([]config.Check, 0, len(staged))
+	for _, s := range staged {
+		out = append(out, s.check)
+	}
+	return out, nil
+}
+
+// loadGitLabDocument reads path and merges its local includes underneath it.
+// Remote, project, and template includes are ignored.
+func loadGitLabDocument(root string, path string, seen map[string]bool) (gitLabDocument, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return gitLabDocument{}, err
+	}
+	if seen[abs] {
+		return gitLabDocument{}, fmt.Errorf("include cycle at %s", path)
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return gitLabDocument{}, err
+	}
+	doc := gitLabDocument{Entries: map[string]interface{}{}}
+	if IsGenerated(data) {
+		// Written by `ci export`; its jobs mirror our own checks.
+		return doc, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return gitLabDocument{}, err
+	}
+	if len(node.Content) == 0 {
+		return doc, nil
+	}
+	top := node.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return gitLabDocument{}, fmt.Errorf("expected a mapping at the top level")
+	}
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		key := top.Content[i].Value
+		var value interface{}
+		if err := top.Content[i+1].Decode(&value); err != nil {
+			return gitLabDocument{}, fmt.Errorf("%s: %w", key, err)
+		}
+		doc.Keys = append(doc.Keys, key)
+		doc.Entries[key] = value
+	}
+
+	merged := gitLabDocument{Entries: map[string]interface{}{}}
+	for _, includePath := range gitLabLocalIncludes(root, doc.Entries["include"]) {
+		included, err := loadGitLabDocument(root, includePath, seen)
+		if err != nil {
+			return gitLabDocument{}, err
+		}
+		merged = mergeGitLabDocuments(merged, included)
+	}
+	return mergeGitLabDocuments(merged, doc), nil
+}
+
+func gitLabLocalIncludes(root string, raw interface{}) []string {
+	var locals []string
+	var visit func(item interface{})
+	visit = func(item interface{}) {
+		switch v := item.(type) {
+		case string:
+			if !strings.Contains(v, "://") {
+				locals = append(locals, v)
+			}
+		case []interface{}:
+			for _, child := range v {
+				visit(child)
+			}
+		case map[string]interface{}:
+			if local, ok := v["local"].(string); ok {
+				locals = append(locals, local)
+			}
+		}
+	}
+	visit(raw)
+
+	var out []string
+	for _, local := range locals {
+		full := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(strings.TrimSpace(local), "/")))
+		if strings.ContainsAny(local, "*?[") {
+			matches, _ := filepath.Glob(full)
+			sort.Strings(matches)
+			out = append(out, matches...)
+			continue
+		}
+		out = append(out, full)
+	}
+	return out
+}
+
+// mergeGitLabDocuments overlays top onto base: mappings deep-merge, everything else is replaced.
+func mergeGitLabDocuments(base gitLabDocument, top gitLabDocument) gitLabDocument {
+	out := gitLabDocument{Keys: append([]string{}, base.Keys...), Entries: map[string]interface{}{}}
+	for k, v := range base.Entries {
+		out.Entries[k] = v
+	}
+	for _, key := range top.Keys {
+		if key == "include" {
+			continue
+		}
+		if existing, ok := out.Entries[key]; ok {
+			out.Entries[key] = deepMergeGitLab(existing, top.Entries[key])
+			continue
+		}
+		out.Keys = append(out.Keys, key)
+		out.Entries[key] = top.Entries[key]
+	}
+	return out
+}
+
+func deepMergeGitLab(base interface{}, top interface{}) interface{} {
+	baseMap, baseOK := base.(map[string]interface{})
+	topMap, topOK := top.(map[string]interface{})
+	if !baseOK || !topOK {
+		return top
+	}
+	out := make(map[string]interface{}, len(baseMap)+len(topMap))
+	for k, v := range baseMap {
+		out[k] = v
+	}
+	for k, v := range topMap {
+		if existing, ok := out[k]; ok {
+			out[k] = deepMergeGitLab(existing, v)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// resolveGitLabJob returns the job with its extends: chain merged in (parents first).
+func resolveGitLabJob(doc gitLabDocument, name string, seen map[string]bool) (map[string]interface{}, error) {
+	if seen[name] {
+		return nil, fmt.Errorf("%s: extends cycle at %q", GitLabCIFile, name)
+	}
+	seen[name] = true
+	defer delete(seen, name)
+
+	job, ok := doc.Entries[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: extends unknown job %q", GitLabCIFile, name)
+	}
+
+	var merged interface{} = map[string]interface{}{}
+	for _, parent := range gitLabStrings(job["extends"]) {
+		resolved, err := resolveGitLabJob(doc, parent, seen)
+		if err != nil {
+			return nil, err
+		}
+		merged = deepMergeGitLab(merged, resolved)
+	}
+	own := make(map[string]interface{}, len(job))
+	for k, v := range job {
+		if k != "extends" {
+			own[k] = v
+		}
+	}
+	return deepMergeGitLab(merged, own).(map[string]interface{}), nil
+}
+
+// gitLabDefaults collects default: settings plus their legacy top-level spellings.
+func gitLabDefaults(doc gitLabDocument) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, key := range []string{"image", "services", "before_script"} {
+		if v, ok := doc.Entries[key]; ok {
+			out[key] = v
+		}
+	}
+	if def, ok := doc.Entries["default"].(map[string]interface{}); ok {
+		for k, v := range def {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func applyGitLabDefaults(job map[string]interface{}, defaults map[string]interface{}) map[string]interface{} {
+	if !inheritsGitLab(job, "default") {
+		return job
+	}
+	out := make(map[string]interface{}, len(job)+len(defaults))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range job {
+		out[k] = v
+	}
+	return out
+}
+
+// inheritsGitLab reports whether inherit:<key> leaves global settings enabled for the job.
+func inheritsGitLab(job map[string]interface{}, key string) bool {
+	inherit, ok := job["inherit"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if enabled, ok := inherit[key].(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+// gitLabJobRunsLocally filters out jobs a local run cannot reproduce: service containers,
+// custom images, downstream triggers, manual/delayed jobs, and jobs pinned to another OS by runner tags.
+func gitLabJobRunsLocally(job map[string]interface{}, currentOS string) bool {
+	if services, ok := job["services"].([]interface{}); ok && len(services) > 0 {
+		return false
+	}
+	if !gitLabImageRunsLocally(job["image"]) {
+		return false
+	}
+	if _, ok := job["trigger"]; ok {
+		return false
+	}
+	switch strings.TrimSpace(fmt.Sprint(job["when"])) {
+	case "manual", "delayed", "never":
+		return false
+	}
+
+	matchedOS := false
+	knownOS := false
+	for _, tag := range gitLabStrings(job["tags"]) {
+		if os := osFromValue(tag); os != "" {
+			knownOS = true
+			if os == currentOS {
+				matchedOS = true
+			}
+		}
+	}
+	return !knownOS || matchedOS
+}
+
+// gitLabImageRunsLocally accepts no image or an official Docker Hub image (`golang:1.22`,
+// `node:20-alpine`, `ubuntu`), which only stands in for a toolchain the developer has
+// installed. Images from another registry or namespace (`registry.example.com/builder`,
+// `$CI_REGISTRY_IMAGE/ci`, `cimg/go`) bundle tools and setup a local run would not have.
+func gitLabImageRunsLocally(raw interface{}) bool {
+	image := raw
+	if settings, ok := raw.(map[string]interface{}); ok {
+		image = settings["name"]
+	}
+	name, ok := image.(string)
+	if !ok {
+		return raw == nil
+	}
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(name, "docker.io/")
+	name = strings.TrimPrefix(name, "library/")
+	return !strings.ContainsAny(name, "/$")
+}
+
+func gitLabVariables(raw interface{}) map[string]string {
+	vars, ok := raw.(map[string]interface{})
+	if !ok || len(vars) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if detailed, ok := v.(map[string]interface{}); ok {
+			v = detailed["value"]
+		}
+		if v == nil {
+			out[k] = ""
+			continue
+		}
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+func gitLabScript(raw interface{}) []string {
+	var out []string
+	switch v := raw.(type) {
+	case string:
+		if strings.TrimSpace(v) != "" {
+			out = append(out, strings.TrimSpace(v))
+		}
+	case []interface{}:
+		for _, item := range v {
+			out = append(out, gitLabScript(item)...)
+		}
+	}
+	return out
+}
+
+func gitLabStrings(raw interface{}) []string {
+	switch v := raw.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []string{strings.TrimSpace(v)}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func gitLabStageOrder(raw interface{}) map[string]int {
+	stages := gitLabStrings(raw)
+	if len(stages) == 0 {
+		stages = gitLabDefaultStages
+	}
+	order := make(map[string]int, len(stages))
+	for i, stage := range stages {
+		order[stage] = i
+	}
+	return order
+}
+
+func defaultGitLabShell(currentOS string) string {
+	if currentOS == "windows" {
+		return "pwsh"
+	}
+	return "bash"
+}
+
+// gitLabKnownVariables are the predefined variables a local push can answer for.
+// Merge request and tag variables are known to be unset on a branch push.
+func gitLabKnownVariables(root string) map[string]string {
+	vars := map[string]string{
+		"CI":                        "true",
+		"GITLAB_CI":                 "true",
+		"CI_PIPELINE_SOURCE":        "push",
+		"CI_COMMIT_TAG":             "",
+		"CI_MERGE_REQUEST_ID":       "",
+		"CI_MERGE_REQUEST_IID":      "",
+		"CI_OPEN_MERGE_REQUESTS":    "",
+		"CI_RUNNER_EXECUTABLE_ARCH": runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if branch := git.CurrentBranch(root); branch != "" {
+		vars["CI_COMMIT_BRANCH"] = branch
+		vars["CI_COMMIT_REF_NAME"] = branch
+	}
+	return vars
+}
+
+// gitLabRulesAllow applies rules: the first rule whose if: matches decides. A rule that
+// cannot be evaluated (unknown variables, changes:, exists:) lets the job through.
+func gitLabRulesAllow(raw interface{}, vars map[string]string) bool {
+	rules, ok := raw.([]interface{})
+	if !ok {
+		return true
+	}
+	for _, item := range rules {
+		rule, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		when := strings.TrimSpace(fmt.Sprint(rule["when"]))
+		runs := when != "never" && when != "manual" && when != "delayed"
+
+		expr, hasIf := rule["if"].(string)
+		_, hasChanges := rule["changes"]
+		_, hasExists := rule["exists"]
+		if hasChanges || hasExists {
+			return true
+		}
+		if !hasIf {
+			return runs
+		}
+
+		matched, known := evalGitLabIf(expr, vars)
+		if !known {
+			return true
+		}
+		if matched {
+			return runs
+		}
+	}
+	return false
+}
+
+// evalGitLabIf evaluates a rules:if expression. The second result is false when the
+// expression depends on something a local run cannot know.
+func evalGitLabIf(expr string, vars map[string]string) (bool, bool) {
+	tokens, err := tokenizeGitLabIf(expr)
+	if err != nil {
+		return false, false
+	}
+	p := &gitLabIfParser{tokens: tokens, vars: vars}
+	result := p.parseOr()
+	if p.err != nil || p.pos != len(p.tokens) {
+		return false, false
+	}
+	return result.value, result.known
+}
+
+type gitLabIfToken struct {
+	kind string // var | string | regex | null | op | ( | )
+	text string
+}
+
+var gitLabVarPattern = regexp.MustCompile(`^\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+func tokenizeGitLabIf(expr string) ([]gitLabIfToken, error) {
+	var tokens []gitLabIfToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, gitLabIfToken{kind: string(c)})
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="),
+			strings.HasPrefix(expr[i:], "=~"), strings.HasPrefix(expr[i:], "!~"):
+			tokens = append(tokens, gitLabIfToken{kind: "op", text: expr[i : i+2]})
+			i += 2
+		case c == '$':
+			m := gitLabVarPattern.FindStringSubmatch(expr[i:])
+			if m == nil {
+				return nil, fmt.Errorf("bad variable at %d", i)
+			}
+			tokens = append(tokens, gitLabIfToken{kind: "var", text: m[1]})
+			i += len(m[0])
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, gitLabIfToken{kind: "string", text: expr[i+1 : i+1+end]})
+			i += end + 2
+		case c == '/':
+			j := i + 1
+			for j < len(expr) && (expr[j] != '/' || expr[j-1] == '\\') {
+				j++
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated regex")
+			}
+			pattern := expr[i+1 : j]
+			j++
+			for j < len(expr) && expr[j] >= 'a' && expr[j] <= 'z' {
+				if expr[j] == 'i' {
+					pattern = "(?i)" + pattern
+				}
+				j++
+			}
+			tokens = append(tokens, gitLabIfToken{kind: "regex", text: pattern})
+			i = j
+		case strings.HasPrefix(expr[i:], "null"):
+			tokens = append(tokens, gitLabIfToken{kind: "null"})
+			i += len("null")
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+type gitLabIfResult struct {
+	value bool
+	known bool
+}
+
+type gitLabIfParser struct {
+	tokens []gitLabIfToken
+	pos    int
+	vars   map[string]string
+	err    error
+}
+
+func (p *gitLabIfParser) peek() (gitLabIfToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return gitLabIfToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *gitLabIfParser) parseOr() gitLabIfResult {
+	left := p.parseAnd()
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != "op" || tok.text != "||" {
+			return left
+		}
+		p.pos++
+		right := p.parseAnd()
+		switch {
+		case (left.known && left.value) || (right.known && right.value):
+			left = gitLabIfResult{value: true, known: true}
+		case left.known && right.known:
+			left = gitLabIfResult{value: false, known: true}
+		default:
+			left = gitLabIfResult{}
+		}
+	}
+}
+
+func (p *gitLabIfParser) parseAnd() gitLabIfResult {
+	left := p.parsePrimary()
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != "op" || tok.text != "&&" {
+			return left
+		}
+		p.pos++
+		right := p.parsePrimary()
+		switch {
+		case (left.known && !left.value) || (right.known && !right.value):
+			left = gitLabIfResult{value: false, known: true}
+		case left.known && right.known:
+			left = gitLabIfResult{value: true, known: true}
+		default:
+			left = gitLabIfResult{}
+		}
+	}
+}
+
+func (p *gitLabIfParser) parsePrimary() gitLabIfResult {
+	tok, ok := p.peek()
+	if !ok {
+		p.err = fmt.Errorf("unexpected end of expression")
+		return gitLabIfResult{}
+	}
+	if tok.kind == "(" {
+		p.pos++
+		inner := p.parseOr()
+		if closing, ok := p.peek(); !ok || closing.kind != ")" {
+			p.err = fmt.Errorf("missing )")
+			return gitLabIfResult{}
+		}
+		p.pos++
+		return inner
+	}
+
+	left := tok
+	p.pos++
+	op, ok := p.peek()
+	if !ok || op.kind != "op" || op.text == "&&" || op.text == "||" {
+		// Bare variable: true when set and non-empty.
+		value, known := p.operand(left)
+		return gitLabIfResult{value: value != "", known: known}
+	}
+	p.pos++
+	right, ok := p.peek()
+	if !ok {
+		p.err = fmt.Errorf("missing right operand")
+		return gitLabIfResult{}
+	}
+	p.pos++
+
+	leftValue, leftKnown := p.operand(left)
+	if op.text == "=~" || op.text == "!~" {
+		if right.kind != "regex" {
+			p.err = fmt.Errorf("expected regex after %s", op.text)
+			return gitLabIfResult{}
+		}
+		re, err := regexp.Compile(right.text)
+		if err != nil || !leftKnown {
+			return gitLabIfResult{}
+		}
+		matched := re.MatchString(leftValue)
+		return gitLabIfResult{value: matched == (op.text == "=~"), known: true}
+	}
+
+	rightValue, rightKnown := p.operand(right)
+	if (left.kind == "null" && p.unset(right)) || (right.kind == "null" && p.unset(left)) {
+		// An undefined variable is null, so the comparison is decided either way.
+		leftKnown, rightKnown = true, true
+	}
+	if !leftKnown || !rightKnown {
+		return gitLabIfResult{}
+	}
+	equal := leftValue == rightValue
+	return gitLabIfResult{value: equal == (op.text == "=="), known: true}
+}
+
+// unset reports whether tok is a variable nothing defines. Predefined CI_ and GITLAB_
+// variables that gitLabKnownVariables cannot answer for are set by GitLab, so they stay
+// unknown instead.
+func (p *gitLabIfParser) unset(tok gitLabIfToken) bool {
+	if tok.kind != "var" || strings.HasPrefix(tok.text, "CI_") || strings.HasPrefix(tok.text, "GITLAB_") {
+		return false
+	}
+	_, ok := p.vars[tok.text]
+	return !ok
+}
+
+func (p *gitLabIfParser) operand(tok gitLabIfToken) (string, bool) {
+	switch tok.kind {
+	case "var":
+		value, ok := p.vars[tok.text]
+		return value, ok
+	case "string", "regex":
+		return tok.text, true
+	case "null":
+		return "", true
+	default:
+		p.err = fmt.Errorf("unexpected token %q", tok.kind)
+		return "", false
+	}
+}
@@ -0,0 +1,155 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksFromGitLabCI(t *testing.T) {
+	root := t.TempDir()
+	writeCIFile(t, root, ".git/HEAD", "ref: refs/heads/feature/login\n")
+	writeCIFile(t, root, "ci/lint.yml", `
+.go-base:
+  variables:
+    GOFLAGS: "-mod=readonly"
+  before_script:
+    - go version
+
+lint:
+  extends: .go-base
+  stage: build
+  script: go vet ./...
+`)
+	writeCIFile(t, root, ".gitlab-ci.yml", `
+include:
+  - local: /ci/lint.yml
+  - remote: https://example.com/ci.yml
+
+stages: [build, test, deploy]
+
+variables:
+  CGO_ENABLED: "0"
+
+default:
+  before_script:
+    - echo default
+
+unit:
+  stage: test
+  variables:
+    RACE:
+      value: "1"
+      description: enable race detector
+  script:
+    - go test ./...
+
+integration:
+  services: [postgres:16]
+  script: go test -tags integration ./...
+
+deploy:
+  stage: deploy
+  rules:
+    - if: '$CI_COMMIT_BRANCH == "main"'
+  script: ./deploy.sh
+
+feature-only:
+  rules:
+    - if: $CI_COMMIT_BRANCH =~ /^feature\//
+  script: make preview
+
+mr-only:
+  rules:
+    - if: $CI_MERGE_REQUEST_IID
+  script: make mr
+
+maybe:
+  rules:
+    - if: $CI_COMMIT_BRANCH == $CI_DEFAULT_BRANCH
+  script: make maybe
+
+manual:
+  when: manual
+  script: make release
+
+stock-image:
+  image: golang:1.22
+  script: go build ./...
+
+custom-image:
+  image: registry.example.com/team/builder:1
+  script: make package
+
+ci-image:
+  image:
+    name: $CI_REGISTRY_IMAGE/ci:latest
+  script: make ci
+
+unless-skipped:
+  rules:
+    - if: $SKIP_DOCS == null
+  script: make docs
+
+only-forced:
+  rules:
+    - if: $FORCE_RELEASE != null
+  script: make release
+`)
+
+	checks, err := ChecksFromGitLabCI(root)
+	if err != nil {
+		t.Fatalf("ChecksFromGitLabCI error: %v", err)
+	}
+
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	want := []string{"ci:gitlab:lint", "ci:gitlab:unit", "ci:gitlab:feature-only", "ci:gitlab:maybe", "ci:gitlab:stock-image", "ci:gitlab:unless-skipped"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+
+	lint := checks[0]
+	if lint.Run != "go version\ngo vet ./..." {
+		t.Fatalf("expected extends before_script to win over default, got %q", lint.Run)
+	}
+	if lint.Env["GOFLAGS"] != "-mod=readonly" || lint.Env["CGO_ENABLED"] != "0" {
+		t.Fatalf("expected merged variables, got %v", lint.Env)
+	}
+	if lint.Source != "gitlab" {
+		t.Fatalf("expected gitlab source, got %q", lint.Source)
+	}
+
+	unit := checks[1]
+	if unit.Run != "echo default\ngo test ./..." || unit.Env["RACE"] != "1" {
+		t.Fatalf("unexpected unit check: %+v", unit)
+	}
+}
+
+func TestEvalGitLabIf(t *testing.T) {
+	vars := map[string]string{"CI_COMMIT_BRANCH": "main", "CI_COMMIT_TAG": ""}
+	cases := []struct {
+		expr  string
+		value bool
+		known bool
+	}{
+		{`$CI_COMMIT_BRANCH == "main"`, true, true},
+		{`$CI_COMMIT_BRANCH != 'main'`, false, true},
+		{`$CI_COMMIT_TAG`, false, true},
+		{`$CI_COMMIT_TAG == null`, true, true},
+		{`$CI_COMMIT_BRANCH =~ /^MA/i && $CI_COMMIT_TAG == null`, true, true},
+		{`$UNKNOWN == "x"`, false, false},
+		{`$UNKNOWN == "x" || $CI_COMMIT_BRANCH == "main"`, true, true},
+		{`($UNKNOWN || $CI_COMMIT_TAG) && $CI_COMMIT_BRANCH == "dev"`, false, true},
+		{`$UNKNOWN == null`, true, true},
+		{`null != $UNKNOWN`, false, true},
+		{`$CI_DEFAULT_BRANCH == null`, false, false},
+	}
+	for _, tc := range cases {
+		value, known := evalGitLabIf(tc.expr, vars)
+		if value != tc.value || known != tc.known {
+			t.Fatalf("%s: got (%v, %v), want (%v, %v)", tc.expr, value, known, tc.value, tc.known)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Provider imports checks from one CI system's configuration.
+type Provider interface {
+	// Name is the value `ci sync --provider` accepts (ex: "github", "gitlab").
+	Name() string
+	// Detect reports whether the repo at root has configuration for this provider.
+	Detect(root string) bool
+	// Checks converts the provider's configuration into checks.
+	Checks(root string) ([]config.Check, error)
+}
+
+var providers = []Provider{
+	gitHubActionsProvider{},
+	gitLabProvider{},
+	azurePipelinesProvider{},
+	circleCIProvider{},
+	bitbucketPipelinesProvider{},
+}
+
+// Providers returns every registered provider in import order.
+func Providers() []Provider {
+	return append([]Provider{}, providers...)
+}
+
+// ProviderNames returns the registered provider names in import order.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// LookupProvider finds a provider by name (case-insensitive).
+func LookupProvider(name string) (Provider, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ProviderForCheck returns the name of the provider that generated check, or "" for
+// checks that did not come from CI. GitHub Actions checks predate provider sources and
+// are recognized by their "ci:" source/name prefix.
+func ProviderForCheck(check config.Check) string {
+	source := strings.ToLower(strings.TrimSpace(check.Source))
+	for _, p := range providers {
+		if source == p.Name() {
+			return p.Name()
+		}
+	}
+	if strings.HasPrefix(source, "ci") || strings.HasPrefix(strings.TrimSpace(check.Name), "ci:") {
+		return gitHubActionsProvider{}.Name()
+	}
+	return ""
+}
+
+type gitHubActionsProvider struct{}
+
+func (gitHubActionsProvider) Name() string { return "github" }
+
+func (gitHubActionsProvider) Detect(root string) bool {
+	return dirExists(filepath.Join(root, ".github", "workflows"))
+}
+
+func (gitHubActionsProvider) Checks(root string) ([]config.Check, error) {
+	return ChecksFromGitHubActions(root)
+}
+
+type gitLabProvider struct{}
+
+func (gitLabProvider) Name() string { return "gitlab" }
+
+func (gitLabProvider) Detect(root string) bool {
+	return fileExists(filepath.Join(root, GitLabCIFile))
+}
+
+func (gitLabProvider) Checks(root string) ([]config.Check, error) {
+	return ChecksFromGitLabCI(root)
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+func dirExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && st.IsDir()
+}
+
+// firstExisting returns the first of the candidate paths under root that is a file.
+func firstExisting(root string, candidates ...string) (string, bool) {
+	for _, rel := range candidates {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// uniqueCheckNames suffixes repeated names (":2", ":3", ...) so imported checks never
+// collide; config names must be unique.
+func uniqueCheckNames(checks []config.Check) []config.Check {
+	seen := make(map[string]int, len(checks))
+	for i := range checks {
+		name := checks[i].Name
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			checks[i].Name = fmt.Sprintf("%s:%d", name, n)
+		}
+	}
+	return checks
+}
+
+// readYAMLMap decodes a YAML file whose top level is a mapping.
+func readYAMLMap(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+	return out, nil
+}
+
+// yamlMappingKeys returns the keys of the mapping at key (top level) in document order.
+func yamlMappingKeys(path string, key string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil || len(node.Content) == 0 {
+		return nil
+	}
+	top := node.Content[0]
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value != key || top.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		child := top.Content[i+1]
+		keys := make([]string, 0, len(child.Content)/2)
+		for j := 0; j+1 < len(child.Content); j += 2 {
+			keys = append(keys, child.Content[j].Value)
+		}
+		return keys
+	}
+	return nil
+}
+
+func asMap(raw interface{}) map[string]interface{} {
+	m, _ := raw.(map[string]interface{})
+	return m
+}
+
+func asList(raw interface{}) []interface{} {
+	l, _ := raw.([]interface{})
+	return l
+}
+
+func asString(raw interface{}) string {
+	switch v := raw.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// stringMap converts a YAML mapping of scalars into env-style key/value pairs.
+func stringMap(raw interface{}) map[string]string {
+	m := asMap(raw)
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = asString(v)
+	}
+	return out
+}
+
+// firstLineLabel labels a step by its first non-empty command line.
+func firstLineLabel(name string, command string) string {
+	if strings.TrimSpace(name) != "" {
+		return sanitizeLabel(name)
+	}
+	for _, line := range strings.Split(command, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return sanitizeLabel(line)
+		}
+	}
+	return "step"
+}
@@ -13,6 +13,21 @@ import (
 )
 
 func Parse(data []byte) (*Config, error) {
+	cfg, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAndDefault(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// decodeDocument strictly decodes a single YAML document without validating it.
+// Composition (extends/include) needs the raw document before defaults are applied.
+func decodeDocument(data []byte) (*Config, error) {
 	var cfg Config
 
 	dec := yaml.NewDecoder(bytes.NewReader(data))
@@ -31,10 +46,6 @@ func Parse(data []byte) (*Config, error) {
 		return nil, err
 	}
 
-	if err := validateAndDefault(&cfg); err != nil {
-		return nil, err
-	}
-
 	return &cfg, nil
 }
 
@@ -46,20 +57,11 @@ func Save(path string, cfg *Config) error {
 		return errors.New("config is nil")
 	}
 
-	if err := validateAndDefault(cfg); err != nil {
-		return err
-	}
-
-	b, err := yaml.Marshal(cfg)
+	b, err := Marshal(cfg)
 	if err != nil {
 		return err
 	}
 
-	// Friendly POSIX convention (also helps diffs).
-	if len(b) == 0 || b[len(b)-1] != '\n' {
-		b = append(b, '\n')
-	}
-
 	dir := filepath.Dir(path)
 	if dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -76,6 +78,61 @@ func Save(path string, cfg *Config) error {
 	return writeFileAtomic(path, b, mode)
 }
 
+// Marshal validates cfg and renders it exactly as Save would write it.
+func Marshal(cfg *Config) ([]byte, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := validateAndDefault(cfg); err != nil {
+		return nil, err
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Friendly POSIX convention (also helps diffs).
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+	return b, nil
+}
+
+// MarshalWithOrigins renders cfg as YAML with an "origin:" comment above each check.
+// Used to show a fully resolved (extends/include) config; it is not meant to be saved.
+func MarshalWithOrigins(cfg *Config) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	if checks := mappingValue(&doc, "checks"); checks != nil && checks.Kind == yaml.SequenceNode {
+		for i, item := range checks.Content {
+			if i >= len(cfg.Checks) {
+				break
+			}
+			if origin := strings.TrimSpace(cfg.Checks[i].Origin); origin != "" {
+				item.HeadComment = "origin: " + origin
+			}
+		}
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
 	tmpPath := path + ".tmp"
 	backupPath := path + ".bak"
@@ -0,0 +1,585 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	assettemplates "github.com/berniemackie97/build-bouncer/assets/templates"
+)
+
+// TemplatePrefix marks an `extends:` value as an embedded template ID (ex: "template:go").
+const TemplatePrefix = "template:"
+
+// maxComposeDepth guards against runaway extends/include chains.
+const maxComposeDepth = 16
+
+// TemplateResolver returns the raw YAML for a template ID used in `extends:`.
+// The CLI replaces it so aliases and user template directories resolve the same way
+// they do for `init`; the default only knows embedded config_<id>.yaml files.
+var TemplateResolver = func(id string) ([]byte, error) {
+	b, err := assettemplates.FS.ReadFile("config_" + id + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown template %q", id)
+	}
+	return b, nil
+}
+
+// resolveComposed loads path and merges everything it extends or includes.
+// Merge order: extends base, then include files (in pattern/glob order), then the file itself.
+func resolveComposed(path string) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	resolver := &composer{root: configRoot(absPath), active: map[string]bool{}}
+	cfg, err := resolver.loadFile(absPath, 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Extends = ""
+	cfg.Include = nil
+	return cfg, nil
+}
+
+type composer struct {
+	root   string
+	active map[string]bool
+
+	// inherited holds what the files including the one being loaded have merged so
+	// far, so a file that only tweaks an inherited check validates against it.
+	inherited []*Config
+}
+
+func (c *composer) loadFile(absPath string, depth int) (*Config, error) {
+	if depth > maxComposeDepth {
+		return nil, fmt.Errorf("config: extends/include nested deeper than %d levels at %s", maxComposeDepth, c.label(absPath))
+	}
+	if c.active[absPath] {
+		return nil, fmt.Errorf("config: extends/include cycle at %s", c.label(absPath))
+	}
+	c.active[absPath] = true
+	defer delete(c.active, absPath)
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.label(absPath), err)
+	}
+	return c.compose(doc, c.label(absPath), configRoot(absPath), depth)
+}
+
+func (c *composer) loadTemplate(id string, depth int) (*Config, error) {
+	origin := TemplatePrefix + id
+	if c.active[origin] {
+		return nil, fmt.Errorf("config: extends cycle at %s", origin)
+	}
+	c.active[origin] = true
+	defer delete(c.active, origin)
+
+	data, err := TemplateResolver(id)
+	if err != nil {
+		return nil, fmt.Errorf("config: extends: %w", err)
+	}
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", origin, err)
+	}
+	return c.compose(doc, origin, c.root, depth)
+}
+
+// compose resolves doc's own extends/include (relative to baseDir) and merges doc on top.
+func (c *composer) compose(doc *Config, origin string, baseDir string, depth int) (*Config, error) {
+	merged := &Config{}
+
+	if extends := strings.TrimSpace(doc.Extends); extends != "" {
+		base, err := c.loadExtends(extends, baseDir, depth)
+		if err != nil {
+			return nil, err
+		}
+		merged = base
+	}
+
+	for _, pattern := range doc.Include {
+		paths, err := expandInclude(baseDir, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s include %q: %w", origin, pattern, err)
+		}
+		for _, includePath := range paths {
+			c.inherited = append(c.inherited, merged)
+			included, err := c.loadFile(includePath, depth+1)
+			c.inherited = c.inherited[:len(c.inherited)-1]
+			if err != nil {
+				return nil, err
+			}
+			mergeConfig(merged, included, "")
+		}
+	}
+
+	if err := validateComposedFile(doc, origin, append(append([]*Config{}, c.inherited...), merged)); err != nil {
+		return nil, err
+	}
+	mergeConfig(merged, doc, origin)
+	return merged, nil
+}
+
+// validateComposedFile validates doc, one file of a composed config, before it is
+// merged, so errors name the file and its own check indexes rather than positions in
+// the merged result. A check that overrides one in known (latest first) is validated
+// as it will look after the merge.
+func validateComposedFile(doc *Config, origin string, known []*Config) error {
+	if doc.Version != 0 && doc.Version != 1 {
+		return fmt.Errorf("config: %s: unsupported version %d", origin, doc.Version)
+	}
+	if doc.Runner.MaxParallel < 0 {
+		return fmt.Errorf("config: %s: runner.maxParallel must be >= 0", origin)
+	}
+	if err := validateProtectionAndInsults(origin+": ", &doc.Protection, &doc.Insults); err != nil {
+		return err
+	}
+	for _, name := range doc.ProfileNames() {
+		profile := doc.Profiles[name]
+		if err := validateProtectionAndInsults(origin+": profiles."+name+".", profile.Protection, profile.Insults); err != nil {
+			return err
+		}
+	}
+
+	seenNames := make(map[string]int, len(doc.Checks))
+	for i, check := range doc.Checks {
+		candidate := check
+		for j := len(known) - 1; j >= 0; j-- {
+			if idx := findMergeTarget(known[j].Checks, check); idx >= 0 {
+				candidate = mergeCheck(known[j].Checks[idx], check)
+				break
+			}
+		}
+		normalized, err := normalizeCheck(candidate)
+		if err != nil {
+			return fmt.Errorf("config: %s checks[%d] %w", origin, i, err)
+		}
+		if prev, exists := seenNames[normalized.Name]; exists {
+			return fmt.Errorf("config: %s checks[%d] name %q duplicates checks[%d] (check names must be unique)", origin, i, normalized.Name, prev)
+		}
+		seenNames[normalized.Name] = i
+	}
+	return nil
+}
+
+func (c *composer) loadExtends(extends string, baseDir string, depth int) (*Config, error) {
+	if id, ok := strings.CutPrefix(extends, TemplatePrefix); ok {
+		return c.loadTemplate(strings.TrimSpace(id), depth+1)
+	}
+
+	candidate := extends
+	if !filepath.IsAbs(candidate) {
+		candidate = filepath.Join(baseDir, filepath.FromSlash(candidate))
+	}
+	if st, err := os.Stat(candidate); err == nil && !st.IsDir() {
+		return c.loadFile(filepath.Clean(candidate), depth+1)
+	}
+
+	// Bare IDs ("go", "node") fall back to templates when no such file exists.
+	if !strings.ContainsAny(extends, `/\`) && filepath.Ext(extends) == "" {
+		return c.loadTemplate(extends, depth+1)
+	}
+	return nil, fmt.Errorf("config: extends %q: file not found", extends)
+}
+
+func (c *composer) label(absPath string) string {
+	if rel, err := filepath.Rel(c.root, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(absPath)
+}
+
+// expandInclude turns an include entry into sorted file paths. Glob patterns may match
+// nothing; literal paths must exist.
+func expandInclude(baseDir string, pattern string) ([]string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+	full := pattern
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(baseDir, filepath.FromSlash(pattern))
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		st, err := os.Stat(full)
+		if err != nil {
+			return nil, err
+		}
+		if st.IsDir() {
+			return nil, errors.New("is a directory")
+		}
+		return []string{filepath.Clean(full)}, nil
+	}
+
+	matches, err := filepath.Glob(full)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if st, err := os.Stat(match); err == nil && !st.IsDir() {
+			out = append(out, filepath.Clean(match))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// configRoot returns the directory relative paths in a config resolve against:
+// the repo root for .buildbouncer/config.yaml, otherwise the file's directory.
+func configRoot(absPath string) string {
+	dir := filepath.Dir(absPath)
+	if filepath.Base(dir) == ConfigDirName {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// mergeConfig merges src into dst. Checks merge by ID (falling back to name); fields set
+// on the overriding check replace inherited ones. Scalar settings in src win when set.
+// Checks new to dst record origin; an empty origin keeps whatever src already recorded.
+func mergeConfig(dst *Config, src *Config, origin string) {
+	if src.Version != 0 {
+		dst.Version = src.Version
+	}
+
+	if strings.TrimSpace(src.Meta.Template.ID) != "" {
+		dst.Meta.Template.ID = src.Meta.Template.ID
+	}
+	if len(src.Meta.Inputs) > 0 {
+		if dst.Meta.Inputs == nil {
+			dst.Meta.Inputs = map[string]string{}
+		}
+		for k, v := range src.Meta.Inputs {
+			dst.Meta.Inputs[k] = v
+		}
+	}
+
+	if len(src.Vars) > 0 {
+		if dst.Vars == nil {
+			dst.Vars = map[string]string{}
+		}
+		for k, v := range src.Vars {
+			dst.Vars[k] = v
+		}
+	}
+
+	for _, check := range src.Checks {
+		if origin != "" {
+			check.Origin = origin
+		}
+		if idx := findMergeTarget(dst.Checks, check); idx >= 0 {
+			dst.Checks[idx] = mergeCheck(dst.Checks[idx], check)
+			continue
+		}
+		dst.Checks = append(dst.Checks, check)
+	}
+
+	for _, parser := range src.Parsers {
+		if idx := findParser(dst.Parsers, parser.Name); idx >= 0 {
+			dst.Parsers[idx] = parser
+			continue
+		}
+		dst.Parsers = append(dst.Parsers, parser)
+	}
+
+	if src.Runner.MaxParallel != 0 {
+		dst.Runner.MaxParallel = src.Runner.MaxParallel
+	}
+	if src.Runner.FailFast != nil {
+		failFast := *src.Runner.FailFast
+		dst.Runner.FailFast = &failFast
+	}
+
+	if strings.TrimSpace(src.Protection.Level) != "" {
+		dst.Protection.Level = src.Protection.Level
+	}
+	if src.Protection.Interactive != nil {
+		dst.Protection.Interactive = src.Protection.Interactive
+	}
+	if len(src.Protection.CriticalPatterns) > 0 {
+		dst.Protection.CriticalPatterns = append([]string{}, src.Protection.CriticalPatterns...)
+	}
+	if src.Protection.Locked {
+		dst.Protection.Locked = true
+	}
+
+	if strings.TrimSpace(src.Insults.Mode) != "" {
+		dst.Insults.Mode = src.Insults.Mode
+	}
+	if strings.TrimSpace(src.Insults.File) != "" {
+		dst.Insults.File = src.Insults.File
+	}
+	if strings.TrimSpace(src.Insults.Locale) != "" {
+		dst.Insults.Locale = src.Insults.Locale
+	}
+
+	if src.Banter.Enabled != nil {
+		dst.Banter.Enabled = src.Banter.Enabled
+	}
+	if strings.TrimSpace(src.Banter.File) != "" {
+		dst.Banter.File = src.Banter.File
+	}
+	if strings.TrimSpace(src.Banter.Locale) != "" {
+		dst.Banter.Locale = src.Banter.Locale
+	}
+
+	if len(src.Profiles) > 0 {
+		if dst.Profiles == nil {
+			dst.Profiles = map[string]Profile{}
+		}
+		for name, profile := range src.Profiles {
+			dst.Profiles[name] = profile
+		}
+	}
+
+	dst.Sources = append(dst.Sources, src.Sources...)
+	if origin != "" {
+		dst.Sources = append(dst.Sources, origin)
+	}
+}
+
+func findMergeTarget(checks []Check, check Check) int {
+	if id := strings.TrimSpace(check.ID); id != "" {
+		for i := range checks {
+			if strings.TrimSpace(checks[i].ID) == id {
+				return i
+			}
+		}
+		return -1
+	}
+	name := strings.TrimSpace(check.Name)
+	if name == "" {
+		return -1
+	}
+	for i := range checks {
+		if strings.TrimSpace(checks[i].Name) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeCheck overlays the fields set on override onto base.
+func findParser(parsers []Parser, name string) int {
+	for i := range parsers {
+		if strings.TrimSpace(parsers[i].Name) == strings.TrimSpace(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeCheck(base Check, override Check) Check {
+	out := base
+	if strings.TrimSpace(override.ID) != "" {
+		out.ID = override.ID
+	}
+	if strings.TrimSpace(override.Source) != "" {
+		out.Source = override.Source
+	}
+	if strings.TrimSpace(override.Name) != "" {
+		out.Name = override.Name
+	}
+	if strings.TrimSpace(override.Run) != "" {
+		out.Run = override.Run
+		out.Builtin = ""
+		out.With = nil
+	}
+	if strings.TrimSpace(override.Shell) != "" {
+		out.Shell = override.Shell
+	}
+	if strings.TrimSpace(override.Builtin) != "" {
+		out.Builtin = override.Builtin
+		out.Run = ""
+	}
+	if len(override.With) > 0 {
+		with := make(map[string]any, len(base.With)+len(override.With))
+		for k, v := range base.With {
+			with[k] = v
+		}
+		for k, v := range override.With {
+			with[k] = v
+		}
+		out.With = with
+	}
+	if strings.TrimSpace(override.Cwd) != "" {
+		out.Cwd = override.Cwd
+	}
+	if len(override.Env) > 0 {
+		env := make(map[string]string, len(base.Env)+len(override.Env))
+		for k, v := range base.Env {
+			env[k] = v
+		}
+		for k, v := range override.Env {
+			env[k] = v
+		}
+		out.Env = env
+	}
+	if len(override.OS) > 0 || len(override.Platforms) > 0 {
+		out.OS = override.OS
+		out.Platforms = override.Platforms
+	}
+	if len(override.Requires) > 0 {
+		out.Requires = override.Requires
+	}
+	if override.Timeout != 0 {
+		out.Timeout = override.Timeout
+	}
+	if len(override.Tags) > 0 {
+		out.Tags = override.Tags
+	}
+	if override.Matrix != nil {
+		out.Matrix = override.Matrix
+	}
+	if strings.TrimSpace(override.Note) != "" {
+		out.Note = override.Note
+	}
+	if strings.TrimSpace(override.Fix) != "" {
+		out.Fix = override.Fix
+	}
+	if strings.TrimSpace(override.Rerun) != "" {
+		out.Rerun = override.Rerun
+	}
+	if strings.TrimSpace(override.Parser) != "" {
+		out.Parser = override.Parser
+	}
+	if len(override.ProblemMatchers) > 0 {
+		out.ProblemMatchers = override.ProblemMatchers
+	}
+	if override.Output != nil {
+		out.Output = override.Output
+	}
+	if len(override.Paths) > 0 {
+		out.Paths = override.Paths
+	}
+	if strings.TrimSpace(override.Origin) != "" {
+		out.Origin = override.Origin
+	}
+	return out
+}
+
+func isComposed(cfg *Config) bool {
+	return strings.TrimSpace(cfg.Extends) != "" || len(cfg.Include) > 0
+}
+
+// applyLocalOverlay merges the developer's untracked overlay (if any) onto the resolved
+// team config. The overlay can add checks and tune existing ones (timeouts, env, banter),
+// but when the team sets protection.locked it cannot touch protection or profiles, or
+// change how (or whether) a team check runs.
+func applyLocalOverlay(cfg *Config, cfgPath string) error {
+	absPath, err := filepath.Abs(cfgPath)
+	if err != nil {
+		return err
+	}
+	localPath := LocalConfigPath(absPath)
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	resolver := &composer{root: configRoot(absPath)}
+	label := resolver.label(localPath)
+
+	overlay, err := decodeDocument(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", label, err)
+	}
+	if isComposed(overlay) {
+		return fmt.Errorf("config: %s cannot use extends/include", label)
+	}
+	if cfg.Protection.Locked {
+		if err := checkLockedOverlay(cfg, overlay, label); err != nil {
+			return err
+		}
+	}
+	if err := validateComposedFile(overlay, label, []*Config{cfg}); err != nil {
+		return err
+	}
+
+	mergeConfig(cfg, overlay, label)
+	return nil
+}
+
+func checkLockedOverlay(team *Config, overlay *Config, label string) error {
+	p := overlay.Protection
+	if strings.TrimSpace(p.Level) != "" || p.Interactive != nil || len(p.CriticalPatterns) > 0 || p.Locked {
+		return fmt.Errorf("config: %s cannot change protection (locked by team config)", label)
+	}
+	// Profiles pick which checks run on push (hook) and in CI, so a new one could swap
+	// every team check for a personal one.
+	if names := overlay.ProfileNames(); len(names) > 0 {
+		name := names[0]
+		switch {
+		case overlay.Profiles[name].Protection != nil:
+			return fmt.Errorf("config: %s profiles.%s cannot change protection (locked by team config)", label, name)
+		case team.HasProfile(name):
+			return fmt.Errorf("config: %s cannot redefine team profile %q (locked by team config)", label, name)
+		default:
+			return fmt.Errorf("config: %s cannot add profile %q (locked by team config)", label, name)
+		}
+	}
+	// A var feeds every team check that references it, so redefining one rewrites them.
+	if len(overlay.Vars) > 0 {
+		used := checkVarReferences(team.Checks)
+		for _, name := range sortedKeys(overlay.Vars) {
+			if _, defined := team.Vars[name]; defined || used[name] {
+				return fmt.Errorf("config: %s cannot redefine vars.%s (used by team checks; locked by team config)", label, name)
+			}
+		}
+	}
+	for _, check := range overlay.Checks {
+		idx := findMergeTarget(team.Checks, check)
+		if idx < 0 {
+			continue
+		}
+		// Besides how a check runs, paths/requires/os can skip it, tags can drop it from
+		// a profile, and env can change what it tests. A longer timeout only gives a slow
+		// machine more room; a shorter one (or any limit where the team set none) can fail it.
+		if strings.TrimSpace(check.Run) != "" || strings.TrimSpace(check.Shell) != "" || strings.TrimSpace(check.Builtin) != "" || len(check.With) > 0 || strings.TrimSpace(check.Fix) != "" ||
+			strings.TrimSpace(check.Cwd) != "" || len(check.OS) > 0 || len(check.Platforms) > 0 || check.Matrix != nil ||
+			len(check.Paths) > 0 || len(check.Requires) > 0 || len(check.Tags) > 0 || len(check.Env) > 0 {
+			return fmt.Errorf("config: %s cannot redefine team check %q (locked by team config)", label, team.Checks[idx].Name)
+		}
+		if teamTimeout := team.Checks[idx].Timeout; check.Timeout != 0 && (teamTimeout == 0 || check.Timeout < teamTimeout) {
+			return fmt.Errorf("config: %s cannot lower the timeout of team check %q (locked by team config)", label, team.Checks[idx].Name)
+		}
+	}
+	return nil
+}
+
+// checkVarReferences collects the vars.* names that checks reference.
+func checkVarReferences(checks []Check) map[string]bool {
+	used := map[string]bool{}
+	for _, check := range checks {
+		texts := []string{check.Run, check.Fix, check.Rerun, check.Cwd}
+		if check.Output != nil {
+			texts = append(texts, check.Output.File)
+		}
+		for _, value := range check.Env {
+			texts = append(texts, value)
+		}
+		for _, text := range texts {
+			refs, _ := references(text)
+			for _, ref := range refs {
+				if ref.namespace == "vars" {
+					used[ref.key] = true
+				}
+			}
+		}
+	}
+	return used
+}
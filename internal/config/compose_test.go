@@ -0,0 +1,326 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLoadResolvesExtendsAndInclude(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "shared", "base.yaml"), `
+version: 1
+checks:
+  - name: "lint"
+    id: "team:lint"
+    run: "golangci-lint run"
+    timeout: 1m
+runner:
+  maxParallel: 2
+insults:
+  mode: "nuclear"
+`)
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "checks", "b-security.yaml"), `
+checks:
+  - name: "gosec"
+    run: "gosec ./..."
+`)
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "checks", "a-vuln.yaml"), `
+checks:
+  - name: "govulncheck"
+    run: "govulncheck ./..."
+`)
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+version: 1
+extends: shared/base.yaml
+include: [.buildbouncer/checks/*.yaml]
+checks:
+  - id: "team:lint"
+    timeout: 5m
+  - name: "tests"
+    run: "go test ./..."
+insults:
+  mode: "polite"
+`)
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	var names []string
+	for _, c := range cfg.Checks {
+		names = append(names, c.Name+"@"+c.Origin)
+	}
+	want := []string{
+		"lint@.buildbouncer/config.yaml",
+		"govulncheck@.buildbouncer/checks/a-vuln.yaml",
+		"gosec@.buildbouncer/checks/b-security.yaml",
+		"tests@.buildbouncer/config.yaml",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected checks:\n got %v\nwant %v", names, want)
+	}
+
+	lint := cfg.Checks[0]
+	if lint.Run != "golangci-lint run" || lint.Timeout != 5*time.Minute {
+		t.Fatalf("expected inherited run with local timeout, got %+v", lint)
+	}
+	if cfg.Runner.MaxParallel != 2 {
+		t.Fatalf("expected inherited runner settings, got %+v", cfg.Runner)
+	}
+	if cfg.Insults.Mode != "polite" {
+		t.Fatalf("expected local insult mode to win, got %q", cfg.Insults.Mode)
+	}
+	if cfg.Extends != "" || len(cfg.Include) != 0 {
+		t.Fatalf("expected resolved config to drop extends/include, got %q %v", cfg.Extends, cfg.Include)
+	}
+	if len(cfg.Sources) != 4 || cfg.Sources[0] != "shared/base.yaml" {
+		t.Fatalf("unexpected sources: %v", cfg.Sources)
+	}
+}
+
+func TestLoadExtendsCanTurnFailFastOff(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "shared", "base.yaml"), `
+checks:
+  - name: "lint"
+    run: "golangci-lint run"
+runner:
+  failFast: true
+`)
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+extends: shared/base.yaml
+runner:
+  failFast: false
+`)
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Runner.IsFailFast() {
+		t.Fatalf("expected failFast: false to override the base")
+	}
+
+	writeConfigFile(t, cfgPath, "extends: shared/base.yaml\n")
+	if cfg, err = Load(cfgPath); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Runner.IsFailFast() {
+		t.Fatalf("expected failFast inherited from the base when unset")
+	}
+}
+
+func TestLoadExtendsEmbeddedTemplate(t *testing.T) {
+	root := t.TempDir()
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+extends: template:go
+checks:
+  - name: "build"
+    run: "go build ./..."
+`)
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 3 {
+		t.Fatalf("expected go template checks plus build, got %+v", cfg.Checks)
+	}
+	if cfg.Checks[0].Origin != "template:go" {
+		t.Fatalf("expected template origin, got %q", cfg.Checks[0].Origin)
+	}
+}
+
+func TestLoadRejectsExtendsCycle(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "a.yaml"), "extends: b.yaml\n")
+	writeConfigFile(t, filepath.Join(root, "b.yaml"), "extends: a.yaml\n")
+
+	if _, err := Load(filepath.Join(root, "a.yaml")); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestLoadFileDoesNotResolveExtends(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "base.yaml"), `
+checks:
+  - name: "lint"
+    run: "go vet ./..."
+`)
+	cfgPath := filepath.Join(root, "config.yaml")
+	writeConfigFile(t, cfgPath, "extends: base.yaml\n")
+
+	cfg, err := LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load file: %v", err)
+	}
+	if len(cfg.Checks) != 0 || cfg.Extends != "base.yaml" {
+		t.Fatalf("expected unresolved config, got %+v", cfg)
+	}
+}
+
+func TestLoadAppliesLocalOverlay(t *testing.T) {
+	root := t.TempDir()
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+version: 1
+checks:
+  - name: "tests"
+    run: "go test ./..."
+    timeout: 1m
+`)
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "config.local.yaml"), `
+checks:
+  - name: "tests"
+    timeout: 10m
+  - name: "slow-lint"
+    run: "golangci-lint run"
+insults:
+  mode: "polite"
+`)
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 2 || cfg.Checks[0].Timeout != 10*time.Minute || cfg.Checks[0].Run != "go test ./..." {
+		t.Fatalf("expected local timeout on team check plus local check, got %+v", cfg.Checks)
+	}
+	if cfg.Checks[1].Origin != ".buildbouncer/config.local.yaml" {
+		t.Fatalf("expected local origin, got %q", cfg.Checks[1].Origin)
+	}
+	if cfg.Insults.Mode != "polite" {
+		t.Fatalf("expected local insult mode, got %q", cfg.Insults.Mode)
+	}
+
+	file, err := LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load file: %v", err)
+	}
+	if len(file.Checks) != 1 {
+		t.Fatalf("expected LoadFile to ignore the overlay, got %+v", file.Checks)
+	}
+}
+
+func TestLoadLocalOverlayRespectsLockedProtection(t *testing.T) {
+	team := `
+version: 1
+vars:
+  pkgs: "./..."
+checks:
+  - name: "tests"
+    run: "go test ${{ vars.pkgs }}"
+    timeout: 5m
+  - name: "vet"
+    run: "go vet ./..."
+protection:
+  level: strict
+  locked: true
+`
+	cases := []struct {
+		name    string
+		overlay string
+		wantErr string
+	}{
+		{name: "protection", overlay: "protection:\n  level: lax\n", wantErr: "cannot change protection"},
+		{name: "run", overlay: "checks:\n  - name: \"tests\"\n    run: \"true\"\n", wantErr: `cannot redefine team check "tests"`},
+		{name: "profile", overlay: "profiles:\n  hook:\n    protection:\n      interactive: true\n", wantErr: "cannot change protection"},
+		{name: "paths", overlay: "checks:\n  - name: \"tests\"\n    paths: [nothing-matches]\n", wantErr: `cannot redefine team check "tests"`},
+		{name: "requires", overlay: "checks:\n  - name: \"tests\"\n    requires: [missing-tool]\n", wantErr: `cannot redefine team check "tests"`},
+		{name: "tags", overlay: "checks:\n  - name: \"tests\"\n    tags: [personal]\n", wantErr: `cannot redefine team check "tests"`},
+		{name: "env", overlay: "checks:\n  - name: \"tests\"\n    env:\n      GOFLAGS: \"-run=None\"\n", wantErr: `cannot redefine team check "tests"`},
+		{name: "shorter timeout", overlay: "checks:\n  - name: \"tests\"\n    timeout: 1ms\n", wantErr: `cannot lower the timeout of team check "tests"`},
+		{name: "timeout on unlimited check", overlay: "checks:\n  - name: \"vet\"\n    timeout: 1h\n", wantErr: `cannot lower the timeout of team check "vet"`},
+		{name: "new hook profile", overlay: "checks:\n  - name: \"mine\"\n    run: \"true\"\nprofiles:\n  hook:\n    checks: [mine]\n", wantErr: `cannot add profile "hook"`},
+		{name: "new profile", overlay: "profiles:\n  quick: {}\n", wantErr: `cannot add profile "quick"`},
+		{name: "team var", overlay: "vars:\n  pkgs: ./nothing\n", wantErr: "cannot redefine vars.pkgs"},
+		{name: "allowed", overlay: "vars:\n  lintArgs: --fast\nchecks:\n  - name: \"tests\"\n    note: \"slow on my laptop\"\n    timeout: 10m\n  - name: \"extra\"\n    run: \"make lint ${{ vars.lintArgs }}\"\ninsults:\n  mode: polite\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+			writeConfigFile(t, cfgPath, team)
+			writeConfigFile(t, filepath.Join(root, ".buildbouncer", "config.local.yaml"), tc.overlay)
+
+			cfg, err := Load(cfgPath)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected overlay to load, got %v", err)
+				}
+				if cfg.Protection.ProtectionLevel() != "strict" {
+					t.Fatalf("expected strict protection, got %q", cfg.Protection.Level)
+				}
+				if cfg.Checks[0].Timeout != 10*time.Minute {
+					t.Fatalf("expected the longer local timeout, got %s", cfg.Checks[0].Timeout)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected %q error, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesEachComposedFileOnItsOwn(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "shared", "base.yaml"), `
+checks:
+  - name: "lint"
+    id: "team:lint"
+    run: "golangci-lint run"
+`)
+	// Only tweaks a check from the including file's base: valid once merged.
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "checks", "a-timeouts.yaml"), `
+checks:
+  - id: "team:lint"
+    timeout: 5m
+`)
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "checks", "b-broken.yaml"), `
+checks:
+  - name: "gosec"
+    run: "gosec ./..."
+  - name: "vuln"
+    shell: "bash -lc"
+`)
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+extends: shared/base.yaml
+include: [.buildbouncer/checks/*.yaml]
+checks:
+  - name: "tests"
+    run: "go test ./..."
+`)
+
+	_, err := Load(cfgPath)
+	if err == nil || !strings.Contains(err.Error(), "config: .buildbouncer/checks/b-broken.yaml checks[1] missing run") {
+		t.Fatalf("expected the error to name the broken file and its own index, got %v", err)
+	}
+
+	writeConfigFile(t, filepath.Join(root, ".buildbouncer", "checks", "b-broken.yaml"), "checks: []\n")
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Checks[0].Timeout != 5*time.Minute {
+		t.Fatalf("expected the included timeout to apply, got %+v", cfg.Checks[0])
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 var ErrConfigNotFound = errors.New("build-bouncer config not found")
 
+// FindConfigFromCwd walks up from the working directory to the nearest team config.
+// A config.local.yaml on its own never counts as a config; Load overlays it onto the
+// team config found here (see LocalConfigPath).
 func FindConfigFromCwd() (cfgPath string, cfgDir string, err error) {
 	start, err := os.Getwd()
 	if err != nil {
@@ -55,3 +58,12 @@ func FindConfigFromCwd() (cfgPath string, cfgDir string, err error) {
 		LegacyConfigName,
 	)
 }
+
+// RootDir returns the directory a config file's relative paths resolve against: the
+// repo root for .buildbouncer/config.yaml, otherwise the file's own directory.
+func RootDir(cfgPath string) string {
+	if abs, err := filepath.Abs(cfgPath); err == nil {
+		cfgPath = abs
+	}
+	return configRoot(cfgPath)
+}
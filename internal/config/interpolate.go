@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Interpolation is what `${{ ... }}` expressions in run, cwd, and env expand against.
+//
+// Supported references:
+//   - vars.NAME   top-level `vars:` entry
+//   - env.NAME    the check's env, then the process environment (empty when unset)
+//   - repo.root   absolute repo root
+//   - git.branch  current branch (empty when detached)
+//   - os          windows | linux | macos
+//   - matrix.KEY  the combination a matrix check was expanded for
+type Interpolation struct {
+	RepoRoot  string
+	Branch    string
+	OS        string
+	LookupEnv func(key string) (string, bool)
+}
+
+var (
+	interpolationPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+	varNamePattern       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+type reference struct {
+	namespace string
+	key       string
+}
+
+func parseReference(expr string) (reference, error) {
+	switch expr {
+	case "os":
+		return reference{namespace: "os"}, nil
+	case "repo.root":
+		return reference{namespace: "repo", key: "root"}, nil
+	case "git.branch":
+		return reference{namespace: "git", key: "branch"}, nil
+	}
+	namespace, key, ok := strings.Cut(expr, ".")
+	if ok && varNamePattern.MatchString(key) {
+		switch namespace {
+		case "vars", "env", "matrix":
+			return reference{namespace: namespace, key: key}, nil
+		}
+	}
+	return reference{}, fmt.Errorf("unknown variable %q", expr)
+}
+
+// references returns every `${{ }}` reference in text, failing on unknown ones.
+func references(text string) ([]reference, error) {
+	var refs []reference
+	for _, match := range interpolationPattern.FindAllStringSubmatch(text, -1) {
+		ref, err := parseReference(match[1])
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// validateInterpolation rejects malformed matrices and unknown variables in vars, run,
+// fix, cwd, and env; matrix.* must name a key of the check's own matrix. When strict
+// is false (the file inherits from elsewhere), vars.* references to names defined in
+// another file are left for Load to catch.
+func validateInterpolation(cfg *Config, strict bool) error {
+	for _, name := range sortedKeys(cfg.Vars) {
+		if !varNamePattern.MatchString(name) {
+			return fmt.Errorf("config: vars: invalid name %q", name)
+		}
+		refs, err := references(cfg.Vars[name])
+		if err != nil {
+			return fmt.Errorf("config: vars.%s: %w", name, err)
+		}
+		for _, ref := range refs {
+			if ref.namespace == "vars" || ref.namespace == "matrix" {
+				return fmt.Errorf("config: vars.%s: vars cannot reference %s.*", name, ref.namespace)
+			}
+		}
+	}
+
+	for i, check := range cfg.Checks {
+		matrixKeys, err := validateMatrix(check.Matrix)
+		if err != nil {
+			return fmt.Errorf("config: checks[%d] matrix: %w", i, err)
+		}
+
+		checkRefs := func(field string, text string) error {
+			refs, err := references(text)
+			if err != nil {
+				return fmt.Errorf("config: checks[%d] %s: %w", i, field, err)
+			}
+			for _, ref := range refs {
+				switch {
+				case ref.namespace == "matrix" && !matrixKeys[ref.key]:
+					return fmt.Errorf("config: checks[%d] %s: unknown variable \"matrix.%s\" (not a matrix key of this check)", i, field, ref.key)
+				case ref.namespace == "vars" && strict:
+					if _, ok := cfg.Vars[ref.key]; !ok {
+						return fmt.Errorf("config: checks[%d] %s: unknown variable \"vars.%s\"", i, field, ref.key)
+					}
+				}
+			}
+			return nil
+		}
+
+		if err := checkRefs("run", check.Run); err != nil {
+			return err
+		}
+		if err := checkRefs("fix", check.Fix); err != nil {
+			return err
+		}
+		if err := checkRefs("rerun", check.Rerun); err != nil {
+			return err
+		}
+		if err := checkRefs("cwd", check.Cwd); err != nil {
+			return err
+		}
+		if check.Output != nil {
+			if err := checkRefs("output.file", check.Output.File); err != nil {
+				return err
+			}
+		}
+		for _, key := range sortedKeys(check.Env) {
+			if err := checkRefs("env."+key, check.Env[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandCheck returns a copy of check with `${{ }}` expressions in run, fix, cwd,
+// output.file and env expanded. Env values expand first (env.* there sees only the process environment);
+// run, fix and cwd then see the check's expanded env.
+func (c *Config) ExpandCheck(check Check, ctx Interpolation) (Check, error) {
+	vars := make(map[string]string, len(c.Vars))
+	for name, value := range c.Vars {
+		expanded, err := expandText(value, ctx, nil, nil, nil)
+		if err != nil {
+			return check, fmt.Errorf("vars.%s: %w", name, err)
+		}
+		vars[name] = expanded
+	}
+
+	out := check
+	if len(check.Env) > 0 {
+		out.Env = make(map[string]string, len(check.Env))
+		for _, key := range sortedKeys(check.Env) {
+			expanded, err := expandText(check.Env[key], ctx, vars, nil, check.MatrixValues)
+			if err != nil {
+				return check, fmt.Errorf("check %q env.%s: %w", check.Name, key, err)
+			}
+			out.Env[key] = expanded
+		}
+	}
+
+	run, err := expandText(check.Run, ctx, vars, out.Env, check.MatrixValues)
+	if err != nil {
+		return check, fmt.Errorf("check %q run: %w", check.Name, err)
+	}
+	fix, err := expandText(check.Fix, ctx, vars, out.Env, check.MatrixValues)
+	if err != nil {
+		return check, fmt.Errorf("check %q fix: %w", check.Name, err)
+	}
+	cwd, err := expandText(check.Cwd, ctx, vars, out.Env, check.MatrixValues)
+	if err != nil {
+		return check, fmt.Errorf("check %q cwd: %w", check.Name, err)
+	}
+	out.Run = run
+	out.Fix = fix
+	out.Cwd = cwd
+	if check.Output != nil {
+		file, err := expandText(check.Output.File, ctx, vars, out.Env, check.MatrixValues)
+		if err != nil {
+			return check, fmt.Errorf("check %q output.file: %w", check.Name, err)
+		}
+		output := *check.Output
+		output.File = file
+		out.Output = &output
+	}
+	return out, nil
+}
+
+func expandText(text string, ctx Interpolation, vars map[string]string, checkEnv map[string]string, matrix map[string]string) (string, error) {
+	if !strings.Contains(text, "${{") {
+		return text, nil
+	}
+
+	var firstErr error
+	out := interpolationPattern.ReplaceAllStringFunc(text, func(match string) string {
+		expr := interpolationPattern.FindStringSubmatch(match)[1]
+		ref, err := parseReference(expr)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		switch ref.namespace {
+		case "os":
+			return ctx.OS
+		case "repo":
+			return ctx.RepoRoot
+		case "git":
+			return ctx.Branch
+		case "env":
+			if value, ok := checkEnv[ref.key]; ok {
+				return value
+			}
+			if ctx.LookupEnv != nil {
+				if value, ok := ctx.LookupEnv(ref.key); ok {
+					return value
+				}
+			}
+			return ""
+		case "matrix":
+			value, ok := matrix[ref.key]
+			if !ok {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unknown variable %q", expr)
+				}
+				return match
+			}
+			return value
+		case "vars":
+			value, ok := vars[ref.key]
+			if !ok {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unknown variable %q", expr)
+				}
+				return match
+			}
+			return value
+		}
+		return match
+	})
+	return out, firstErr
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
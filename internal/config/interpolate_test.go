@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandCheckInterpolatesVarsEnvAndContext(t *testing.T) {
+	cfg, err := Parse([]byte(`
+version: 1
+vars:
+  pkgs: "./..."
+  out: "${{ repo.root }}/out"
+checks:
+  - name: "tests"
+    run: "go test ${{ vars.pkgs }} -o ${{ vars.out }}/${{ os }} # ${{ env.MODE }} on ${{ git.branch }}"
+    cwd: "${{ vars.pkgs }}"
+    env:
+      MODE: "${{ env.HOME_MODE }}-fast"
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	ctx := Interpolation{
+		RepoRoot: "/repo",
+		Branch:   "main",
+		OS:       "linux",
+		LookupEnv: func(key string) (string, bool) {
+			if key == "HOME_MODE" {
+				return "ci", true
+			}
+			return "", false
+		},
+	}
+	got, err := cfg.ExpandCheck(cfg.Checks[0], ctx)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	if want := "go test ./... -o /repo/out/linux # ci-fast on main"; got.Run != want {
+		t.Fatalf("unexpected run:\n got %q\nwant %q", got.Run, want)
+	}
+	if got.Cwd != "./..." || got.Env["MODE"] != "ci-fast" {
+		t.Fatalf("unexpected cwd/env: %q %v", got.Cwd, got.Env)
+	}
+	if !strings.Contains(cfg.Checks[0].Run, "${{ vars.pkgs }}") {
+		t.Fatalf("expected original check to stay raw, got %q", cfg.Checks[0].Run)
+	}
+}
+
+func TestParseRejectsUnknownVariables(t *testing.T) {
+	cases := map[string]string{
+		"unknown namespace": "checks:\n  - name: a\n    run: \"echo ${{ secrets.TOKEN }}\"\n",
+		"undefined var":     "checks:\n  - name: a\n    run: \"echo ${{ vars.missing }}\"\n",
+		"env value":         "checks:\n  - name: a\n    run: \"true\"\n    env:\n      X: \"${{ repo.name }}\"\n",
+		"nested vars":       "vars:\n  a: \"${{ vars.b }}\"\n  b: x\nchecks:\n  - name: a\n    run: \"true\"\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(content)); err == nil {
+				t.Fatalf("expected validation error")
+			}
+		})
+	}
+}
@@ -5,10 +5,48 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// Load reads a config file, resolves composition (extends/include), overlays the
+// optional untracked config.local.yaml, and validates the merged result. The returned
+// config is self-contained: Extends and Include are cleared, matrix checks are expanded
+// into one check per combination, and every check records the file it came from in Origin.
+//
+// Commands that rewrite the config on disk should use LoadFile instead so inherited
+// checks are not flattened into the local file.
 func Load(path string) (*Config, error) {
+	return load(path, true)
+}
+
+// LoadTeam is Load without config.local.yaml: the config as committed, for output that
+// is shared with the rest of the team (such as an exported CI pipeline).
+func LoadTeam(path string) (*Config, error) {
+	return load(path, false)
+}
+
+func load(path string, withLocal bool) (*Config, error) {
+	cfg, err := resolveComposed(path)
+	if err != nil {
+		return nil, err
+	}
+	if withLocal {
+		if err := applyLocalOverlay(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateAndDefault(cfg); err != nil {
+		return nil, err
+	}
+	if err := expandMatrixChecks(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFile reads and validates a single config file without resolving extends/include.
+func LoadFile(path string) (*Config, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -16,6 +54,10 @@ func Load(path string) (*Config, error) {
 	return Parse(b)
 }
 
+// BuiltinValidator checks a `builtin:` name and its `with:` options. The CLI sets it
+// to the built-in registry; when nil any name is accepted.
+var BuiltinValidator func(name string, with map[string]any) error
+
 // validateAndDefault mutates cfg in-place (defaults + normalization).
 func validateAndDefault(cfg *Config) error {
 	if cfg.Version == 0 {
@@ -25,7 +67,9 @@ func validateAndDefault(cfg *Config) error {
 		return fmt.Errorf("config: unsupported version %d", cfg.Version)
 	}
 
-	if len(cfg.Checks) == 0 {
+	// A file that extends or includes others may rely on them for its checks.
+	composed := isComposed(cfg)
+	if len(cfg.Checks) == 0 && !composed {
 		return errors.New("config: no checks configured")
 	}
 
@@ -33,58 +77,37 @@ func validateAndDefault(cfg *Config) error {
 	seenNames := make(map[string]int, len(cfg.Checks))
 
 	for i := range cfg.Checks {
-		c := cfg.Checks[i]
-
-		name := strings.TrimSpace(c.Name)
-		if name == "" {
-			return fmt.Errorf("config: checks[%d] missing name", i)
-		}
-		if prev, exists := seenNames[name]; exists {
-			return fmt.Errorf("config: checks[%d] name %q duplicates checks[%d] (check names must be unique)", i, name, prev)
-		}
-		seenNames[name] = i
-
-		if strings.TrimSpace(c.Run) == "" {
-			return fmt.Errorf("config: checks[%d] missing run", i)
-		}
-
-		// Config is user-authored: keep shell strict and predictable.
-		// shell must be ONLY the executable name/path (no args).
-		if err := validateShellSpec(c.Shell); err != nil {
-			return fmt.Errorf("config: checks[%d] shell: %w", i, err)
-		}
-
-		osList, err := normalizeOSList(c.OS, c.Platforms)
-		if err != nil {
-			return fmt.Errorf("config: checks[%d] os: %w", i, err)
-		}
-
-		requires, err := normalizeStringList(c.Requires)
+		c, err := normalizeCheck(cfg.Checks[i])
 		if err != nil {
-			return fmt.Errorf("config: checks[%d] requires: %w", i, err)
+			return fmt.Errorf("config: checks[%d] %w", i, err)
 		}
-
-		if c.Timeout < 0 {
-			return fmt.Errorf("config: checks[%d] timeout must be >= 0", i)
+		if prev, exists := seenNames[c.Name]; exists {
+			return fmt.Errorf("config: checks[%d] name %q duplicates checks[%d] (check names must be unique)", i, c.Name, prev)
 		}
+		seenNames[c.Name] = i
+		cfg.Checks[i] = c
+	}
 
-		if err := validateEnvOverrides(c.Env); err != nil {
-			return fmt.Errorf("config: checks[%d] env: %w", i, err)
-		}
+	if err := validateProfiles(cfg, !composed); err != nil {
+		return err
+	}
 
-		// Normalize deprecated/legacy fields into canonical ones.
-		c.Name = name
-		c.OS = osList
-		c.Platforms = nil
-		c.Requires = requires
+	if err := validateInterpolation(cfg, !composed); err != nil {
+		return err
+	}
 
-		cfg.Checks[i] = c
+	if err := validateParsers(cfg, !composed); err != nil {
+		return err
 	}
 
 	if cfg.Runner.MaxParallel < 0 {
 		return errors.New("config: runner.maxParallel must be >= 0")
 	}
 
+	if err := validateProtectionAndInsults("", &cfg.Protection, &cfg.Insults); err != nil {
+		return err
+	}
+
 	if strings.TrimSpace(cfg.Insults.Mode) == "" {
 		cfg.Insults.Mode = "snarky"
 	}
@@ -109,6 +132,128 @@ func validateAndDefault(cfg *Config) error {
 	return nil
 }
 
+// validateProtectionAndInsults normalizes protection.level and insults.mode (top-level
+// or a profile's, named by prefix) and rejects unknown values, which would otherwise
+// quietly act as the defaults.
+func validateProtectionAndInsults(prefix string, protection *Protection, insults *Insults) error {
+	if protection != nil {
+		protection.Level = strings.ToLower(strings.TrimSpace(protection.Level))
+		if protection.Level != "" && !slices.Contains(ProtectionLevels, protection.Level) {
+			return fmt.Errorf("config: %sprotection.level: unknown level %q (use %s)", prefix, protection.Level, strings.Join(ProtectionLevels, ", "))
+		}
+	}
+	if insults != nil {
+		insults.Mode = strings.ToLower(strings.TrimSpace(insults.Mode))
+		if insults.Mode != "" && !slices.Contains(InsultModes, insults.Mode) {
+			return fmt.Errorf("config: %sinsults.mode: unknown mode %q (use %s)", prefix, insults.Mode, strings.Join(InsultModes, ", "))
+		}
+	}
+	return nil
+}
+
+// normalizeCheck validates one check and normalizes its fields. Errors leave out which
+// check it is; callers add that.
+func normalizeCheck(c Check) (Check, error) {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		return c, errors.New("missing name")
+	}
+
+	c.Builtin = strings.TrimSpace(c.Builtin)
+	switch {
+	case c.Builtin != "":
+		if strings.TrimSpace(c.Run) != "" {
+			return c, errors.New("sets both run and builtin")
+		}
+		if strings.TrimSpace(c.Shell) != "" {
+			return c, errors.New("shell is not used by builtin checks")
+		}
+		if BuiltinValidator != nil {
+			if err := BuiltinValidator(c.Builtin, c.With); err != nil {
+				return c, fmt.Errorf("builtin: %w", err)
+			}
+		}
+	case strings.TrimSpace(c.Run) == "":
+		return c, errors.New("missing run")
+	case len(c.With) > 0:
+		return c, errors.New("with is only used by builtin checks")
+	}
+
+	// Config is user-authored: keep shell strict and predictable.
+	// shell must be ONLY the executable name/path (no args).
+	if err := validateShellSpec(c.Shell); err != nil {
+		return c, fmt.Errorf("shell: %w", err)
+	}
+
+	osList, err := normalizeOSList(c.OS, c.Platforms)
+	if err != nil {
+		return c, fmt.Errorf("os: %w", err)
+	}
+
+	requires, err := normalizeStringList(c.Requires)
+	if err != nil {
+		return c, fmt.Errorf("requires: %w", err)
+	}
+
+	tags, err := normalizeStringList(c.Tags)
+	if err != nil {
+		return c, fmt.Errorf("tags: %w", err)
+	}
+
+	paths, err := normalizeStringList(c.Paths)
+	if err != nil {
+		return c, fmt.Errorf("paths: %w", err)
+	}
+	if _, err := CompilePathFilter(paths); err != nil {
+		return c, fmt.Errorf("paths: %w", err)
+	}
+
+	problemMatchers, err := normalizeStringList(c.ProblemMatchers)
+	if err != nil {
+		return c, fmt.Errorf("problemMatchers: %w", err)
+	}
+
+	if c.Output != nil {
+		if c.Builtin != "" {
+			return c, errors.New("output is not used by builtin checks")
+		}
+		c.Output.Format = strings.ToLower(strings.TrimSpace(c.Output.Format))
+		c.Output.File = strings.TrimSpace(c.Output.File)
+		if !slices.Contains(OutputFormats, c.Output.Format) {
+			return c, fmt.Errorf("output.format: unknown format %q (use %s)", c.Output.Format, strings.Join(OutputFormats, ", "))
+		}
+	}
+
+	c.Rerun = strings.TrimSpace(c.Rerun)
+	if c.Rerun != "" {
+		if c.Builtin != "" {
+			return c, errors.New("rerun is not used by builtin checks")
+		}
+		if !strings.Contains(c.Rerun, "{tests}") && !strings.Contains(c.Rerun, "{regex}") && !strings.Contains(c.Rerun, "{filter}") {
+			return c, errors.New("rerun: must use {tests}, {regex} or {filter}")
+		}
+	}
+
+	if c.Timeout < 0 {
+		return c, errors.New("timeout must be >= 0")
+	}
+
+	if err := validateEnvOverrides(c.Env); err != nil {
+		return c, fmt.Errorf("env: %w", err)
+	}
+
+	// Normalize deprecated/legacy fields into canonical ones.
+	c.Name = name
+	c.OS = osList
+	c.Platforms = nil
+	c.Requires = requires
+	c.Tags = tags
+	c.Paths = paths
+	c.ProblemMatchers = problemMatchers
+
+	return c, nil
+}
+
 func validateShellSpec(shellSpec string) error {
 	s := strings.TrimSpace(shellSpec)
 	if s == "" {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -164,3 +165,88 @@ checks:
 		t.Fatalf("expected os validation error, got %v", err)
 	}
 }
+
+func TestParseBuiltinChecks(t *testing.T) {
+	t.Cleanup(func() { BuiltinValidator = nil })
+	var validated []string
+	BuiltinValidator = func(name string, with map[string]any) error {
+		validated = append(validated, name)
+		if _, bad := with["bogus"]; bad {
+			return errors.New("unknown option(s) in with: bogus")
+		}
+		return nil
+	}
+
+	cfg, err := Parse([]byte("checks:\n  - name: secrets\n    builtin: secrets\n    with:\n      entropy: 4\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Checks[0].Builtin != "secrets" || cfg.Checks[0].With["entropy"] != 4 || len(validated) != 1 {
+		t.Fatalf("unexpected builtin check %+v (validated %v)", cfg.Checks[0], validated)
+	}
+
+	for _, doc := range []string{
+		"checks:\n  - name: x\n    run: echo\n    builtin: secrets\n",
+		"checks:\n  - name: x\n    run: echo\n    with:\n      entropy: 4\n",
+		"checks:\n  - name: x\n    builtin: secrets\n    shell: bash\n",
+		"checks:\n  - name: x\n    builtin: secrets\n    with:\n      bogus: true\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("expected error for:\n%s", doc)
+		}
+	}
+}
+
+func TestOutputFormatIsValidatedAndFileExpanded(t *testing.T) {
+	cfg, err := Parse([]byte(`
+checks:
+  - name: test
+    run: pytest --junitxml=report-${{ matrix.py }}.xml
+    matrix:
+      py: ["3.12"]
+    output:
+      format: " JUnit "
+      file: report-${{ matrix.py }}.xml
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := expandMatrixChecks(cfg); err != nil {
+		t.Fatalf("expand matrix: %v", err)
+	}
+	check, err := cfg.ExpandCheck(cfg.Checks[0], Interpolation{})
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	if check.Output.Format != "junit" || check.Output.File != "report-3.12.xml" {
+		t.Fatalf("unexpected output %+v", check.Output)
+	}
+
+	for _, doc := range []string{
+		"checks:\n  - name: x\n    run: x\n    output:\n      format: tap\n",
+		"checks:\n  - name: x\n    builtin: secrets\n    output:\n      format: junit\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil || !strings.Contains(err.Error(), "output") {
+			t.Errorf("expected output error for:\n%s\ngot %v", doc, err)
+		}
+	}
+}
+
+func TestRerunTemplateNeedsATestPlaceholder(t *testing.T) {
+	cfg, err := Parse([]byte("checks:\n  - name: x\n    run: make test\n    rerun: \" make test TESTS={tests} \"\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := cfg.Checks[0].Rerun; got != "make test TESTS={tests}" {
+		t.Fatalf("rerun = %q", got)
+	}
+
+	for _, doc := range []string{
+		"checks:\n  - name: x\n    run: make test\n    rerun: make test\n",
+		"checks:\n  - name: x\n    builtin: secrets\n    rerun: x {tests}\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil || !strings.Contains(err.Error(), "rerun") {
+			t.Errorf("expected rerun error for:\n%s\ngot %v", doc, err)
+		}
+	}
+}
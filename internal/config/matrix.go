@@ -0,0 +1,343 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Matrix expands one check into several, like a GitHub Actions strategy matrix:
+// every combination of the axis values, minus exclude entries, plus include entries.
+//
+//	matrix:
+//	  tags: [unit, integration]
+//	  include:
+//	    - tags: integration
+//	      timeout: 10m      # extra keys are available as ${{ matrix.timeout }}
+//	  exclude:
+//	    - tags: unit
+type Matrix struct {
+	Axes    []MatrixAxis
+	Include []map[string]string
+	Exclude []map[string]string
+}
+
+// MatrixAxis is one matrix key and its values, in the order they were written.
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// MatrixCombo is one expanded combination. Keys holds display order (axes first,
+// then keys added by include entries).
+type MatrixCombo struct {
+	Keys   []string
+	Values map[string]string
+}
+
+func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("matrix: expected mapping, got %s at line %d col %d", yamlKindName(node.Kind), node.Line, node.Column)
+	}
+
+	out := Matrix{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := strings.TrimSpace(node.Content[i].Value)
+		value := node.Content[i+1]
+		switch key {
+		case "include", "exclude":
+			entries, err := decodeMatrixEntries(key, value)
+			if err != nil {
+				return err
+			}
+			if key == "include" {
+				out.Include = entries
+			} else {
+				out.Exclude = entries
+			}
+		default:
+			var values StringList
+			if err := value.Decode(&values); err != nil {
+				return fmt.Errorf("matrix.%s: %w", key, err)
+			}
+			out.Axes = append(out.Axes, MatrixAxis{Name: key, Values: values})
+		}
+	}
+	*m = out
+	return nil
+}
+
+func decodeMatrixEntries(key string, node *yaml.Node) ([]map[string]string, error) {
+	var raw []map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("matrix.%s: expected a list of mappings: %w", key, err)
+	}
+	out := make([]map[string]string, 0, len(raw))
+	for i, entry := range raw {
+		values := make(map[string]string, len(entry))
+		for k, v := range entry {
+			if v.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("matrix.%s[%d].%s: expected a scalar value", key, i, k)
+			}
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v.Value)
+		}
+		out = append(out, values)
+	}
+	return out, nil
+}
+
+func (m Matrix) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, axis := range m.Axes {
+		values := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, v := range axis.Values {
+			values.Content = append(values.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: v})
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: axis.Name}, values)
+	}
+	for _, section := range []struct {
+		key     string
+		entries []map[string]string
+	}{{"include", m.Include}, {"exclude", m.Exclude}} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, entry := range section.entries {
+			item := &yaml.Node{Kind: yaml.MappingNode}
+			for _, k := range sortedKeys(entry) {
+				item.Content = append(item.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+					&yaml.Node{Kind: yaml.ScalarNode, Value: entry[k]})
+			}
+			list.Content = append(list.Content, item)
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section.key}, list)
+	}
+	return node, nil
+}
+
+// Keys returns every key a combination can carry: axis names, then include-only keys (sorted).
+func (m Matrix) Keys() []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, axis := range m.Axes {
+		if !seen[axis.Name] {
+			seen[axis.Name] = true
+			keys = append(keys, axis.Name)
+		}
+	}
+	var extra []string
+	for _, entry := range m.Include {
+		for k := range entry {
+			if !seen[k] {
+				seen[k] = true
+				extra = append(extra, k)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// ExpandMatrix returns the matrix combinations in deterministic order: the cartesian
+// product of the axes (first axis varies slowest), minus excludes, with include entries
+// merged into matching combinations or appended as new ones.
+func ExpandMatrix(m Matrix) []MatrixCombo {
+	combos := []map[string]string{{}}
+	for _, axis := range m.Axes {
+		next := make([]map[string]string, 0, len(combos)*len(axis.Values))
+		for _, combo := range combos {
+			for _, value := range axis.Values {
+				c := make(map[string]string, len(combo)+1)
+				for k, v := range combo {
+					c[k] = v
+				}
+				c[axis.Name] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	if len(m.Axes) == 0 {
+		combos = nil
+	}
+
+	kept := combos[:0]
+	for _, combo := range combos {
+		excluded := false
+		for _, entry := range m.Exclude {
+			if matrixEntryMatches(combo, entry) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, combo)
+		}
+	}
+	combos = kept
+
+	axisNames := map[string]bool{}
+	for _, axis := range m.Axes {
+		axisNames[axis.Name] = true
+	}
+	original := len(combos)
+	for _, entry := range m.Include {
+		matched := false
+		for i := 0; i < original; i++ {
+			if !includeFits(combos[i], entry, axisNames) {
+				continue
+			}
+			matched = true
+			for k, v := range entry {
+				if !axisNames[k] {
+					combos[i][k] = v
+				}
+			}
+		}
+		if !matched {
+			c := make(map[string]string, len(entry))
+			for k, v := range entry {
+				c[k] = v
+			}
+			combos = append(combos, c)
+		}
+	}
+
+	order := m.Keys()
+	out := make([]MatrixCombo, 0, len(combos))
+	for _, combo := range combos {
+		keys := make([]string, 0, len(combo))
+		for _, k := range order {
+			if _, ok := combo[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		out = append(out, MatrixCombo{Keys: keys, Values: combo})
+	}
+	return out
+}
+
+func matrixEntryMatches(combo map[string]string, entry map[string]string) bool {
+	for k, v := range entry {
+		if combo[k] != v {
+			return false
+		}
+	}
+	return len(entry) > 0
+}
+
+// includeFits reports whether an include entry can extend combo without changing any
+// of its original axis values.
+func includeFits(combo map[string]string, entry map[string]string, axisNames map[string]bool) bool {
+	for k, v := range entry {
+		if axisNames[k] && combo[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Label renders the combination as "k=v" pairs joined by sep.
+func (c MatrixCombo) Label(sep string) string {
+	parts := make([]string, 0, len(c.Keys))
+	for _, k := range c.Keys {
+		parts = append(parts, k+"="+c.Values[k])
+	}
+	return strings.Join(parts, sep)
+}
+
+// expandMatrixChecks replaces every check that has a matrix with one check per
+// combination, named "name (k=v, ...)" with ID "id[k=v,...]".
+func expandMatrixChecks(cfg *Config) error {
+	hasMatrix := false
+	for _, check := range cfg.Checks {
+		if check.Matrix != nil {
+			hasMatrix = true
+			break
+		}
+	}
+	if !hasMatrix {
+		return nil
+	}
+
+	out := make([]Check, 0, len(cfg.Checks))
+	for _, check := range cfg.Checks {
+		if check.Matrix == nil {
+			out = append(out, check)
+			continue
+		}
+		for _, combo := range ExpandMatrix(*check.Matrix) {
+			out = append(out, matrixCheck(check, combo))
+		}
+	}
+
+	seen := make(map[string]bool, len(out))
+	for _, check := range out {
+		if seen[check.Name] {
+			return fmt.Errorf("config: matrix expansion produced duplicate check name %q", check.Name)
+		}
+		seen[check.Name] = true
+	}
+	cfg.Checks = out
+	return nil
+}
+
+func matrixCheck(base Check, combo MatrixCombo) Check {
+	out := base
+	out.Matrix = nil
+	out.MatrixParent = base.Name
+	out.MatrixValues = combo.Values
+	out.Name = fmt.Sprintf("%s (%s)", base.Name, combo.Label(", "))
+	stem := strings.TrimSpace(base.ID)
+	if stem == "" {
+		stem = base.Name
+	}
+	out.ID = fmt.Sprintf("%s[%s]", stem, combo.Label(","))
+	return out
+}
+
+// validateMatrix checks a check's matrix shape; it returns the keys ${{ matrix.* }} may use.
+func validateMatrix(m *Matrix) (map[string]bool, error) {
+	if m == nil {
+		return nil, nil
+	}
+	if len(m.Axes) == 0 && len(m.Include) == 0 {
+		return nil, fmt.Errorf("needs at least one axis or include entry")
+	}
+	keys := map[string]bool{}
+	for _, axis := range m.Axes {
+		if !varNamePattern.MatchString(axis.Name) {
+			return nil, fmt.Errorf("invalid axis name %q", axis.Name)
+		}
+		if len(axis.Values) == 0 {
+			return nil, fmt.Errorf("axis %q has no values", axis.Name)
+		}
+		keys[axis.Name] = true
+	}
+	for _, entry := range m.Include {
+		for k := range entry {
+			if !varNamePattern.MatchString(k) {
+				return nil, fmt.Errorf("include: invalid key %q", k)
+			}
+			keys[k] = true
+		}
+	}
+	for _, entry := range m.Exclude {
+		for k := range entry {
+			if !keys[k] {
+				return nil, fmt.Errorf("exclude: unknown key %q", k)
+			}
+		}
+	}
+	if len(ExpandMatrix(*m)) == 0 {
+		return nil, fmt.Errorf("expands to no combinations")
+	}
+	return keys, nil
+}
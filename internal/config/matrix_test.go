@@ -0,0 +1,134 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandMatrixIncludeExclude(t *testing.T) {
+	m := Matrix{
+		Axes: []MatrixAxis{
+			{Name: "os", Values: []string{"linux", "windows"}},
+			{Name: "tags", Values: []string{"unit", "integration"}},
+		},
+		Include: []map[string]string{
+			{"tags": "integration", "timeout": "10m"},
+			{"os": "macos", "tags": "unit"},
+		},
+		Exclude: []map[string]string{
+			{"os": "windows", "tags": "integration"},
+		},
+	}
+
+	var labels []string
+	for _, combo := range ExpandMatrix(m) {
+		labels = append(labels, combo.Label(","))
+	}
+	want := []string{
+		"os=linux,tags=unit",
+		"os=linux,tags=integration,timeout=10m",
+		"os=windows,tags=unit",
+		"os=macos,tags=unit",
+	}
+	if strings.Join(labels, " | ") != strings.Join(want, " | ") {
+		t.Fatalf("unexpected combos:\n got %v\nwant %v", labels, want)
+	}
+}
+
+func TestLoadExpandsMatrixChecks(t *testing.T) {
+	root := t.TempDir()
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	writeConfigFile(t, cfgPath, `
+version: 1
+checks:
+  - name: "tests"
+    id: "go-tests"
+    run: "go test -tags ${{ matrix.tags }} ./..."
+    env:
+      GOFLAGS: "-tags=${{ matrix.tags }}"
+    matrix:
+      tags: [unit, integration]
+  - name: "lint"
+    run: "go vet ./..."
+profiles:
+  ci:
+    checks: [tests]
+`)
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Checks) != 3 {
+		t.Fatalf("expected 2 matrix checks plus lint, got %+v", cfg.Checks)
+	}
+	first := cfg.Checks[1]
+	if first.Name != "tests (tags=integration)" || first.ID != "go-tests[tags=integration]" {
+		t.Fatalf("unexpected expanded check: %q %q", first.Name, first.ID)
+	}
+
+	expanded, err := cfg.ExpandCheck(first, Interpolation{})
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	if expanded.Run != "go test -tags integration ./..." || expanded.Env["GOFLAGS"] != "-tags=integration" {
+		t.Fatalf("unexpected matrix substitution: %q %v", expanded.Run, expanded.Env)
+	}
+
+	ci, err := cfg.ApplyProfile("ci")
+	if err != nil {
+		t.Fatalf("apply profile: %v", err)
+	}
+	if len(ci.Checks) != 2 {
+		t.Fatalf("expected profile to select every combination, got %+v", ci.Checks)
+	}
+
+	file, err := LoadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("load file: %v", err)
+	}
+	if len(file.Checks) != 2 || file.Checks[0].Matrix == nil {
+		t.Fatalf("expected LoadFile to keep the matrix unexpanded, got %+v", file.Checks)
+	}
+}
+
+func TestParseRejectsUnknownMatrixReference(t *testing.T) {
+	content := `
+checks:
+  - name: "tests"
+    run: "go test -tags ${{ matrix.tag }} ./..."
+    matrix:
+      tags: [unit]
+`
+	if _, err := Parse([]byte(content)); err == nil || !strings.Contains(err.Error(), "matrix.tag") {
+		t.Fatalf("expected unknown matrix key error, got %v", err)
+	}
+}
+
+func TestMatrixRoundTripsThroughSave(t *testing.T) {
+	cfg, err := Parse([]byte(`
+checks:
+  - name: "tests"
+    run: "go test -tags ${{ matrix.tags }} ./..."
+    matrix:
+      tags: [unit, integration]
+      exclude:
+        - tags: unit
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	reloaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	m := reloaded.Checks[0].Matrix
+	if m == nil || len(m.Axes) != 1 || len(m.Axes[0].Values) != 2 || len(m.Exclude) != 1 {
+		t.Fatalf("matrix did not round-trip: %+v", m)
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Parser turns lines of a check's output into diagnostics for tools the built-in
+// headline rules don't know. Regex uses named groups: file, line, col, message and
+// rule; it needs at least message or file. A parser applies to the checks it lists
+// and to any check that names it with `parser:`.
+type Parser struct {
+	Name   string     `yaml:"name"`
+	Regex  string     `yaml:"regex"`
+	Checks StringList `yaml:"checks,omitempty"`
+}
+
+// ParserGroups are the named groups a parser regex may use.
+var ParserGroups = []string{"file", "line", "col", "message", "rule"}
+
+// Compile compiles the parser's regex, multi-line mode on, and checks its groups.
+func (p Parser) Compile() (*regexp.Regexp, error) {
+	if strings.TrimSpace(p.Regex) == "" {
+		return nil, fmt.Errorf("regex is required")
+	}
+	re, err := regexp.Compile("(?m)" + p.Regex)
+	if err != nil {
+		return nil, err
+	}
+	useful := false
+	for _, group := range re.SubexpNames()[1:] {
+		switch group {
+		case "":
+		case "file", "message":
+			useful = true
+		case "line", "col", "rule":
+		default:
+			return nil, fmt.Errorf("unknown group %q (use %s)", group, strings.Join(ParserGroups, ", "))
+		}
+	}
+	if !useful {
+		return nil, fmt.Errorf("regex needs a (?P<message>...) or (?P<file>...) group")
+	}
+	return re, nil
+}
+
+// ParsersFor returns the parsers that apply to a check, in config order: the one it
+// names with `parser:` first, then those that list it (by name, or by the name of the
+// matrix check it was expanded from).
+func (c *Config) ParsersFor(check Check) []Parser {
+	var out []Parser
+	named := strings.TrimSpace(check.Parser)
+	for _, parser := range c.Parsers {
+		if parser.Name == named {
+			out = append(out, parser)
+		}
+	}
+	for _, parser := range c.Parsers {
+		if parser.Name == named {
+			continue
+		}
+		for _, name := range parser.Checks {
+			if name == check.Name || (check.MatrixParent != "" && name == check.MatrixParent) {
+				out = append(out, parser)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// validateParsers normalizes the parsers, test-compiles their regexes and checks the
+// references between parsers and checks. References are only checked when strict
+// (a composed file may refer to parsers or checks from the files it pulls in).
+func validateParsers(cfg *Config, strict bool) error {
+	seen := make(map[string]int, len(cfg.Parsers))
+	for i := range cfg.Parsers {
+		parser := cfg.Parsers[i]
+		parser.Name = strings.TrimSpace(parser.Name)
+		if parser.Name == "" {
+			return fmt.Errorf("config: parsers[%d] missing name", i)
+		}
+		if prev, exists := seen[parser.Name]; exists {
+			return fmt.Errorf("config: parsers[%d] name %q duplicates parsers[%d]", i, parser.Name, prev)
+		}
+		seen[parser.Name] = i
+		if _, err := parser.Compile(); err != nil {
+			return fmt.Errorf("config: parsers.%s: %w", parser.Name, err)
+		}
+		checks, err := normalizeStringList(parser.Checks)
+		if err != nil {
+			return fmt.Errorf("config: parsers.%s checks: %w", parser.Name, err)
+		}
+		if strict {
+			for _, name := range checks {
+				if !anyCheckNamed(cfg.Checks, name) {
+					return fmt.Errorf("config: parsers.%s checks: unknown check %q", parser.Name, name)
+				}
+			}
+		}
+		parser.Checks = checks
+		cfg.Parsers[i] = parser
+	}
+
+	for i := range cfg.Checks {
+		name := strings.TrimSpace(cfg.Checks[i].Parser)
+		cfg.Checks[i].Parser = name
+		if _, exists := seen[name]; strict && name != "" && !exists {
+			return fmt.Errorf("config: checks[%d] parser: unknown parser %q", i, name)
+		}
+	}
+	return nil
+}
+
+func anyCheckNamed(checks []Check, name string) bool {
+	for _, check := range checks {
+		if check.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const parsersConfig = `
+parsers:
+  - name: acme
+    regex: '^ACME (?P<rule>[A-Z]+\d+) (?P<file>[^:]+):(?P<line>\d+) (?P<message>.+)$'
+    checks: ["lint"]
+  - name: plain
+    regex: '^oops: (?P<message>.+)$'
+checks:
+  - name: lint
+    run: acme-lint
+    parser: plain
+  - name: test
+    run: acme-test
+    parser: acme
+`
+
+func TestParsersApplyByNameAndByCheckList(t *testing.T) {
+	cfg, err := Parse([]byte(parsersConfig))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	names := func(parsers []Parser) string {
+		var out []string
+		for _, parser := range parsers {
+			out = append(out, parser.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names(cfg.ParsersFor(cfg.Checks[0])); got != "plain,acme" {
+		t.Fatalf("lint parsers = %q, want the named parser first", got)
+	}
+	if got := names(cfg.ParsersFor(cfg.Checks[1])); got != "acme" {
+		t.Fatalf("test parsers = %q", got)
+	}
+	if got := names(cfg.ParsersFor(Check{Name: "lint (go=1.22)", MatrixParent: "lint"})); got != "acme" {
+		t.Fatalf("matrix check parsers = %q", got)
+	}
+}
+
+func TestParsersRejectBadDefinitions(t *testing.T) {
+	for _, tc := range []struct{ doc, want string }{
+		{"parsers:\n  - name: x\n    regex: '(?P<message>'\nchecks:\n  - name: a\n    run: a\n", "parsers.x"},
+		{"parsers:\n  - name: x\n    regex: '^(?P<line>\\d+)$'\nchecks:\n  - name: a\n    run: a\n", "message"},
+		{"parsers:\n  - name: x\n    regex: '(?P<msg>.+)'\nchecks:\n  - name: a\n    run: a\n", `unknown group "msg"`},
+		{"parsers:\n  - name: x\n    regex: '(?P<message>.+)'\n  - name: x\n    regex: '(?P<file>.+)'\nchecks:\n  - name: a\n    run: a\n", "duplicates"},
+		{"parsers:\n  - name: x\n    regex: '(?P<message>.+)'\n    checks: [nope]\nchecks:\n  - name: a\n    run: a\n", `unknown check "nope"`},
+		{"checks:\n  - name: a\n    run: a\n    parser: nope\n", `unknown parser "nope"`},
+	} {
+		_, err := Parse([]byte(tc.doc))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v for:\n%s", tc.want, err, tc.doc)
+		}
+	}
+}
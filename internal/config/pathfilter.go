@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PathFilter decides whether a changed file is relevant to a check with `paths:`.
+//
+// Pattern syntax:
+//   - globs match repo-relative slash paths; "*" and "?" stay inside one directory,
+//     "**" spans directories, "{a,b}" picks alternatives, "[...]" is a character class
+//   - a glob without "/" matches the file name at any depth ("*.go")
+//   - "re:<expr>" is a regular expression searched anywhere in the path
+//   - a leading "!" excludes matching files
+//
+// A file matches when it matches any include (or there are only excludes) and no exclude.
+type PathFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// CompilePathFilter parses patterns; an empty list yields a filter that matches everything.
+func CompilePathFilter(patterns []string) (PathFilter, error) {
+	var filter PathFilter
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		negate := false
+		if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+			negate = true
+			pattern = strings.TrimSpace(rest)
+		}
+		if pattern == "" {
+			return PathFilter{}, fmt.Errorf("empty pattern %q", raw)
+		}
+
+		var re *regexp.Regexp
+		var err error
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			re, err = regexp.Compile(expr)
+		} else {
+			re, err = globRegexp(pattern)
+		}
+		if err != nil {
+			return PathFilter{}, fmt.Errorf("pattern %q: %w", raw, err)
+		}
+
+		if negate {
+			filter.exclude = append(filter.exclude, re)
+		} else {
+			filter.include = append(filter.include, re)
+		}
+	}
+	return filter, nil
+}
+
+// Match reports whether file (a repo-relative path) passes the filter.
+func (f PathFilter) Match(file string) bool {
+	file = strings.TrimPrefix(path.Clean(strings.ReplaceAll(file, `\`, "/")), "./")
+	for _, re := range f.exclude {
+		if re.MatchString(file) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny reports whether any of files passes the filter.
+func (f PathFilter) MatchAny(files []string) bool {
+	for _, file := range files {
+		if f.Match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp translates a glob into an anchored regular expression.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimPrefix(glob, "./")
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(strings.TrimSuffix(glob, "/"), "/") {
+		b.WriteString("(?:.*/)?")
+	} else {
+		glob = strings.TrimPrefix(glob, "/")
+	}
+
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch ch {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			braces++
+			b.WriteString("(?:")
+		case '}':
+			if braces == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			braces--
+			b.WriteString(")")
+		case ',':
+			if braces > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	if braces != 0 {
+		return nil, fmt.Errorf("unterminated {")
+	}
+	// A directory pattern ("docs/") covers everything below it.
+	if strings.HasSuffix(glob, "/") {
+		b.WriteString(".*")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
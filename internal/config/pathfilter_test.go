@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestPathFilterMatch(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		file     string
+		want     bool
+	}{
+		{[]string{"*.go"}, "main.go", true},
+		{[]string{"*.go"}, "internal/config/load.go", true},
+		{[]string{"*.go"}, "README.md", false},
+		{[]string{"src/*.ts"}, "src/app.ts", true},
+		{[]string{"src/*.ts"}, "src/lib/app.ts", false},
+		{[]string{"src/**/*.ts"}, "src/app.ts", true},
+		{[]string{"src/**/*.ts"}, "src/lib/deep/app.ts", true},
+		{[]string{"*.{js,ts}"}, "web/index.ts", true},
+		{[]string{"*.{js,ts}"}, "web/index.css", false},
+		{[]string{"docs/"}, "docs/guide/intro.md", true},
+		{[]string{"file?.[ch]"}, "lib/file1.c", true},
+		{[]string{`re:\.py$`}, "tools/gen.py", true},
+		{[]string{`re:^tools/`}, "src/tools/gen.py", false},
+		{[]string{"*.go", "!*_test.go"}, "runner/run_test.go", false},
+		{[]string{"*.go", "!*_test.go"}, "runner/run.go", true},
+		{[]string{"!vendor/**"}, "vendor/x/y.go", false},
+		{[]string{"!vendor/**"}, "main.go", true},
+		{nil, "anything", true},
+	}
+	for _, tc := range cases {
+		filter, err := CompilePathFilter(tc.patterns)
+		if err != nil {
+			t.Fatalf("compile %v: %v", tc.patterns, err)
+		}
+		if got := filter.Match(tc.file); got != tc.want {
+			t.Errorf("%v match %q = %v, want %v", tc.patterns, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestValidateRejectsBadPaths(t *testing.T) {
+	for _, paths := range []StringList{{"*.{go"}, {"re:("}, {"!"}} {
+		cfg := &Config{Version: 1, Checks: []Check{{Name: "lint", Run: "golangci-lint run", Paths: paths}}}
+		if err := validateAndDefault(cfg); err == nil {
+			t.Errorf("expected error for paths %v", paths)
+		}
+	}
+}
@@ -13,7 +13,9 @@ import (
 const (
 	ConfigDirName     = ".buildbouncer"
 	ConfigFileName    = "config.yaml"
+	LocalConfigName   = "config.local.yaml"
 	LegacyConfigName  = ".buildbouncer.yaml"
+	LegacyLocalName   = ".buildbouncer.local.yaml"
 	DefaultAssetsDir  = "assets"
 	DefaultInsultsRel = "assets/insults/default.json"
 	DefaultBanterRel  = "assets/banter/default.json"
@@ -31,6 +33,17 @@ func LegacyConfigPath(root string) string {
 	return filepath.Join(root, LegacyConfigName)
 }
 
+// LocalConfigPath returns the untracked per-developer overlay for a config file:
+// .buildbouncer/config.local.yaml next to config.yaml, or .buildbouncer.local.yaml
+// next to the legacy .buildbouncer.yaml.
+func LocalConfigPath(cfgPath string) string {
+	dir := filepath.Dir(cfgPath)
+	if filepath.Base(cfgPath) == LegacyConfigName {
+		return filepath.Join(dir, LegacyLocalName)
+	}
+	return filepath.Join(dir, LocalConfigName)
+}
+
 func DefaultAssetsPath(root string) string {
 	return filepath.Join(root, ConfigDirName, DefaultAssetsDir)
 }
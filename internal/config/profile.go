@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HasProfile reports whether a profile with the given name is configured.
+func (c *Config) HasProfile(name string) bool {
+	if c == nil || len(c.Profiles) == 0 {
+		return false
+	}
+	_, ok := c.Profiles[strings.TrimSpace(name)]
+	return ok
+}
+
+// ProfileNames returns configured profile names in sorted order.
+func (c *Config) ProfileNames() []string {
+	if c == nil || len(c.Profiles) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile returns a copy of the config narrowed to the checks selected by
+// the named profile, with the profile's runner/protection/insults overrides applied.
+// The receiver is not modified.
+func (c *Config) ApplyProfile(name string) (*Config, error) {
+	name = strings.TrimSpace(name)
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := c.ProfileNames()
+		if len(available) == 0 {
+			return nil, fmt.Errorf("config: unknown profile %q (no profiles configured)", name)
+		}
+		return nil, fmt.Errorf("config: unknown profile %q (available: %s)", name, strings.Join(available, ", "))
+	}
+
+	out := *c
+	out.Checks = nil
+	for _, check := range c.Checks {
+		if profileSelects(profile, check) {
+			out.Checks = append(out.Checks, check)
+		}
+	}
+	if len(out.Checks) == 0 {
+		return nil, fmt.Errorf("config: profile %q selects no checks", name)
+	}
+
+	if profile.Runner != nil {
+		if profile.Runner.MaxParallel > 0 {
+			out.Runner.MaxParallel = profile.Runner.MaxParallel
+		}
+		if profile.Runner.FailFast != nil {
+			failFast := *profile.Runner.FailFast
+			out.Runner.FailFast = &failFast
+		}
+	}
+	if profile.Protection != nil {
+		if strings.TrimSpace(profile.Protection.Level) != "" {
+			out.Protection.Level = strings.TrimSpace(profile.Protection.Level)
+		}
+		if profile.Protection.Interactive != nil {
+			interactive := *profile.Protection.Interactive
+			out.Protection.Interactive = &interactive
+		}
+		if len(profile.Protection.CriticalPatterns) > 0 {
+			out.Protection.CriticalPatterns = append([]string{}, profile.Protection.CriticalPatterns...)
+		}
+	}
+	if profile.Insults != nil {
+		if strings.TrimSpace(profile.Insults.Mode) != "" {
+			out.Insults.Mode = strings.TrimSpace(profile.Insults.Mode)
+		}
+		if strings.TrimSpace(profile.Insults.File) != "" {
+			out.Insults.File = strings.TrimSpace(profile.Insults.File)
+		}
+		if strings.TrimSpace(profile.Insults.Locale) != "" {
+			out.Insults.Locale = strings.TrimSpace(profile.Insults.Locale)
+		}
+	}
+
+	return &out, nil
+}
+
+// profileSelects reports whether a check is part of a profile.
+// A profile without tag or check selectors selects everything.
+func profileSelects(profile Profile, check Check) bool {
+	if len(profile.Tags) == 0 && len(profile.Checks) == 0 {
+		return true
+	}
+	for _, selector := range profile.Checks {
+		if checkMatchesSelector(check, selector) {
+			return true
+		}
+	}
+	for _, tag := range profile.Tags {
+		for _, checkTag := range check.Tags {
+			if strings.EqualFold(strings.TrimSpace(checkTag), strings.TrimSpace(tag)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SelectChecks returns a copy of the config keeping only the checks that match one of
+// selectors (check IDs or names, as in profiles). Every selector must match something.
+func (c *Config) SelectChecks(selectors []string) (*Config, error) {
+	out := *c
+	out.Checks = nil
+	for _, selector := range selectors {
+		if !anyCheckMatches(c.Checks, selector) {
+			return nil, fmt.Errorf("config: unknown check %q", strings.TrimSpace(selector))
+		}
+	}
+	for _, check := range c.Checks {
+		for _, selector := range selectors {
+			if checkMatchesSelector(check, selector) {
+				out.Checks = append(out.Checks, check)
+				break
+			}
+		}
+	}
+	return &out, nil
+}
+
+func checkMatchesSelector(check Check, selector string) bool {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return false
+	}
+	if id := strings.TrimSpace(check.ID); id != "" && (id == selector || strings.HasPrefix(id, selector+"[")) {
+		return true
+	}
+	// Selecting a matrix check selects every combination it expands to.
+	if check.MatrixParent != "" && check.MatrixParent == selector {
+		return true
+	}
+	return strings.TrimSpace(check.Name) == selector
+}
+
+// validateProfiles normalizes profile selectors. When strict is false (the file
+// inherits checks from elsewhere), unknown check selectors are left for Load to catch.
+func validateProfiles(cfg *Config, strict bool) error {
+	if len(cfg.Profiles) == 0 {
+		return nil
+	}
+
+	for _, rawName := range cfg.ProfileNames() {
+		profile := cfg.Profiles[rawName]
+		name := strings.TrimSpace(rawName)
+		if name == "" {
+			return fmt.Errorf("config: profiles contains an empty name")
+		}
+
+		tags, err := normalizeStringList(profile.Tags)
+		if err != nil {
+			return fmt.Errorf("config: profiles.%s tags: %w", name, err)
+		}
+		selectors, err := normalizeStringList(profile.Checks)
+		if err != nil {
+			return fmt.Errorf("config: profiles.%s checks: %w", name, err)
+		}
+		if strict {
+			for _, selector := range selectors {
+				if !anyCheckMatches(cfg.Checks, selector) {
+					return fmt.Errorf("config: profiles.%s checks: unknown check %q", name, selector)
+				}
+			}
+		}
+		if profile.Runner != nil && profile.Runner.MaxParallel < 0 {
+			return fmt.Errorf("config: profiles.%s runner.maxParallel must be >= 0", name)
+		}
+		if err := validateProtectionAndInsults("profiles."+name+".", profile.Protection, profile.Insults); err != nil {
+			return err
+		}
+
+		profile.Tags = tags
+		profile.Checks = selectors
+
+		if name != rawName {
+			delete(cfg.Profiles, rawName)
+		}
+		cfg.Profiles[name] = profile
+	}
+
+	return nil
+}
+
+func anyCheckMatches(checks []Check, selector string) bool {
+	for _, check := range checks {
+		if checkMatchesSelector(check, selector) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const profileConfig = `
+version: 1
+checks:
+  - name: "lint"
+    id: "lint"
+    run: "go vet ./..."
+    tags: [fast]
+  - name: "tests"
+    run: "go test ./..."
+    tags: [fast, tests]
+  - name: "race"
+    run: "go test -race ./..."
+    tags: slow
+runner:
+  maxParallel: 2
+profiles:
+  hook:
+    tags: [fast]
+  ci:
+    checks: [lint, race]
+    runner:
+      maxParallel: 8
+      failFast: true
+    protection:
+      level: strict
+    insults:
+      mode: polite
+  release: {}
+`
+
+func TestApplyProfileSelectsByTag(t *testing.T) {
+	cfg, err := Parse([]byte(profileConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	hook, err := cfg.ApplyProfile("hook")
+	if err != nil {
+		t.Fatalf("apply hook profile: %v", err)
+	}
+	if len(hook.Checks) != 2 || hook.Checks[0].Name != "lint" || hook.Checks[1].Name != "tests" {
+		t.Fatalf("expected lint+tests, got %+v", hook.Checks)
+	}
+	if hook.Runner.MaxParallel != 2 {
+		t.Fatalf("expected base runner settings to remain, got %+v", hook.Runner)
+	}
+	if len(cfg.Checks) != 3 {
+		t.Fatalf("expected base config to be unchanged, got %d checks", len(cfg.Checks))
+	}
+}
+
+func TestApplyProfileSelectsByIDAndOverrides(t *testing.T) {
+	cfg, err := Parse([]byte(profileConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	ci, err := cfg.ApplyProfile("ci")
+	if err != nil {
+		t.Fatalf("apply ci profile: %v", err)
+	}
+	if len(ci.Checks) != 2 || ci.Checks[0].Name != "lint" || ci.Checks[1].Name != "race" {
+		t.Fatalf("expected lint+race, got %+v", ci.Checks)
+	}
+	if ci.Runner.MaxParallel != 8 || !ci.Runner.IsFailFast() {
+		t.Fatalf("expected runner overrides, got %+v", ci.Runner)
+	}
+	if ci.Protection.ProtectionLevel() != "strict" || ci.Protection.IsInteractive() {
+		t.Fatalf("expected strict protection, got %+v", ci.Protection)
+	}
+	if ci.Insults.Mode != "polite" || ci.Insults.Locale != "en" {
+		t.Fatalf("expected insult mode override only, got %+v", ci.Insults)
+	}
+	if cfg.Protection.ProtectionLevel() != "moderate" {
+		t.Fatalf("expected base protection unchanged, got %q", cfg.Protection.Level)
+	}
+}
+
+func TestApplyProfileWithoutSelectorsKeepsAllChecks(t *testing.T) {
+	cfg, err := Parse([]byte(profileConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	release, err := cfg.ApplyProfile("release")
+	if err != nil {
+		t.Fatalf("apply release profile: %v", err)
+	}
+	if len(release.Checks) != 3 {
+		t.Fatalf("expected all checks, got %d", len(release.Checks))
+	}
+}
+
+func TestApplyProfileUnknown(t *testing.T) {
+	cfg, err := Parse([]byte(profileConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	if _, err := cfg.ApplyProfile("nightly"); err == nil || !strings.Contains(err.Error(), "available: ci, hook, release") {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+}
+
+func TestParseRejectsProfileWithUnknownCheck(t *testing.T) {
+	content := `
+version: 1
+checks:
+  - name: "lint"
+    run: "go vet ./..."
+profiles:
+  ci:
+    checks: [tests]
+`
+	if _, err := Parse([]byte(content)); err == nil || !strings.Contains(err.Error(), `unknown check "tests"`) {
+		t.Fatalf("expected unknown check error, got %v", err)
+	}
+}
+
+func TestProfileCanTurnFailFastOff(t *testing.T) {
+	cfg, err := Parse([]byte(`
+checks:
+  - name: "lint"
+    run: "go vet ./..."
+runner:
+  failFast: true
+profiles:
+  hook:
+    runner:
+      failFast: false
+  ci: {}
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	hook, err := cfg.ApplyProfile("hook")
+	if err != nil {
+		t.Fatalf("apply hook profile: %v", err)
+	}
+	if hook.Runner.IsFailFast() {
+		t.Fatalf("expected the hook profile to turn fail-fast off")
+	}
+	ci, err := cfg.ApplyProfile("ci")
+	if err != nil {
+		t.Fatalf("apply ci profile: %v", err)
+	}
+	if !ci.Runner.IsFailFast() {
+		t.Fatalf("expected a profile without runner overrides to keep fail-fast")
+	}
+}
+
+func TestProfileProtectionLevelAndInsultModeAreValidated(t *testing.T) {
+	for _, tc := range []struct{ doc, want string }{
+		{"protection:\n  level: stirct\n", `protection.level: unknown level "stirct"`},
+		{"insults:\n  mode: rude\n", `insults.mode: unknown mode "rude"`},
+		{"profiles:\n  ci:\n    protection:\n      level: stirct\n", `profiles.ci.protection.level: unknown level "stirct"`},
+		{"profiles:\n  ci:\n    insults:\n      mode: rude\n", `profiles.ci.insults.mode: unknown mode "rude"`},
+	} {
+		_, err := Parse([]byte("checks:\n  - name: lint\n    run: go vet ./...\n" + tc.doc))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected %q for:\n%s\ngot %v", tc.want, tc.doc, err)
+		}
+	}
+
+	cfg, err := Parse([]byte("checks:\n  - name: lint\n    run: go vet ./...\nprofiles:\n  ci:\n    protection:\n      level: \" Strict \"\n"))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if level := cfg.Profiles["ci"].Protection.Level; level != "strict" {
+		t.Fatalf("expected the level to be normalized, got %q", level)
+	}
+}
@@ -3,20 +3,28 @@ package config
 import "time"
 
 type Config struct {
-	Version    int        `yaml:"version"`
-	Meta       Meta       `yaml:"meta,omitempty"`
-	Checks     []Check    `yaml:"checks"`
-	Runner     Runner     `yaml:"runner,omitempty"`
-	Protection Protection `yaml:"protection,omitempty"`
-	Insults    Insults    `yaml:"insults"`
-	Banter     Banter     `yaml:"banter"`
+	Version    int                `yaml:"version"`
+	Extends    string             `yaml:"extends,omitempty"`
+	Include    StringList         `yaml:"include,omitempty"`
+	Meta       Meta               `yaml:"meta,omitempty"`
+	Vars       map[string]string  `yaml:"vars,omitempty"`
+	Checks     []Check            `yaml:"checks"`
+	Parsers    []Parser           `yaml:"parsers,omitempty"`
+	Runner     Runner             `yaml:"runner,omitempty"`
+	Protection Protection         `yaml:"protection,omitempty"`
+	Insults    Insults            `yaml:"insults"`
+	Banter     Banter             `yaml:"banter"`
+	Profiles   map[string]Profile `yaml:"profiles,omitempty"`
+
+	// Sources lists every file merged into this config (base first) after composition.
+	Sources []string `yaml:"-"`
 }
 
 type Check struct {
 	ID        string            `yaml:"id,omitempty"`
 	Source    string            `yaml:"source,omitempty"`
 	Name      string            `yaml:"name"`
-	Run       string            `yaml:"run"`
+	Run       string            `yaml:"run,omitempty"`
 	Shell     string            `yaml:"shell,omitempty"`
 	Cwd       string            `yaml:"cwd,omitempty"`
 	Env       map[string]string `yaml:"env,omitempty"`
@@ -24,11 +32,85 @@ type Check struct {
 	Platforms StringList        `yaml:"platforms,omitempty"`
 	Requires  StringList        `yaml:"requires,omitempty"`
 	Timeout   time.Duration     `yaml:"timeout,omitempty"`
+	Tags      StringList        `yaml:"tags,omitempty"`
+	Matrix    *Matrix           `yaml:"matrix,omitempty"`
+	Note      string            `yaml:"note,omitempty"`
+
+	// Fix repairs what the check complains about (a formatter without --check). It runs
+	// like Run, with the check's shell, cwd and env, from `build-bouncer fix`.
+	Fix string `yaml:"fix,omitempty"`
+
+	// Rerun is the command `build-bouncer rerun --failed-tests` runs to repeat only the
+	// tests that failed, with {run}, {tests}, {regex} and {filter} filled in. Empty
+	// means the built-in template for the test runner the output came from.
+	Rerun string `yaml:"rerun,omitempty"`
+
+	// Parser names an entry of `parsers:` to read this check's output with, ahead of
+	// the built-in headline rules.
+	Parser string `yaml:"parser,omitempty"`
+
+	// ProblemMatchers are GitHub problem matcher files (.github/*-matcher.json) to read
+	// the check's full log with when it fails.
+	ProblemMatchers StringList `yaml:"problemMatchers,omitempty"`
+
+	// Output says the check's results are machine-readable (go test -json, JUnit XML,
+	// ...) so the runner can report tests and diagnostics instead of scraping text.
+	Output *Output `yaml:"output,omitempty"`
+
+	// Paths limits the check to pushes that change a matching file (globs; "re:" for a
+	// regular expression, "!" to exclude). Empty means the check always runs.
+	Paths StringList `yaml:"paths,omitempty"`
+
+	// Builtin runs one of build-bouncer's in-process checks (secrets, ...) instead of
+	// Run; With holds its options.
+	Builtin string         `yaml:"builtin,omitempty"`
+	With    map[string]any `yaml:"with,omitempty"`
+
+	// Origin is the file this check was defined in (set by Load; never serialized).
+	Origin string `yaml:"-"`
+
+	// MatrixParent and MatrixValues are set on checks expanded from a matrix by Load.
+	MatrixParent string            `yaml:"-"`
+	MatrixValues map[string]string `yaml:"-"`
+}
+
+// Output describes a check's structured results: their Format and, when the tool
+// writes them to a file instead of stdout, the File (relative to the check's cwd).
+type Output struct {
+	Format string `yaml:"format"`
+	File   string `yaml:"file,omitempty"`
 }
 
+// OutputFormats are the formats `output.format` accepts.
+var OutputFormats = []string{"gotest-json", "cargo-json", "eslint-json", "golangci-json", "ruff-json", "junit"}
+
 type Runner struct {
-	MaxParallel int  `yaml:"maxParallel,omitempty"`
-	FailFast    bool `yaml:"failFast,omitempty"`
+	MaxParallel int   `yaml:"maxParallel,omitempty"`
+	FailFast    *bool `yaml:"failFast,omitempty"`
+}
+
+// IsFailFast returns whether the first failure should cancel the remaining checks.
+func (r Runner) IsFailFast() bool {
+	return r.FailFast != nil && *r.FailFast
+}
+
+// Profile narrows the check list and overrides settings for one context
+// (local, hook, ci, release, ...). Checks are selected by tag or by ID/name;
+// a profile without selectors keeps every check. Overrides only replace the
+// fields they set.
+type Profile struct {
+	Tags       StringList     `yaml:"tags,omitempty"`
+	Checks     StringList     `yaml:"checks,omitempty"`
+	Runner     *ProfileRunner `yaml:"runner,omitempty"`
+	Protection *Protection    `yaml:"protection,omitempty"`
+	Insults    *Insults       `yaml:"insults,omitempty"`
+}
+
+// ProfileRunner overrides runner settings for a profile. FailFast is a pointer so a
+// profile can turn a base `failFast: true` off as well as on.
+type ProfileRunner struct {
+	MaxParallel int   `yaml:"maxParallel,omitempty"`
+	FailFast    *bool `yaml:"failFast,omitempty"`
 }
 
 type Meta struct {
@@ -40,6 +122,9 @@ type TemplateMeta struct {
 	ID string `yaml:"id,omitempty"`
 }
 
+// InsultModes are the values insults.mode accepts.
+var InsultModes = []string{"polite", "snarky", "nuclear"}
+
 type Insults struct {
 	Mode   string `yaml:"mode"`   // polite | snarky | nuclear
 	File   string `yaml:"file"`   // .buildbouncer/assets/insults/default.json
@@ -77,8 +162,14 @@ type Protection struct {
 	// CriticalPatterns are regex patterns to identify critical failures (build errors, compilation failures)
 	// Used by 'lax' mode to determine if a failure is severe enough to block
 	CriticalPatterns []string `yaml:"criticalPatterns,omitempty"`
+
+	// Locked stops config.local.yaml from changing protection settings or redefining team checks
+	Locked bool `yaml:"locked,omitempty"`
 }
 
+// ProtectionLevels are the values protection.level accepts, least strict first.
+var ProtectionLevels = []string{"lax", "moderate", "strict"}
+
 // ProtectionLevel returns the configured protection level, defaulting to "moderate"
 func (p Protection) ProtectionLevel() string {
 	if p.Level == "" {
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CurrentBranch returns the checked-out branch for the repo at root by reading HEAD.
+// It returns "" for a detached HEAD or when root is not a git repository.
+func CurrentBranch(root string) string {
+	gitDir, ok := gitDirFor(root)
+	if !ok {
+		return ""
+	}
+	b, err := readFilePrefix(filepath.Join(gitDir, "HEAD"), 4096)
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(b))
+	ref, ok := strings.CutPrefix(head, "ref:")
+	if !ok {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(ref), "refs/heads/")
+}
+
+// gitDirFor resolves the git directory for root, following the "gitdir: <path>" pointer
+// that worktrees and submodules use.
+func gitDirFor(root string) (string, bool) {
+	dotGit := filepath.Join(root, ".git")
+	st, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if st.IsDir() {
+		return dotGit, true
+	}
+
+	b, err := readFilePrefix(dotGit, 4096)
+	if err != nil {
+		return "", false
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(string(b)), "\n")
+	first = strings.TrimSpace(first)
+	const prefix = "gitdir:"
+	if !strings.HasPrefix(strings.ToLower(first), prefix) {
+		return "", false
+	}
+	dir := strings.TrimSpace(first[len(prefix):])
+	if dir == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return filepath.Clean(dir), true
+}
@@ -0,0 +1,473 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PushRefsEnv carries the pre-push hook's stdin ("<local ref> <local sha> <remote ref>
+// <remote sha>" per line) from the hook script to `check --hook`.
+const PushRefsEnv = "BUILDBOUNCER_PUSH_REFS"
+
+// ChangedFiles lists the repo-relative files a push would change. pushRefs is the
+// pre-push hook input; when it is empty the push is assumed to be HEAD onto its upstream,
+// plus any uncommitted changes. ok is false when the set cannot be worked out (no git,
+// no upstream, unknown commits), in which case callers should run everything.
+//
+// Like every helper in this file it shells out to `git`: diffing needs the object database.
+func ChangedFiles(root string, pushRefs string) (files []string, ok bool) {
+	seen := map[string]struct{}{}
+	add := func(names []string) {
+		for _, name := range names {
+			seen[name] = struct{}{}
+		}
+	}
+
+	if strings.TrimSpace(pushRefs) != "" {
+		for _, u := range parsePushRefs(pushRefs) {
+			var names []string
+			var err error
+			if u.newBranch() {
+				// New remote branch: everything not already on some remote.
+				names, err = gitLines(root, "log", "--name-only", "--format=", u.localSHA, "--not", "--remotes")
+			} else {
+				names, err = gitLines(root, "diff", "--name-only", u.remoteSHA, u.localSHA)
+			}
+			if err != nil {
+				return nil, false
+			}
+			add(names)
+		}
+		return sortedSet(seen), true
+	}
+
+	committed, err := gitLines(root, "diff", "--name-only", "@{upstream}...HEAD")
+	if err != nil {
+		return nil, false
+	}
+	add(committed)
+	uncommitted, err := gitLines(root, "diff", "--name-only", "HEAD")
+	if err != nil {
+		return nil, false
+	}
+	add(uncommitted)
+	return sortedSet(seen), true
+}
+
+// PushDiff returns the zero-context patch (`git diff -U0`) of what a push would add,
+// worked out the same way as ChangedFiles. Without pushRefs it diffs the working tree
+// against the merge base with the upstream, so uncommitted changes are included.
+func PushDiff(root string, pushRefs string) (patch string, ok bool) {
+	diffArgs := []string{"-U0", "--no-color", "--no-ext-diff", "--no-renames"}
+	if strings.TrimSpace(pushRefs) != "" {
+		var out strings.Builder
+		for _, u := range parsePushRefs(pushRefs) {
+			var text string
+			var err error
+			if u.newBranch() {
+				text, err = gitOutput(root, append(append([]string{"log", "-p", "--format="}, diffArgs...), u.localSHA, "--not", "--remotes")...)
+			} else {
+				text, err = gitOutput(root, append(append([]string{"diff"}, diffArgs...), u.remoteSHA, u.localSHA)...)
+			}
+			if err != nil {
+				return "", false
+			}
+			out.WriteString(text)
+		}
+		return out.String(), true
+	}
+
+	base, err := gitLines(root, "merge-base", "@{upstream}", "HEAD")
+	if err != nil || len(base) == 0 {
+		return "", false
+	}
+	text, err := gitOutput(root, append(append([]string{"diff"}, diffArgs...), base[0])...)
+	if err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// Commit is one commit a push would publish.
+type Commit struct {
+	SHA     string
+	Branch  string // the branch it is pushed to; "" for tags and a detached HEAD
+	Message string // the full message, trailing newlines trimmed
+}
+
+// PushCommits lists the non-merge commits a push would publish, oldest first, worked
+// out the same way as ChangedFiles: for a new remote branch, the commits no remote has
+// yet. Without pushRefs it is HEAD's commits that are not on its upstream, or not on any
+// remote when there is no upstream. ok is false when that cannot be worked out (no git,
+// unknown commits, a repo with no remotes).
+func PushCommits(root string, pushRefs string) (commits []Commit, ok bool) {
+	seen := map[string]bool{}
+	add := func(branch string, args ...string) bool {
+		out, err := gitOutput(root, append([]string{"log", "-z", "--reverse", "--no-merges", "--format=%H%n%B"}, args...)...)
+		if err != nil {
+			return false
+		}
+		for _, record := range strings.Split(out, "\x00") {
+			sha, message, _ := strings.Cut(strings.TrimLeft(record, "\n"), "\n")
+			if sha == "" || seen[sha] {
+				continue
+			}
+			seen[sha] = true
+			commits = append(commits, Commit{SHA: sha, Branch: branch, Message: strings.TrimRight(message, "\n")})
+		}
+		return true
+	}
+
+	if strings.TrimSpace(pushRefs) != "" {
+		for _, u := range parsePushRefs(pushRefs) {
+			args := []string{u.remoteSHA + ".." + u.localSHA}
+			if u.newBranch() {
+				args = []string{u.localSHA, "--not", "--remotes"}
+			}
+			if !add(branchName(u.remoteRef), args...) {
+				return nil, false
+			}
+		}
+		return commits, true
+	}
+
+	branch := CurrentBranch(root)
+	if _, err := gitLines(root, "rev-parse", "--verify", "-q", "@{upstream}"); err == nil {
+		return commits, add(branch, "@{upstream}..HEAD")
+	}
+	remotes, err := gitLines(root, "remote")
+	if err != nil || len(remotes) == 0 {
+		return nil, false
+	}
+	return commits, add(branch, "HEAD", "--not", "--remotes")
+}
+
+// PushBranches lists the branches a push updates (deletions and tags left out). Without
+// pushRefs it is the checked-out branch, if any.
+func PushBranches(root string, pushRefs string) []string {
+	if strings.TrimSpace(pushRefs) == "" {
+		if branch := CurrentBranch(root); branch != "" {
+			return []string{branch}
+		}
+		return nil
+	}
+	seen := map[string]struct{}{}
+	for _, u := range parsePushRefs(pushRefs) {
+		if branch := branchName(u.remoteRef); branch != "" {
+			seen[branch] = struct{}{}
+		}
+	}
+	return sortedSet(seen)
+}
+
+// branchName strips refs/heads/ from ref, returning "" for anything that is not a branch.
+func branchName(ref string) string {
+	name, ok := strings.CutPrefix(ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// TrackedFiles lists the repo-relative files git tracks under root.
+func TrackedFiles(root string) ([]string, error) {
+	out, err := gitOutput(root, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// CheckAttr returns the value of a gitattribute for each of files ("unspecified" when no
+// .gitattributes rule sets it).
+func CheckAttr(root string, attr string, files []string) (map[string]string, error) {
+	values := make(map[string]string, len(files))
+	if len(files) == 0 {
+		return values, nil
+	}
+	cmd := exec.Command("git", "check-attr", "-z", "--stdin", attr)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00") + "\x00")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	// -z output is "<path> NUL <attribute> NUL <value> NUL" per file.
+	fields := strings.Split(stdout.String(), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		values[fields[i]] = fields[i+2]
+	}
+	return values, nil
+}
+
+// status returns the porcelain status code ("XY") of every changed or untracked file
+// under root, keyed by repo-relative path. Ignored files are left out.
+func status(root string) (map[string]string, error) {
+	out, err := gitOutput(root, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	codes := map[string]string{}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code := entry[:2]
+		codes[entry[3:]] = code
+		if code[0] == 'R' || code[0] == 'C' {
+			i++ // the next entry is the original path
+		}
+	}
+	return codes, nil
+}
+
+// WorktreeSnapshot records a working tree's uncommitted state, to tell afterwards which
+// files a command (a generator, a fixer) changed and to put them back.
+type WorktreeSnapshot struct {
+	root   string
+	status map[string]string
+	// contents holds tracked files with uncommitted changes, which the index can't
+	// restore (nil for a deleted file).
+	contents map[string][]byte
+}
+
+// WorktreeChanges are the files changed since a snapshot, each list sorted.
+type WorktreeChanges struct {
+	Changed []string // tracked files that had no uncommitted changes
+	Edited  []string // tracked files that already had uncommitted changes
+	Created []string // new untracked files
+}
+
+// Files lists every changed file.
+func (c WorktreeChanges) Files() []string {
+	files := append(append(append([]string{}, c.Changed...), c.Edited...), c.Created...)
+	sort.Strings(files)
+	return files
+}
+
+// SnapshotWorktree records the uncommitted state of the working tree at root.
+func SnapshotWorktree(root string) (*WorktreeSnapshot, error) {
+	codes, err := status(root)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &WorktreeSnapshot{root: root, status: codes, contents: map[string][]byte{}}
+	for file, code := range codes {
+		if code == "??" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		snapshot.contents[file] = data
+	}
+	return snapshot, nil
+}
+
+// Changes lists the files changed since the snapshot was taken.
+func (s *WorktreeSnapshot) Changes() (WorktreeChanges, error) {
+	codes, err := status(s.root)
+	if err != nil {
+		return WorktreeChanges{}, err
+	}
+	var changes WorktreeChanges
+	for file, code := range codes {
+		_, wasDirty := s.status[file]
+		switch {
+		case code == "??" && !wasDirty:
+			changes.Created = append(changes.Created, file)
+		case code == "??":
+		case !wasDirty:
+			changes.Changed = append(changes.Changed, file)
+		default:
+			data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(file)))
+			if errors.Is(err, os.ErrNotExist) {
+				data = nil
+			}
+			if !bytes.Equal(data, s.contents[file]) {
+				changes.Edited = append(changes.Edited, file)
+			}
+		}
+	}
+	for file, code := range s.status {
+		// A file put back the way the index has it.
+		if _, still := codes[file]; !still && code != "??" {
+			changes.Edited = append(changes.Edited, file)
+		}
+	}
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Edited)
+	sort.Strings(changes.Created)
+	return changes, nil
+}
+
+// Restore undoes changes: clean files come back from the index, files with uncommitted
+// changes from the snapshot, and created files are removed.
+func (s *WorktreeSnapshot) Restore(changes WorktreeChanges) error {
+	var errs []error
+	if len(changes.Changed) > 0 {
+		if _, err := gitOutput(s.root, append([]string{"checkout", "-q", "--"}, changes.Changed...)...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, file := range changes.Edited {
+		full := filepath.Join(s.root, filepath.FromSlash(file))
+		data := s.contents[file]
+		if data == nil {
+			if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(full, data, 0o644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, file := range changes.Created {
+		if err := os.Remove(filepath.Join(s.root, filepath.FromSlash(file))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CommitFiles commits files (staging new ones) as a new commit with message, or into
+// HEAD when amend is set, leaving anything else in the index alone. It returns the new
+// HEAD.
+func CommitFiles(root string, files []string, message string, amend bool) (string, error) {
+	if _, err := gitOutput(root, append([]string{"add", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	args := []string{"commit", "-q", "-m", message}
+	if amend {
+		args = []string{"commit", "-q", "--amend", "--no-edit"}
+	}
+	if _, err := gitOutput(root, append(append(args, "--"), files...)...); err != nil {
+		return "", err
+	}
+	head, err := gitLines(root, "rev-parse", "HEAD")
+	if err != nil || len(head) == 0 {
+		return "", err
+	}
+	return head[0], nil
+}
+
+// PushesHead reports whether HEAD is the tip of one of the refs in the pre-push input,
+// so amending it rewrites a commit that push sends rather than some unrelated one.
+func PushesHead(root string, pushRefs string) bool {
+	head, err := gitLines(root, "rev-parse", "HEAD")
+	if err != nil || len(head) == 0 {
+		return false
+	}
+	for _, u := range parsePushRefs(pushRefs) {
+		if u.localSHA == head[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// WorktreeDiff returns `git diff` of files between the index and the working tree.
+func WorktreeDiff(root string, files []string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+	return gitOutput(root, append([]string{"diff", "--no-color", "--no-ext-diff", "--"}, files...)...)
+}
+
+// DiffNoIndex diffs two files outside any repository. It runs in dir with paths
+// relative to it and without the a/ b/ prefixes, so laying the files out as
+// "a/<name>" and "b/<name>" gives the headers a normal diff would have.
+func DiffNoIndex(dir string, oldPath string, newPath string) (string, error) {
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", "--no-ext-diff", "--no-prefix", "--", oldPath, newPath)
+	cmd.Dir = dir
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	// Exit status 1 only means the files differ.
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	return stdout.String(), err
+}
+
+// pushUpdate is one line of pre-push input.
+type pushUpdate struct {
+	localRef, localSHA, remoteRef, remoteSHA string
+}
+
+// newBranch reports whether the remote does not have the ref yet.
+func (u pushUpdate) newBranch() bool {
+	return isZeroSHA(u.remoteSHA)
+}
+
+// parsePushRefs reads the pre-push input, leaving out deletions (they change no files).
+func parsePushRefs(pushRefs string) []pushUpdate {
+	var updates []pushUpdate
+	for _, line := range strings.Split(pushRefs, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || isZeroSHA(fields[1]) {
+			continue
+		}
+		updates = append(updates, pushUpdate{localRef: fields[0], localSHA: fields[1], remoteRef: fields[2], remoteSHA: fields[3]})
+	}
+	return updates
+}
+
+func isZeroSHA(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
+
+func gitLines(root string, args ...string) ([]string, error) {
+	out, err := gitOutput(root, args...)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func gitOutput(root string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-c", "core.quotepath=off"}, args...)...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for name := range set {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -1,5 +1,7 @@
-// Package git contains small helpers for discovering Git repository context
-// without shelling out to the `git` binary.
+// Package git contains small helpers for Git repository context. Finding the repo
+// root (repo.go) and the current branch (branch.go) read .git directly; everything in
+// changes.go (pushed files, diffs and commits, tracked files, attributes, worktree
+// snapshots, and commits made by `fix`) shells out to the `git` binary.
 package git
 
 import (
@@ -140,6 +140,13 @@ if ! echo "$bb_args" | grep -q -- "--verbose" && ! echo "$bb_args" | grep -q --
   esac
 fi
 
+# Git lists the refs being pushed on stdin; pass them on so checks with paths: only
+# run when a matching file is pushed.
+if [ ! -t 0 ]; then
+  BUILDBOUNCER_PUSH_REFS="$(cat)"
+  export BUILDBOUNCER_PUSH_REFS
+fi
+
 # For interactive prompts to work in Git Bash on Windows, we need to explicitly use the terminal
 if [ -t 0 ]; then
   # stdin is already a terminal
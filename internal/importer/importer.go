@@ -0,0 +1,117 @@
+// Package importer turns task-runner definitions (Make targets, just recipes, Taskfile
+// tasks, package.json/composer.json scripts, tox/nox sessions) into checks.
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// SourcePrefix starts the source: of every imported check ("import:make", ...), so a later
+// import can replace exactly the checks it created.
+const SourcePrefix = "import:"
+
+// Candidate is one task that could become a check.
+type Candidate struct {
+	Check config.Check
+	// Recommended marks tasks that look like checks (lint, test, build, ...); they are
+	// imported by default, the rest only with --all or when picked interactively.
+	Recommended bool
+}
+
+// Source reads one kind of task-runner file.
+type Source interface {
+	Name() string
+	Detect(root string) bool
+	Candidates(root string) ([]Candidate, error)
+}
+
+var sources = []Source{
+	makeSource{},
+	justSource{},
+	taskSource{},
+	npmSource{},
+	composerSource{},
+	toxSource{},
+	noxSource{},
+}
+
+// Sources returns every registered source, in import order.
+func Sources() []Source {
+	return append([]Source{}, sources...)
+}
+
+// SourceNames lists the registered source names.
+func SourceNames() []string {
+	names := make([]string, 0, len(sources))
+	for _, s := range sources {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// LookupSource finds a source by name.
+func LookupSource(name string) (Source, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, s := range sources {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// SourceForCheck returns the import source that created check, or "".
+func SourceForCheck(check config.Check) string {
+	source := strings.TrimSpace(check.Source)
+	if !strings.HasPrefix(source, SourcePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(source, SourcePrefix)
+}
+
+// candidate builds a check named "<source>:<task>" running run from the repo root.
+func candidate(source string, task string, run string) Candidate {
+	return Candidate{
+		Check: config.Check{
+			Name:   source + ":" + task,
+			Run:    run,
+			Source: SourcePrefix + source,
+		},
+		Recommended: looksLikeCheck(task),
+	}
+}
+
+var checkWords = map[string]bool{
+	"lint": true, "test": true, "tests": true, "build": true, "check": true, "checks": true,
+	"vet": true, "typecheck": true, "types": true, "verify": true, "validate": true,
+	"ci": true, "audit": true, "analyse": true, "analyze": true, "static": true,
+}
+
+// looksLikeCheck recognizes task names such as "lint", "test:unit", or "type-check".
+func looksLikeCheck(task string) bool {
+	task = strings.ToLower(task)
+	if checkWords[task] || checkWords[strings.ReplaceAll(task, "-", "")] {
+		return true
+	}
+	head := strings.FieldsFunc(task, func(r rune) bool { return r == ':' || r == '-' || r == '_' || r == '.' })
+	return len(head) > 0 && checkWords[head[0]]
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func firstExisting(root string, names ...string) (string, bool) {
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
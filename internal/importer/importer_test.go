@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// candidateSummary renders candidates as "name=run" with a "*" on recommended ones.
+func candidateSummary(candidates []Candidate) string {
+	var parts []string
+	for _, c := range candidates {
+		mark := ""
+		if c.Recommended {
+			mark = "*"
+		}
+		parts = append(parts, mark+c.Check.Name+"="+c.Check.Run)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestSourceCandidates(t *testing.T) {
+	cases := []struct {
+		name   string
+		source Source
+		files  map[string]string
+		want   string
+	}{
+		{
+			name:   "make",
+			source: makeSource{},
+			files: map[string]string{"Makefile": `
+.PHONY: build test \
+	lint
+.PHONY: release
+
+VERSION := 1.0
+build: deps
+	go build ./...
+test:
+	go test ./...
+lint:
+	golangci-lint run
+deps: go.sum
+	go mod download
+release:
+	./release.sh
+%.o: %.c
+	cc -c $<
+`},
+			want: "*make:build=make build; *make:test=make test; *make:lint=make lint; make:release=make release",
+		},
+		{
+			name:   "just",
+			source: justSource{},
+			files: map[string]string{"justfile": `
+set shell := ["bash", "-c"]
+version := "1.0"
+
+# run the linters
+lint:
+    golangci-lint run
+
+test *args:
+    go test {{args}} ./...
+
+deploy env:
+    ./deploy.sh {{env}}
+
+[private]
+helper:
+    echo hidden
+
+_internal:
+    echo hidden
+
+@fmt-check target="./...":
+    gofmt -l {{target}}
+`},
+			want: "*just:lint=just lint; *just:test=just test; just:fmt-check=just fmt-check",
+		},
+		{
+			name:   "task",
+			source: taskSource{},
+			files: map[string]string{"Taskfile.yml": `
+version: '3'
+tasks:
+  default:
+    cmds: [task --list]
+  test:
+    cmds: [go test ./...]
+  lint:unit: [golangci-lint run]
+  setup:
+    internal: true
+    cmds: [go mod download]
+  docs:
+    cmds: [mkdocs build]
+`},
+			want: "*task:test=task test; *task:lint:unit=task lint:unit; task:docs=task docs",
+		},
+		{
+			name:   "npm",
+			source: npmSource{},
+			files: map[string]string{
+				"package.json":   `{"scripts": {"pretest": "echo", "test": "vitest", "prettier": "prettier -c .", "type-check": "tsc", "postinstall": "husky", "dev": "vite"}}`,
+				"pnpm-lock.yaml": "",
+			},
+			want: "*npm:test=pnpm run test; npm:prettier=pnpm run prettier; *npm:type-check=pnpm run type-check; npm:dev=pnpm run dev",
+		},
+		{
+			name:   "composer",
+			source: composerSource{},
+			files:  map[string]string{"composer.json": `{"scripts": {"post-install-cmd": "x", "test": "phpunit", "analyse": ["phpstan analyse"], "serve": "php -S localhost:8000"}}`},
+			want:   "*composer:test=composer run-script test; *composer:analyse=composer run-script analyse; composer:serve=composer run-script serve",
+		},
+		{
+			name:   "tox",
+			source: toxSource{},
+			files: map[string]string{"tox.ini": `
+[tox]
+envlist = py311, lint
+    type
+isolated_build = true
+
+[testenv]
+commands = pytest
+
+[testenv:lint]
+commands = ruff check .
+
+[testenv:docs]
+commands = sphinx-build docs out
+`},
+			want: "*tox:py311=tox -e py311; *tox:lint=tox -e lint; *tox:type=tox -e type; *tox:docs=tox -e docs",
+		},
+		{
+			name:   "nox",
+			source: noxSource{},
+			files: map[string]string{"noxfile.py": `
+import nox
+
+@nox.session
+def tests(session):
+    session.run("pytest")
+
+@nox.session(python=["3.12"], name="type-check")
+def mypy(session):
+    session.run("mypy", ".")
+
+def helper():
+    pass
+`},
+			want: "*nox:tests=nox -s tests; *nox:type-check=nox -s type-check",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for rel, content := range tc.files {
+				path := filepath.Join(root, filepath.FromSlash(rel))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatalf("write %s: %v", rel, err)
+				}
+			}
+			if !tc.source.Detect(root) {
+				t.Fatalf("expected %s to be detected", tc.source.Name())
+			}
+			got, err := tc.source.Candidates(root)
+			if err != nil {
+				t.Fatalf("candidates: %v", err)
+			}
+			if candidateSummary(got) != tc.want {
+				t.Fatalf("unexpected candidates:\n got %s\nwant %s", candidateSummary(got), tc.want)
+			}
+			for _, c := range got {
+				if c.Check.Source != SourcePrefix+tc.source.Name() || SourceForCheck(c.Check) != tc.source.Name() {
+					t.Fatalf("unexpected source %q", c.Check.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupSource(t *testing.T) {
+	if _, ok := LookupSource(" Make "); !ok {
+		t.Fatalf("expected make source")
+	}
+	if _, ok := LookupSource("gradle"); ok {
+		t.Fatalf("expected unknown source")
+	}
+}
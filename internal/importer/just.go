@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+type justSource struct{}
+
+func (justSource) Name() string { return "just" }
+
+func (justSource) Detect(root string) bool {
+	_, ok := firstExisting(root, justfileNames...)
+	return ok
+}
+
+var (
+	justRecipePattern = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)((?:\s+[^:]*)?):([^=]|$)`)
+	justKeywords      = map[string]bool{"set": true, "alias": true, "export": true, "import": true, "mod": true}
+)
+
+// Candidates offers public recipes that can run without arguments. Recipes marked
+// [private] or named with a leading underscore are skipped.
+func (justSource) Candidates(root string) ([]Candidate, error) {
+	path, ok := firstExisting(root, justfileNames...)
+	if !ok {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Candidate
+	private := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			private = private || strings.Contains(trimmed, "private")
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		m := justRecipePattern.FindStringSubmatch(line)
+		wasPrivate := private
+		private = false
+		if m == nil || justKeywords[m[1]] || wasPrivate || strings.HasPrefix(m[1], "_") {
+			continue
+		}
+		if !justParamsOptional(m[2]) {
+			continue
+		}
+		out = append(out, candidate("just", m[1], "just "+m[1]))
+	}
+	return out, scanner.Err()
+}
+
+// justParamsOptional reports whether every recipe parameter has a default or is variadic
+// with `*`, so the recipe can run without arguments.
+func justParamsOptional(params string) bool {
+	for _, p := range strings.Fields(params) {
+		if strings.HasPrefix(p, "*") || strings.HasPrefix(p, "$*") || strings.Contains(p, "=") {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+
+type makeSource struct{}
+
+func (makeSource) Name() string { return "make" }
+
+func (makeSource) Detect(root string) bool {
+	_, ok := firstExisting(root, makefileNames...)
+	return ok
+}
+
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=]|$)`)
+
+// Candidates offers the .PHONY targets, in the order their rules appear. Pattern rules,
+// file targets, and special targets are left alone.
+func (makeSource) Candidates(root string) ([]Candidate, error) {
+	path, ok := firstExisting(root, makefileNames...)
+	if !ok {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	phony := map[string]bool{}
+	var rules []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	continued := false
+	inPhony := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		wasContinued := continued
+		continued = strings.HasSuffix(line, "\\")
+		body := strings.TrimSuffix(line, "\\")
+
+		if wasContinued {
+			if inPhony {
+				for _, t := range strings.Fields(body) {
+					phony[t] = true
+				}
+			}
+			inPhony = inPhony && continued
+			continue
+		}
+		inPhony = false
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(body, ".PHONY:"); ok {
+			for _, t := range strings.Fields(rest) {
+				phony[t] = true
+			}
+			inPhony = continued
+			continue
+		}
+		if m := makeTargetPattern.FindStringSubmatch(line); m != nil {
+			for _, t := range strings.Fields(strings.SplitN(line, ":", 2)[0]) {
+				if !seen[t] {
+					seen[t] = true
+					rules = append(rules, t)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []Candidate
+	for _, target := range rules {
+		if phony[target] {
+			out = append(out, candidate("make", target, "make "+target))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type toxSource struct{}
+
+func (toxSource) Name() string { return "tox" }
+
+func (toxSource) Detect(root string) bool {
+	return fileExists(filepath.Join(root, "tox.ini"))
+}
+
+var toxSectionPattern = regexp.MustCompile(`^\[testenv:([^\]]+)\]`)
+
+// Candidates offers the environments in tox.ini's envlist plus every [testenv:NAME]
+// section. Tox environments are what the project runs to check itself, so all of them
+// are recommended.
+func (toxSource) Candidates(root string) ([]Candidate, error) {
+	f, err := os.Open(filepath.Join(root, "tox.ini"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var envs []string
+	seen := map[string]bool{}
+	add := func(env string) {
+		env = strings.TrimSpace(env)
+		if env == "" || seen[env] || strings.ContainsAny(env, "{}") {
+			return
+		}
+		seen[env] = true
+		envs = append(envs, env)
+	}
+
+	section := ""
+	inEnvlist := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			section = trimmed
+			inEnvlist = false
+			if m := toxSectionPattern.FindStringSubmatch(trimmed); m != nil {
+				add(m[1])
+			}
+			continue
+		}
+		if section != "[tox]" || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(trimmed, "="); ok && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inEnvlist = strings.TrimSpace(key) == "envlist" || strings.TrimSpace(key) == "env_list"
+			if inEnvlist {
+				for _, env := range strings.Split(value, ",") {
+					add(env)
+				}
+			}
+			continue
+		}
+		if inEnvlist {
+			for _, env := range strings.Split(trimmed, ",") {
+				add(env)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]Candidate, 0, len(envs))
+	for _, env := range envs {
+		c := candidate("tox", env, "tox -e "+env)
+		c.Recommended = true
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+type noxSource struct{}
+
+func (noxSource) Name() string { return "nox" }
+
+func (noxSource) Detect(root string) bool {
+	return fileExists(filepath.Join(root, "noxfile.py"))
+}
+
+var (
+	noxDecoratorPattern = regexp.MustCompile(`^@nox\.session\b(?:\((.*)\))?`)
+	noxNameArgPattern   = regexp.MustCompile(`\bname\s*=\s*["']([^"']+)["']`)
+	noxDefPattern       = regexp.MustCompile(`^def\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+)
+
+// Candidates offers each @nox.session, using its name= argument when given.
+func (noxSource) Candidates(root string) ([]Candidate, error) {
+	f, err := os.Open(filepath.Join(root, "noxfile.py"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Candidate
+	pending := false
+	pendingName := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := noxDecoratorPattern.FindStringSubmatch(line); m != nil {
+			pending = true
+			pendingName = ""
+			if n := noxNameArgPattern.FindStringSubmatch(m[1]); n != nil {
+				pendingName = n[1]
+			}
+			continue
+		}
+		if !pending {
+			continue
+		}
+		if m := noxDefPattern.FindStringSubmatch(line); m != nil {
+			name := pendingName
+			if name == "" {
+				name = m[1]
+			}
+			c := candidate("nox", name, "nox -s "+name)
+			c.Recommended = true
+			out = append(out, c)
+			pending = false
+		}
+	}
+	return out, scanner.Err()
+}
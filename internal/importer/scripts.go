@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type npmSource struct{}
+
+func (npmSource) Name() string { return "npm" }
+
+func (npmSource) Detect(root string) bool {
+	return fileExists(filepath.Join(root, "package.json"))
+}
+
+// Candidates offers package.json scripts, run with the repo's package manager.
+// Lifecycle hooks (pre*/post*, install, prepare) are skipped.
+func (npmSource) Candidates(root string) ([]Candidate, error) {
+	path := filepath.Join(root, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		PackageManager string `json:"packageManager"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	names, err := orderedJSONKeys(data, "scripts")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	runner := NodeRunner(root, pkg.PackageManager)
+	scripts := make(map[string]bool, len(names))
+	for _, name := range names {
+		scripts[name] = true
+	}
+	var out []Candidate
+	for _, name := range names {
+		if isLifecycleScript(name, scripts) {
+			continue
+		}
+		out = append(out, candidate("npm", name, runner+" run "+name))
+	}
+	return out, nil
+}
+
+var npmLifecycle = map[string]bool{
+	"install": true, "preinstall": true, "postinstall": true, "prepare": true,
+	"prepublish": true, "prepublishOnly": true, "prepack": true, "postpack": true,
+	"publish": true, "postpublish": true, "version": true, "preversion": true, "postversion": true,
+}
+
+// isLifecycleScript recognizes npm's own hooks and pre/post scripts of other scripts
+// ("pretest" when "test" exists); those run automatically around their main script.
+func isLifecycleScript(name string, scripts map[string]bool) bool {
+	if npmLifecycle[name] {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(name, "pre"); ok && scripts[rest] {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(name, "post"); ok && scripts[rest] {
+		return true
+	}
+	return false
+}
+
+// NodeRunner picks the package manager for a Node project from package.json's
+// packageManager field, then lockfiles, defaulting to npm.
+func NodeRunner(root string, packageManager string) string {
+	if pm := strings.ToLower(strings.TrimSpace(packageManager)); pm != "" {
+		for _, runner := range []string{"bun", "pnpm", "yarn", "npm"} {
+			if strings.HasPrefix(pm, runner) {
+				return runner
+			}
+		}
+	}
+
+	if fileExists(filepath.Join(root, "pnpm-lock.yaml")) {
+		return "pnpm"
+	}
+	if fileExists(filepath.Join(root, "yarn.lock")) {
+		return "yarn"
+	}
+	if fileExists(filepath.Join(root, "bun.lockb")) || fileExists(filepath.Join(root, "bun.lock")) {
+		return "bun"
+	}
+	return "npm"
+}
+
+type composerSource struct{}
+
+func (composerSource) Name() string { return "composer" }
+
+func (composerSource) Detect(root string) bool {
+	return fileExists(filepath.Join(root, "composer.json"))
+}
+
+// Candidates offers composer.json scripts, skipping Composer's event hooks
+// (post-install-cmd, pre-autoload-dump, ...).
+func (composerSource) Candidates(root string) ([]Candidate, error) {
+	path := filepath.Join(root, "composer.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	names, err := orderedJSONKeys(data, "scripts")
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var out []Candidate
+	for _, name := range names {
+		if strings.HasPrefix(name, "pre-") || strings.HasPrefix(name, "post-") {
+			continue
+		}
+		out = append(out, candidate("composer", name, "composer run-script "+name))
+	}
+	return out, nil
+}
+
+// orderedJSONKeys returns the keys of the top-level object field in document order;
+// encoding/json maps would lose the order the author chose.
+func orderedJSONKeys(data []byte, field string) ([]string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	raw, ok := top[field]
+	if !ok {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("%s: expected an object", field)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
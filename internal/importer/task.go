@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+var taskfileNames = []string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml", "Taskfile.dist.yml", "Taskfile.dist.yaml"}
+
+type taskSource struct{}
+
+func (taskSource) Name() string { return "task" }
+
+func (taskSource) Detect(root string) bool {
+	_, ok := firstExisting(root, taskfileNames...)
+	return ok
+}
+
+// Candidates offers the Taskfile's tasks in file order, skipping `internal: true` ones.
+func (taskSource) Candidates(root string) ([]Candidate, error) {
+	path, ok := firstExisting(root, taskfileNames...)
+	if !ok {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if doc.Tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var out []Candidate
+	for i := 0; i+1 < len(doc.Tasks.Content); i += 2 {
+		name := doc.Tasks.Content[i].Value
+		var task struct {
+			Internal bool `yaml:"internal"`
+		}
+		// Tasks can also be a bare command list; those have no settings to read.
+		_ = doc.Tasks.Content[i+1].Decode(&task)
+		if task.Internal || name == "default" {
+			continue
+		}
+		out = append(out, candidate("task", name, "task "+name))
+	}
+	return out, nil
+}
@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const huskyDir = ".husky"
+
+var (
+	// huskyBootstrap matches the husky v4–v8 preamble that sources husky.sh.
+	huskyBootstrap = regexp.MustCompile(`^\.\s+"?\$\(dirname\s+(--\s+)?"?\$0"?\)/_/husky\.sh"?$`)
+	// huskyLintStaged matches a line that just runs lint-staged through a package runner.
+	huskyLintStaged = regexp.MustCompile(`^(?:(?:npx|bunx|pnpm(?:\s+exec)?|yarn(?:\s+run)?|npm\s+exec)\s+)?(?:--no(?:-install)?\s+)?lint-staged(?:\s|$)`)
+	// huskyControlFlow spots scripts that are more than a list of commands.
+	huskyControlFlow = regexp.MustCompile(`^(if|then|else|elif|fi|for|while|until|do|done|case|esac|function)\b|\(\)\s*\{|\\$|^[{}]$`)
+)
+
+// lintStagedConfigFiles are lint-staged's static config files; JSON is valid YAML, so one
+// decoder reads all of them.
+var lintStagedConfigFiles = []string{".lintstagedrc", ".lintstagedrc.json", ".lintstagedrc.yaml", ".lintstagedrc.yml"}
+
+type huskyTool struct{}
+
+func (huskyTool) Name() string { return "husky" }
+
+func (huskyTool) Detect(root string) bool {
+	for _, hook := range pushHooks {
+		if fileExists(filepath.Join(root, huskyDir, hook)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (huskyTool) Migrate(root string) (Result, error) {
+	var res Result
+	entries, err := os.ReadDir(filepath.Join(root, huskyDir))
+	if err != nil {
+		return Result{}, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || isPushHook(name) || !isGitHookName(name) {
+			continue
+		}
+		res.Unsupported = append(res.Unsupported, fmt.Sprintf("%s (runs at %s, not before a push)", name, name))
+	}
+
+	for _, hook := range pushHooks {
+		script := filepath.Join(root, huskyDir, hook)
+		data, err := os.ReadFile(script)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		if err := huskyHookChecks(root, hook, string(data), &res); err != nil {
+			return Result{}, err
+		}
+	}
+	res.Checks = uniqueNames(res.Checks)
+	return res, nil
+}
+
+func huskyHookChecks(root string, hook string, script string, res *Result) error {
+	var commands []string
+	for _, raw := range strings.Split(strings.ReplaceAll(script, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || huskyBootstrap.MatchString(line) {
+			continue
+		}
+		if huskyControlFlow.MatchString(line) {
+			// Not a plain list of commands: keep the script whole.
+			check := newCheck("husky", hook, "sh "+huskyDir+"/"+hook)
+			addNote(&check, "husky script with shell logic, run as is")
+			res.Checks = append(res.Checks, check)
+			return nil
+		}
+		commands = append(commands, line)
+	}
+
+	for _, run := range commands {
+		if huskyLintStaged.MatchString(run) {
+			if err := lintStagedChecks(root, res); err != nil {
+				return err
+			}
+			continue
+		}
+		res.Checks = append(res.Checks, newCheck("husky", hook+":"+commandLabel(run), run))
+	}
+	return nil
+}
+
+// lintStagedChecks expands `lint-staged` into one check per glob and command. lint-staged
+// runs globs concurrently and re-stages what its commands fix.
+func lintStagedChecks(root string, res *Result) error {
+	tasks, origin, err := readLintStagedConfig(root)
+	if err != nil {
+		return err
+	}
+	if origin == "" {
+		res.Unsupported = append(res.Unsupported, "lint-staged (no package.json \"lint-staged\" key or .lintstagedrc; JavaScript configs are not read)")
+		return nil
+	}
+	if len(tasks.Keys) > 1 {
+		res.Parallel = true
+	}
+	for _, glob := range tasks.Keys {
+		for _, run := range tasks.Values[glob] {
+			check := newCheck("husky", "lint-staged:"+commandLabel(run), run+" .")
+			// lint-staged globs without "/" match file names at any depth, as paths: does.
+			check.Paths = config.StringList{strings.TrimSpace(glob)}
+			addNote(&check, "lint-staged "+fileListNote)
+			addNote(&check, "lint-staged staged the files its commands fixed; commit the fixes before pushing")
+			res.Checks = append(res.Checks, check)
+		}
+	}
+	return nil
+}
+
+func readLintStagedConfig(root string) (orderedMap[config.StringList], string, error) {
+	var tasks orderedMap[config.StringList]
+	if path, ok := firstExisting(root, lintStagedConfigFiles...); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return tasks, "", err
+		}
+		if err := yaml.Unmarshal(data, &tasks); err != nil {
+			return tasks, "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		return tasks, filepath.Base(path), nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if os.IsNotExist(err) {
+		return tasks, "", nil
+	}
+	if err != nil {
+		return tasks, "", err
+	}
+	var pkg struct {
+		LintStaged json.RawMessage `json:"lint-staged"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return tasks, "", fmt.Errorf("package.json: %w", err)
+	}
+	if len(pkg.LintStaged) == 0 {
+		return tasks, "", nil
+	}
+	if err := yaml.Unmarshal(pkg.LintStaged, &tasks); err != nil {
+		return tasks, "", fmt.Errorf("package.json lint-staged: %w", err)
+	}
+	return tasks, "package.json", nil
+}
+
+var gitHookNames = map[string]bool{
+	"applypatch-msg": true, "commit-msg": true, "post-applypatch": true, "post-checkout": true,
+	"post-commit": true, "post-merge": true, "post-rewrite": true, "pre-applypatch": true,
+	"pre-auto-gc": true, "pre-commit": true, "pre-merge-commit": true, "pre-push": true,
+	"pre-rebase": true, "prepare-commit-msg": true,
+}
+
+func isGitHookName(name string) bool {
+	return gitHookNames[name]
+}
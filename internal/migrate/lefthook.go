@@ -0,0 +1,210 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+var lefthookConfigFiles = []string{"lefthook.yml", "lefthook.yaml", ".lefthook.yml", ".lefthook.yaml"}
+
+// lefthookFilePlaceholder matches the file-list templates lefthook substitutes into run.
+var lefthookFilePlaceholder = regexp.MustCompile(`\{(staged_files|push_files|all_files|files)\}`)
+
+type lefthookHook struct {
+	Parallel bool                        `yaml:"parallel"`
+	Piped    bool                        `yaml:"piped"`
+	Commands orderedMap[lefthookCommand] `yaml:"commands"`
+	Scripts  orderedMap[lefthookCommand] `yaml:"scripts"`
+	Jobs     []lefthookCommand           `yaml:"jobs"`
+}
+
+type lefthookCommand struct {
+	Name       string            `yaml:"name"`
+	Run        string            `yaml:"run"`
+	Script     string            `yaml:"script"`
+	Runner     string            `yaml:"runner"`
+	Glob       config.StringList `yaml:"glob"`
+	Root       string            `yaml:"root"`
+	Exclude    yaml.Node         `yaml:"exclude"`
+	Env        map[string]string `yaml:"env"`
+	StageFixed bool              `yaml:"stage_fixed"`
+	Skip       yaml.Node         `yaml:"skip"`
+	Files      string            `yaml:"files"`
+	// Group nests further jobs (lefthook's `jobs:` syntax).
+	Group *lefthookHook `yaml:"group"`
+}
+
+type lefthookTool struct{}
+
+func (lefthookTool) Name() string { return "lefthook" }
+
+func (lefthookTool) Detect(root string) bool {
+	_, ok := firstExisting(root, lefthookConfigFiles...)
+	return ok
+}
+
+func (lefthookTool) Migrate(root string) (Result, error) {
+	cfgPath, ok := firstExisting(root, lefthookConfigFiles...)
+	if !ok {
+		return Result{}, fmt.Errorf("no lefthook config found")
+	}
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return Result{}, err
+	}
+	var hooks orderedMap[yaml.Node]
+	if err := yaml.Unmarshal(data, &hooks); err != nil {
+		return Result{}, fmt.Errorf("%s: %w", filepath.Base(cfgPath), err)
+	}
+
+	var res Result
+	for _, name := range hooks.Keys {
+		node := hooks.Values[name]
+		if node.Kind != yaml.MappingNode {
+			// Top-level settings (min_version, colors, skip_output, ...).
+			continue
+		}
+		var hook lefthookHook
+		if err := node.Decode(&hook); err != nil {
+			return Result{}, fmt.Errorf("%s: %s: %w", filepath.Base(cfgPath), name, err)
+		}
+		if !hook.hasCommands() {
+			continue
+		}
+		if !isPushHook(name) {
+			res.Unsupported = append(res.Unsupported, fmt.Sprintf("%s (runs at %s, not before a push)", name, name))
+			continue
+		}
+		if hook.Parallel {
+			res.Parallel = true
+		}
+		if hook.Piped {
+			res.FailFast = true
+		}
+		lefthookHookChecks(name, hook, &res)
+	}
+	res.Checks = uniqueNames(res.Checks)
+	return res, nil
+}
+
+func (h lefthookHook) hasCommands() bool {
+	return len(h.Commands.Keys) > 0 || len(h.Scripts.Keys) > 0 || len(h.Jobs) > 0
+}
+
+func lefthookHookChecks(hookName string, hook lefthookHook, res *Result) {
+	for _, name := range hook.Commands.Keys {
+		cmd := hook.Commands.Values[name]
+		cmd.Name = name
+		lefthookCommandCheck(hookName, cmd, res)
+	}
+	for _, file := range hook.Scripts.Keys {
+		script := hook.Scripts.Values[file]
+		script.Name = file
+		script.Script = file
+		lefthookCommandCheck(hookName, script, res)
+	}
+	for _, job := range hook.Jobs {
+		if job.Group != nil {
+			if job.Group.Parallel {
+				res.Parallel = true
+			}
+			lefthookHookChecks(hookName, *job.Group, res)
+			continue
+		}
+		lefthookCommandCheck(hookName, job, res)
+	}
+}
+
+func lefthookCommandCheck(hookName string, cmd lefthookCommand, res *Result) {
+	run := strings.TrimSpace(cmd.Run)
+	if script := strings.TrimSpace(cmd.Script); script != "" && run == "" {
+		run = path.Join(".lefthook", hookName, script)
+		if runner := strings.TrimSpace(cmd.Runner); runner != "" {
+			run = runner + " " + run
+		} else {
+			run = "./" + run
+		}
+	}
+	label := strings.TrimSpace(cmd.Name)
+	if label == "" {
+		label = commandLabel(run)
+	}
+	if run == "" {
+		res.Unsupported = append(res.Unsupported, fmt.Sprintf("%s: %s (no run command)", hookName, label))
+		return
+	}
+	if lefthookSkipsAlways(cmd.Skip) {
+		res.Unsupported = append(res.Unsupported, fmt.Sprintf("%s: %s (disabled with skip: true)", hookName, label))
+		return
+	}
+
+	check := newCheck("lefthook", hookName+":"+label, run)
+	if lefthookFilePlaceholder.MatchString(check.Run) {
+		check.Run = lefthookFilePlaceholder.ReplaceAllString(check.Run, ".")
+		addNote(&check, "lefthook "+fileListNote)
+	}
+	check.Env = cmd.Env
+
+	root := strings.Trim(strings.TrimSpace(cmd.Root), "/")
+	if root != "" {
+		check.Cwd = root
+	}
+	check.Paths = lefthookPaths(cmd, root)
+
+	if strings.TrimSpace(cmd.Files) != "" {
+		addNote(&check, "lefthook listed files with `"+strings.TrimSpace(cmd.Files)+"`; paths are not limited")
+		check.Paths = nil
+	}
+	if cmd.StageFixed {
+		addNote(&check, "lefthook staged the files this command fixed (stage_fixed); commit the fixes before pushing")
+	}
+	res.Checks = append(res.Checks, check)
+}
+
+// lefthookPaths converts glob, root and exclude. lefthook matches globs without a "/"
+// against file names at any depth, which is what paths: does too; under a root they are
+// scoped to that directory.
+func lefthookPaths(cmd lefthookCommand, root string) []string {
+	var paths []string
+	for _, glob := range cmd.Glob {
+		if root != "" && !strings.Contains(glob, "/") {
+			glob = root + "/**/" + glob
+		}
+		paths = append(paths, glob)
+	}
+	if len(paths) == 0 && root != "" {
+		paths = append(paths, root+"/")
+	}
+
+	switch cmd.Exclude.Kind {
+	case yaml.ScalarNode:
+		// Older lefthook configs give exclude as one regular expression.
+		if expr := strings.TrimSpace(cmd.Exclude.Value); expr != "" {
+			paths = append(paths, "!re:"+expr)
+		}
+	case yaml.SequenceNode:
+		for _, item := range cmd.Exclude.Content {
+			if glob := strings.TrimSpace(item.Value); glob != "" {
+				paths = append(paths, "!"+glob)
+			}
+		}
+	}
+	return paths
+}
+
+// lefthookSkipsAlways reports `skip: true`; conditional skips (merge, rebase, refs) still
+// migrate since pushes rarely hit them.
+func lefthookSkipsAlways(skip yaml.Node) bool {
+	if skip.Kind != yaml.ScalarNode {
+		return false
+	}
+	var b bool
+	return skip.Decode(&b) == nil && b
+}
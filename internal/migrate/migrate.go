@@ -0,0 +1,187 @@
+// Package migrate converts hooks defined for other hook managers (pre-commit, lefthook,
+// husky) into checks, so a repo can switch to build-bouncer without rewriting them.
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePrefix starts the source: of every migrated check ("migrate:lefthook", ...), so
+// migrating again replaces exactly the checks the previous run created.
+const SourcePrefix = "migrate:"
+
+// Result is what one hook manager's configuration converts into.
+type Result struct {
+	Checks []config.Check
+	// Parallel is set when the hook manager ran these hooks concurrently.
+	Parallel bool
+	// FailFast is set when the hook manager stopped at the first failing hook.
+	FailFast bool
+	// Unsupported describes every hook that was not converted and why.
+	Unsupported []string
+}
+
+// Tool reads one hook manager's configuration.
+type Tool interface {
+	// Name is the value `migrate --from` accepts.
+	Name() string
+	Detect(root string) bool
+	Migrate(root string) (Result, error)
+}
+
+var tools = []Tool{
+	preCommitTool{},
+	lefthookTool{},
+	huskyTool{},
+}
+
+// Tools returns every supported hook manager.
+func Tools() []Tool {
+	return append([]Tool{}, tools...)
+}
+
+// ToolNames lists the supported hook manager names.
+func ToolNames() []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// LookupTool finds a hook manager by name.
+func LookupTool(name string) (Tool, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, t := range tools {
+		if t.Name() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// ToolForCheck returns the hook manager a check was migrated from, or "".
+func ToolForCheck(check config.Check) string {
+	source := strings.TrimSpace(check.Source)
+	if !strings.HasPrefix(source, SourcePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(source, SourcePrefix)
+}
+
+// pushHooks are the git hooks whose commands belong in a pre-push check run. Commit-time
+// hooks count too: pushing is the last chance to catch what they would have.
+var pushHooks = []string{"pre-commit", "pre-push"}
+
+func isPushHook(hook string) bool {
+	for _, h := range pushHooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// fileListNote explains a run whose hook manager appended (or substituted) the list of
+// changed files; the migrated check runs on "." instead.
+const fileListNote = `received the changed file names; migrated to run on "."`
+
+func newCheck(tool string, name string, run string) config.Check {
+	return config.Check{
+		Source: SourcePrefix + tool,
+		Name:   tool + ":" + name,
+		Run:    strings.TrimSpace(run),
+	}
+}
+
+func addNote(check *config.Check, note string) {
+	if strings.TrimSpace(note) == "" {
+		return
+	}
+	if check.Note == "" {
+		check.Note = note
+		return
+	}
+	check.Note += "; " + note
+}
+
+// commandLabel names a check after its command: the first line, trimmed to a readable
+// length.
+func commandLabel(run string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(run), "\n")
+	line = strings.Join(strings.Fields(line), " ")
+	const maxLabel = 40
+	if len(line) > maxLabel {
+		line = strings.TrimSpace(line[:maxLabel])
+	}
+	if line == "" {
+		return "command"
+	}
+	return line
+}
+
+// uniqueNames suffixes repeated check names (":2", ":3", ...); config names must be unique.
+func uniqueNames(checks []config.Check) []config.Check {
+	seen := make(map[string]int, len(checks))
+	for i := range checks {
+		name := checks[i].Name
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			checks[i].Name = fmt.Sprintf("%s:%d", name, n)
+		}
+	}
+	return checks
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+// firstExisting returns the first of the candidate paths under root that is a file.
+func firstExisting(root string, candidates ...string) (string, bool) {
+	for _, rel := range candidates {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// orderedMap decodes a YAML (or JSON) mapping while keeping its key order.
+type orderedMap[T any] struct {
+	Keys   []string
+	Values map[string]T
+}
+
+func (m *orderedMap[T]) UnmarshalYAML(node *yaml.Node) error {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected a mapping at line %d", node.Line)
+	}
+	m.Values = make(map[string]T, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if _, dup := m.Values[key]; !dup {
+			m.Keys = append(m.Keys, key)
+		}
+		m.Values[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// writeHookFile writes a hook manager config file under root.
+func writeHookFile(t *testing.T, root string, rel string, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// checkSummary renders checks as "name=run [paths] (cwd)", one per line.
+func checkSummary(checks []config.Check) string {
+	var lines []string
+	for _, c := range checks {
+		line := c.Name + "=" + c.Run
+		if len(c.Paths) > 0 {
+			line += " [" + strings.Join(c.Paths, " ") + "]"
+		}
+		if c.Cwd != "" {
+			line += " (" + c.Cwd + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestPreCommitMigratesLocalHooksAndReportsRemoteOnes(t *testing.T) {
+	root := t.TempDir()
+	writeHookFile(t, root, PreCommitConfigFile, `
+exclude: ^vendor/
+repos:
+  - repo: https://github.com/pre-commit/pre-commit-hooks
+    rev: v4.6.0
+    hooks:
+      - id: trailing-whitespace
+      - id: end-of-file-fixer
+  - repo: meta
+    hooks:
+      - id: check-hooks-apply
+  - repo: local
+    hooks:
+      - id: gofmt
+        name: gofmt
+        entry: gofmt -l
+        language: system
+        types: [go]
+      - id: go-vet
+        entry: go vet ./...
+        language: system
+        pass_filenames: false
+        files: \.go$
+      - id: lint-sql
+        entry: scripts/lint-sql.sh
+        language: script
+        args: ["--dialect", "postgres 16"]
+        types_or: [sql, python]
+        exclude: ^migrations/legacy/
+      - id: commit-msg
+        entry: ./check-msg
+        language: system
+        stages: [commit-msg]
+      - id: black
+        entry: black
+        language: python
+`)
+
+	res, err := preCommitTool{}.Migrate(root)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := strings.Join([]string{
+		`pre-commit:gofmt=gofmt -l . [*.go !re:^vendor/]`,
+		`pre-commit:go-vet=go vet ./... [re:\.go$ !re:^vendor/]`,
+		`pre-commit:lint-sql=./scripts/lint-sql.sh --dialect 'postgres 16' . [*.sql *.py *.pyi !re:^migrations/legacy/ !re:^vendor/]`,
+	}, "\n")
+	if got := checkSummary(res.Checks); got != want {
+		t.Fatalf("checks:\n%s\nwant:\n%s", got, want)
+	}
+	if res.Checks[1].Note != "" {
+		t.Fatalf("pass_filenames: false should not add a note, got %q", res.Checks[1].Note)
+	}
+	if !strings.Contains(res.Checks[0].Note, `run on "."`) {
+		t.Fatalf("expected file list note, got %q", res.Checks[0].Note)
+	}
+
+	unsupported := strings.Join(res.Unsupported, "\n")
+	for _, want := range []string{
+		"https://github.com/pre-commit/pre-commit-hooks@v4.6.0: trailing-whitespace, end-of-file-fixer",
+		"meta: check-hooks-apply",
+		"local: commit-msg (runs at commit-msg, not before a push)",
+		"local: black (language python needs pre-commit to build its environment)",
+	} {
+		if !strings.Contains(unsupported, want) {
+			t.Errorf("unsupported report missing %q:\n%s", want, unsupported)
+		}
+	}
+}
+
+func TestLefthookMigratesCommandsGlobsAndSettings(t *testing.T) {
+	root := t.TempDir()
+	writeHookFile(t, root, "lefthook.yml", `
+min_version: 1.5.0
+pre-commit:
+  parallel: true
+  commands:
+    eslint:
+      glob: "*.{js,ts}"
+      root: web/
+      run: npx eslint --fix {staged_files}
+      stage_fixed: true
+    govet:
+      run: go vet ./...
+      glob: "*.go"
+      exclude: '_test\.go$'
+    old:
+      run: ./old.sh
+      skip: true
+pre-push:
+  piped: true
+  scripts:
+    "audit.sh":
+      runner: bash
+  jobs:
+    - name: test
+      run: go test ./...
+      exclude: ["docs/**"]
+commit-msg:
+  commands:
+    lint-msg:
+      run: commitlint --edit {1}
+`)
+
+	res, err := lefthookTool{}.Migrate(root)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := strings.Join([]string{
+		`lefthook:pre-commit:eslint=npx eslint --fix . [web/**/*.{js,ts}] (web)`,
+		`lefthook:pre-commit:govet=go vet ./... [*.go !re:_test\.go$]`,
+		`lefthook:pre-push:audit.sh=bash .lefthook/pre-push/audit.sh`,
+		`lefthook:pre-push:test=go test ./... [!docs/**]`,
+	}, "\n")
+	if got := checkSummary(res.Checks); got != want {
+		t.Fatalf("checks:\n%s\nwant:\n%s", got, want)
+	}
+	if !res.Parallel || !res.FailFast {
+		t.Fatalf("expected parallel and fail-fast, got %+v", res)
+	}
+	if note := res.Checks[0].Note; !strings.Contains(note, "stage_fixed") || !strings.Contains(note, `run on "."`) {
+		t.Fatalf("unexpected eslint note %q", note)
+	}
+	unsupported := strings.Join(res.Unsupported, "\n")
+	if !strings.Contains(unsupported, "pre-commit: old (disabled with skip: true)") || !strings.Contains(unsupported, "commit-msg (runs at commit-msg") {
+		t.Fatalf("unexpected unsupported report:\n%s", unsupported)
+	}
+}
+
+func TestHuskyMigratesCommandsAndLintStaged(t *testing.T) {
+	root := t.TempDir()
+	writeHookFile(t, root, ".husky/pre-commit", `#!/usr/bin/env sh
+. "$(dirname -- "$0")/_/husky.sh"
+
+npx lint-staged
+`)
+	writeHookFile(t, root, ".husky/pre-push", "npm test\nnpm run typecheck\n")
+	writeHookFile(t, root, ".husky/commit-msg", "npx --no -- commitlint --edit \"$1\"\n")
+	writeHookFile(t, root, "package.json", `{
+  "name": "web",
+  "lint-staged": {
+    "*.{js,ts}": ["eslint --fix", "prettier --write"],
+    "*.css": "stylelint"
+  }
+}`)
+
+	res, err := huskyTool{}.Migrate(root)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := strings.Join([]string{
+		`husky:lint-staged:eslint --fix=eslint --fix . [*.{js,ts}]`,
+		`husky:lint-staged:prettier --write=prettier --write . [*.{js,ts}]`,
+		`husky:lint-staged:stylelint=stylelint . [*.css]`,
+		`husky:pre-push:npm test=npm test`,
+		`husky:pre-push:npm run typecheck=npm run typecheck`,
+	}, "\n")
+	if got := checkSummary(res.Checks); got != want {
+		t.Fatalf("checks:\n%s\nwant:\n%s", got, want)
+	}
+	if !res.Parallel {
+		t.Fatalf("lint-staged with several globs should run in parallel")
+	}
+	if len(res.Unsupported) != 1 || !strings.HasPrefix(res.Unsupported[0], "commit-msg") {
+		t.Fatalf("unexpected unsupported report %v", res.Unsupported)
+	}
+}
+
+func TestHuskyKeepsScriptsWithShellLogicWhole(t *testing.T) {
+	root := t.TempDir()
+	writeHookFile(t, root, ".husky/pre-push", "if [ \"$CI\" = true ]; then\n  exit 0\nfi\nnpm test\n")
+
+	res, err := huskyTool{}.Migrate(root)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if got := checkSummary(res.Checks); got != "husky:pre-push=sh .husky/pre-push" {
+		t.Fatalf("unexpected checks %q", got)
+	}
+}
+
+func TestPreCommitDropsPythonOnlyPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeHookFile(t, root, PreCommitConfigFile, `
+exclude: |
+  (?x)^(
+      docs/generated/|
+      vendor/
+  )
+repos:
+  - repo: local
+    hooks:
+      - id: ruff
+        entry: ruff check
+        language: system
+        types: [python]
+        files: ^(?!tests/).*\.py$
+      - id: go-vet
+        entry: go vet ./...
+        language: system
+        pass_filenames: false
+        exclude: ^third_party/
+`)
+
+	res, err := preCommitTool{}.Migrate(root)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	want := strings.Join([]string{
+		`pre-commit:ruff=ruff check . [*.py *.pyi]`,
+		`pre-commit:go-vet=go vet ./... [!re:^third_party/]`,
+	}, "\n")
+	if got := checkSummary(res.Checks); got != want {
+		t.Fatalf("checks:\n%s\nwant:\n%s", got, want)
+	}
+	if note := res.Checks[0].Note; !strings.Contains(note, `files pattern "^(?!tests/).*\\.py$" dropped`) || !strings.Contains(note, "top-level exclude pattern") {
+		t.Fatalf("expected notes for both dropped patterns, got %q", note)
+	}
+	cfgPath := filepath.Join(root, ".buildbouncer", "config.yaml")
+	if err := config.Save(cfgPath, &config.Config{Version: 1, Checks: res.Checks}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := config.Load(cfgPath); err != nil {
+		t.Fatalf("migrated checks should load: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// PreCommitConfigFile is the pre-commit framework's configuration file.
+const PreCommitConfigFile = ".pre-commit-config.yaml"
+
+type preCommitConfig struct {
+	DefaultStages config.StringList `yaml:"default_stages"`
+	Files         string            `yaml:"files"`
+	Exclude       string            `yaml:"exclude"`
+	Repos         []preCommitRepo   `yaml:"repos"`
+}
+
+type preCommitRepo struct {
+	Repo  string          `yaml:"repo"`
+	Rev   string          `yaml:"rev"`
+	Hooks []preCommitHook `yaml:"hooks"`
+}
+
+type preCommitHook struct {
+	ID            string            `yaml:"id"`
+	Entry         string            `yaml:"entry"`
+	Language      string            `yaml:"language"`
+	Args          []string          `yaml:"args"`
+	Files         string            `yaml:"files"`
+	Exclude       string            `yaml:"exclude"`
+	Types         config.StringList `yaml:"types"`
+	TypesOr       config.StringList `yaml:"types_or"`
+	ExcludeTypes  config.StringList `yaml:"exclude_types"`
+	PassFilenames *bool             `yaml:"pass_filenames"`
+	AlwaysRun     bool              `yaml:"always_run"`
+	Stages        config.StringList `yaml:"stages"`
+}
+
+// preCommitTypeGlobs maps pre-commit's identify file types to the paths they cover.
+// Generic tags (file, text, executable, ...) match everything and are left out.
+var preCommitTypeGlobs = map[string][]string{
+	"bash":       {"*.bash", "*.sh"},
+	"c":          {"*.c", "*.h"},
+	"c++":        {"*.cpp", "*.cc", "*.cxx", "*.hpp", "*.hh"},
+	"c#":         {"*.cs"},
+	"css":        {"*.css"},
+	"dart":       {"*.dart"},
+	"dockerfile": {"Dockerfile", "*.dockerfile"},
+	"go":         {"*.go"},
+	"go-mod":     {"go.mod"},
+	"go-sum":     {"go.sum"},
+	"html":       {"*.html", "*.htm"},
+	"ini":        {"*.ini", "*.cfg"},
+	"java":       {"*.java"},
+	"javascript": {"*.js", "*.mjs", "*.cjs"},
+	"json":       {"*.json"},
+	"jsx":        {"*.jsx"},
+	"kotlin":     {"*.kt", "*.kts"},
+	"lua":        {"*.lua"},
+	"markdown":   {"*.md", "*.markdown"},
+	"php":        {"*.php"},
+	"proto":      {"*.proto"},
+	"pyi":        {"*.pyi"},
+	"python":     {"*.py", "*.pyi"},
+	"ruby":       {"*.rb", "*.rake", "Gemfile", "Rakefile"},
+	"rust":       {"*.rs"},
+	"scala":      {"*.scala"},
+	"scss":       {"*.scss"},
+	"sh":         {"*.sh"},
+	"shell":      {"*.sh", "*.bash", "*.zsh"},
+	"sql":        {"*.sql"},
+	"swift":      {"*.swift"},
+	"terraform":  {"*.tf", "*.tfvars"},
+	"toml":       {"*.toml"},
+	"ts":         {"*.ts", "*.mts", "*.cts"},
+	"tsx":        {"*.tsx"},
+	"vue":        {"*.vue"},
+	"xml":        {"*.xml"},
+	"yaml":       {"*.yaml", "*.yml"},
+	"zsh":        {"*.zsh"},
+}
+
+var preCommitGenericTypes = map[string]bool{
+	"file": true, "text": true, "binary": true, "executable": true, "non-executable": true,
+	"symlink": true, "directory": true, "plain-text": true,
+}
+
+type preCommitTool struct{}
+
+func (preCommitTool) Name() string { return "pre-commit" }
+
+func (preCommitTool) Detect(root string) bool {
+	return fileExists(filepath.Join(root, PreCommitConfigFile))
+}
+
+func (preCommitTool) Migrate(root string) (Result, error) {
+	path := filepath.Join(root, PreCommitConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	var cfg preCommitConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Result{}, fmt.Errorf("%s: %w", PreCommitConfigFile, err)
+	}
+
+	var res Result
+	for _, repo := range cfg.Repos {
+		switch repo.Repo {
+		case "local":
+		case "meta":
+			res.Unsupported = append(res.Unsupported, fmt.Sprintf("meta: %s (pre-commit's own checks of its config)", preCommitHookIDs(repo.Hooks)))
+			continue
+		default:
+			label := repo.Repo
+			if repo.Rev != "" {
+				label += "@" + repo.Rev
+			}
+			res.Unsupported = append(res.Unsupported, fmt.Sprintf("%s: %s (remote hooks are installed by pre-commit; add equivalent checks by hand)", label, preCommitHookIDs(repo.Hooks)))
+			continue
+		}
+
+		for _, hook := range repo.Hooks {
+			check, reason := preCommitCheck(hook, cfg)
+			if reason != "" {
+				res.Unsupported = append(res.Unsupported, fmt.Sprintf("local: %s (%s)", hook.ID, reason))
+				continue
+			}
+			res.Checks = append(res.Checks, check)
+		}
+	}
+	res.Checks = uniqueNames(res.Checks)
+	return res, nil
+}
+
+// preCommitCheck converts one local hook, or explains why it can't be.
+func preCommitCheck(hook preCommitHook, cfg preCommitConfig) (config.Check, string) {
+	stages := hook.Stages
+	if len(stages) == 0 {
+		stages = cfg.DefaultStages
+	}
+	if !preCommitRunsBeforePush(stages) {
+		return config.Check{}, "runs at " + strings.Join(stages, ", ") + ", not before a push"
+	}
+
+	language := strings.TrimSpace(hook.Language)
+	entry := strings.TrimSpace(hook.Entry)
+	if entry == "" {
+		return config.Check{}, "no entry"
+	}
+	switch language {
+	case "system":
+	case "script":
+		if first := strings.Fields(entry)[0]; !filepath.IsAbs(first) && !strings.HasPrefix(first, "./") {
+			entry = "./" + entry
+		}
+	default:
+		return config.Check{}, fmt.Sprintf("language %s needs pre-commit to build its environment", language)
+	}
+
+	run := entry
+	for _, arg := range hook.Args {
+		run += " " + shellQuote(arg)
+	}
+	check := newCheck("pre-commit", hook.ID, run)
+	if hook.PassFilenames == nil || *hook.PassFilenames {
+		check.Run += " ."
+		addNote(&check, "pre-commit "+fileListNote)
+	}
+
+	if !hook.AlwaysRun {
+		var paths []string
+		files := preCommitPattern(&check, "files", hook.Files)
+		typeGlobs, typeNote := preCommitTypeFilter(hook.Types, hook.TypesOr)
+		switch {
+		case files != "":
+			paths = append(paths, "re:"+files)
+			if len(typeGlobs) > 0 {
+				addNote(&check, "types not applied on top of files")
+			}
+		case len(typeGlobs) > 0:
+			paths = append(paths, typeGlobs...)
+			addNote(&check, typeNote)
+		default:
+			if files := preCommitPattern(&check, "top-level files", cfg.Files); files != "" {
+				paths = append(paths, "re:"+files)
+			}
+		}
+		if exclude := preCommitPattern(&check, "exclude", hook.Exclude); exclude != "" {
+			paths = append(paths, "!re:"+exclude)
+		}
+		if exclude := preCommitPattern(&check, "top-level exclude", cfg.Exclude); exclude != "" && exclude != "^$" {
+			paths = append(paths, "!re:"+exclude)
+		}
+		for _, t := range hook.ExcludeTypes {
+			for _, glob := range preCommitTypeGlobs[t] {
+				paths = append(paths, "!"+glob)
+			}
+		}
+		check.Paths = paths
+	}
+	return check, ""
+}
+
+// preCommitPattern returns a files/exclude regex that Go can compile. pre-commit uses
+// Python's re, so verbose `(?x)` patterns, lookarounds, and backreferences are dropped
+// with a note: the hook then runs on more files, never fewer.
+func preCommitPattern(check *config.Check, field string, pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return ""
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		addNote(check, fmt.Sprintf("%s pattern %q dropped (Python-only regex syntax)", field, pattern))
+		return ""
+	}
+	return pattern
+}
+
+// preCommitTypeFilter turns `types` (all must match) and `types_or` (any may match) into
+// globs. Only one specific type can be honored from `types`; a note says when others
+// were dropped.
+func preCommitTypeFilter(types []string, typesOr []string) ([]string, string) {
+	var globs []string
+	var unknown []string
+	specific := 0
+	for _, t := range types {
+		if preCommitGenericTypes[t] {
+			continue
+		}
+		specific++
+		if specific > 1 {
+			continue
+		}
+		if g, ok := preCommitTypeGlobs[t]; ok {
+			globs = append(globs, g...)
+		} else {
+			unknown = append(unknown, t)
+		}
+	}
+	if specific == 0 {
+		for _, t := range typesOr {
+			if preCommitGenericTypes[t] {
+				return nil, ""
+			}
+			if g, ok := preCommitTypeGlobs[t]; ok {
+				globs = append(globs, g...)
+			} else {
+				unknown = append(unknown, t)
+			}
+		}
+	}
+
+	var notes []string
+	if specific > 1 {
+		notes = append(notes, "only the first of types "+strings.Join(types, ", ")+" is applied")
+	}
+	if len(unknown) > 0 {
+		notes = append(notes, "unknown file types "+strings.Join(unknown, ", ")+" not applied")
+		if len(globs) == 0 {
+			return nil, strings.Join(notes, "; ")
+		}
+	}
+	return globs, strings.Join(notes, "; ")
+}
+
+func preCommitRunsBeforePush(stages []string) bool {
+	if len(stages) == 0 {
+		return true
+	}
+	for _, stage := range stages {
+		switch strings.TrimSpace(stage) {
+		case "pre-commit", "commit", "pre-push", "push":
+			return true
+		}
+	}
+	return false
+}
+
+func preCommitHookIDs(hooks []preCommitHook) string {
+	ids := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		ids = append(ids, h.ID)
+	}
+	if len(ids) == 0 {
+		return "no hooks"
+	}
+	return strings.Join(ids, ", ")
+}
+
+// shellQuote quotes an argument for a POSIX shell when it needs it.
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\$`*?[]{}()<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
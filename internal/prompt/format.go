@@ -39,10 +39,17 @@ func FormatPrompt(stderr io.Writer, report runner.Report, protectionLevel string
 			fmt.Fprintln(stderr, tui.Bold("  "+category+":"))
 			for _, name := range failures {
 				fmt.Fprintln(stderr, tui.Cross(name))
+				for _, line := range FailedTestLines(report.FailedTests[name], promptFailedTests) {
+					fmt.Fprintln(stderr, tui.Dim("      "+line))
+				}
 			}
 			fmt.Fprintln(stderr, "")
 		}
 	}
+	if len(report.FailedTests) > 0 {
+		fmt.Fprintln(stderr, tui.Dim("  Rerun only the failed tests: build-bouncer rerun --failed-tests"))
+		fmt.Fprintln(stderr, "")
+	}
 
 	// Protection level info
 	fmt.Fprintln(stderr, tui.Dim("  ───────────────────────────────────────────────────────────"))
@@ -69,6 +76,22 @@ func FormatPrompt(stderr io.Writer, report runner.Report, protectionLevel string
 	fmt.Fprintln(stderr, "")
 }
 
+// promptFailedTests caps the failed tests listed per check in the prompt.
+const promptFailedTests = 10
+
+// FailedTestLines lists failed tests one per line, at most limit of them.
+func FailedTestLines(tests []runner.TestResult, limit int) []string {
+	lines := make([]string, 0, min(len(tests), limit+1))
+	for i, test := range tests {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("... and %d more failed tests", len(tests)-i))
+			break
+		}
+		lines = append(lines, test.String())
+	}
+	return lines
+}
+
 // categorizeFailuresDetailed returns a map of category -> failure names
 func categorizeFailuresDetailed(report runner.Report) map[string][]string {
 	categories := make(map[string][]string)
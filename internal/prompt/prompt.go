@@ -12,13 +12,21 @@ import (
 
 // Result represents the user's response to a prompt
 type Result struct {
-	Override bool // Whether user wants to override and push anyway
-	Abort    bool // Whether user wants to abort
+	Override bool   // Whether user wants to override and push anyway
+	Abort    bool   // Whether user wants to abort
+	Fix      string // FixCommit or FixAmend when the user wants the fixers run instead
 }
 
+// Answers to the fixer offer: commit the fixes as a new commit, or fold them into HEAD.
+const (
+	FixCommit = "commit"
+	FixAmend  = "amend"
+)
+
 // AskOverride displays an interactive prompt asking if the user wants to push despite failures.
-// Returns true if user wants to override (push anyway), false otherwise.
-func AskOverride(stdin io.Reader, stdout, stderr io.Writer, report runner.Report, protectionLevel string) (Result, error) {
+// When fixable names failed checks that have a `fix:` command, it also offers to run
+// their fixers and commit (or amend) the result; strict mode offers only that.
+func AskOverride(stdin io.Reader, stdout, stderr io.Writer, report runner.Report, protectionLevel string, fixable []string) (Result, error) {
 	if stdin == nil {
 		stdin = os.Stdin
 	}
@@ -29,17 +37,26 @@ func AskOverride(stdin io.Reader, stdout, stderr io.Writer, report runner.Report
 		stderr = os.Stderr
 	}
 
+	// Display beautifully formatted prompt
+	FormatPrompt(stderr, report, protectionLevel)
+
 	// Protection-level-specific handling
-	if protectionLevel == "strict" {
-		FormatPrompt(stderr, report, protectionLevel)
+	strict := protectionLevel == "strict"
+	if strict && len(fixable) == 0 {
 		return Result{Override: false, Abort: true}, nil
 	}
 
-	// Display beautifully formatted prompt
-	FormatPrompt(stderr, report, protectionLevel)
-
 	// Prompt for input
-	fmt.Fprint(stderr, "  Push anyway? [y/N]: ")
+	if len(fixable) > 0 {
+		fmt.Fprintf(stderr, "  Fixable: %s\n", strings.Join(fixable, ", "))
+		if strict {
+			fmt.Fprint(stderr, "  Run fixers and [c]ommit or [a]mend? [c/a/N]: ")
+		} else {
+			fmt.Fprint(stderr, "  Push anyway, or run fixers and [c]ommit or [a]mend? [y/c/a/N]: ")
+		}
+	} else {
+		fmt.Fprint(stderr, "  Push anyway? [y/N]: ")
+	}
 
 	scanner := bufio.NewScanner(stdin)
 	if !scanner.Scan() {
@@ -47,7 +64,13 @@ func AskOverride(stdin io.Reader, stdout, stderr io.Writer, report runner.Report
 	}
 
 	response := strings.ToLower(strings.TrimSpace(scanner.Text()))
-	override := response == "y" || response == "yes"
+	switch {
+	case len(fixable) > 0 && (response == "c" || response == "commit"):
+		return Result{Fix: FixCommit}, nil
+	case len(fixable) > 0 && (response == "a" || response == "amend"):
+		return Result{Fix: FixAmend}, nil
+	}
+	override := !strict && (response == "y" || response == "yes")
 
 	return Result{Override: override, Abort: !override}, nil
 }
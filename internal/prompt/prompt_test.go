@@ -0,0 +1,62 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/runner"
+)
+
+func TestAskOverride(t *testing.T) {
+	report := runner.Report{Failures: []string{"go:fmt", "go:test"}}
+	fixable := []string{"go:fmt"}
+	cases := []struct {
+		name     string
+		level    string
+		fixable  []string
+		input    string
+		want     Result
+		question string
+	}{
+		{name: "yes pushes", level: "moderate", input: "y\n", want: Result{Override: true}, question: "Push anyway? [y/N]"},
+		{name: "full yes pushes", level: "lax", input: "YES\n", want: Result{Override: true}, question: "Push anyway? [y/N]"},
+		{name: "empty aborts", level: "moderate", input: "\n", want: Result{Abort: true}, question: "Push anyway? [y/N]"},
+		{name: "no input aborts", level: "moderate", input: "", want: Result{Abort: true}, question: "Push anyway? [y/N]"},
+		{name: "fix answer without fixers aborts", level: "moderate", input: "c\n", want: Result{Abort: true}, question: "Push anyway? [y/N]"},
+		{name: "c commits fixes", level: "moderate", fixable: fixable, input: "c\n", want: Result{Fix: FixCommit}, question: "[y/c/a/N]"},
+		{name: "commit commits fixes", level: "lax", fixable: fixable, input: " commit \n", want: Result{Fix: FixCommit}, question: "[y/c/a/N]"},
+		{name: "a amends fixes", level: "moderate", fixable: fixable, input: "a\n", want: Result{Fix: FixAmend}, question: "[y/c/a/N]"},
+		{name: "yes still pushes with fixers", level: "moderate", fixable: fixable, input: "y\n", want: Result{Override: true}, question: "[y/c/a/N]"},
+		{name: "no aborts with fixers", level: "moderate", fixable: fixable, input: "n\n", want: Result{Abort: true}, question: "[y/c/a/N]"},
+		{name: "strict without fixers never asks", level: "strict", input: "y\n", want: Result{Abort: true}},
+		{name: "strict commits fixes", level: "strict", fixable: fixable, input: "c\n", want: Result{Fix: FixCommit}, question: "[c/a/N]"},
+		{name: "strict amends fixes", level: "strict", fixable: fixable, input: "amend\n", want: Result{Fix: FixAmend}, question: "[c/a/N]"},
+		{name: "strict refuses yes", level: "strict", fixable: fixable, input: "y\n", want: Result{Abort: true}, question: "[c/a/N]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			got, err := AskOverride(bytes.NewReader([]byte(tc.input)), &stdout, &stderr, report, tc.level, tc.fixable)
+			if err != nil {
+				t.Fatalf("AskOverride: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+			out := stderr.String()
+			if tc.question == "" {
+				if strings.Contains(out, "N]: ") {
+					t.Fatalf("expected no question, got:\n%s", out)
+				}
+				return
+			}
+			if !strings.Contains(out, tc.question) {
+				t.Fatalf("expected %q in prompt:\n%s", tc.question, out)
+			}
+			if len(tc.fixable) > 0 && !strings.Contains(out, "Fixable: go:fmt") {
+				t.Fatalf("expected the fixable checks listed:\n%s", out)
+			}
+		})
+	}
+}
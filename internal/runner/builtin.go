@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/berniemackie97/build-bouncer/internal/builtin"
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// runBuiltin performs a `builtin:` check in-process. Its output goes where a command's
+// would: the tail, the terminal in verbose mode, and a log kept only on failure.
+func runBuiltin(
+	parentContext context.Context,
+	repoRoot string,
+	workingDirectory string,
+	checkIndex int,
+	check config.Check,
+	options Options,
+) (runOutcome, error) {
+	if parentContext.Err() != nil {
+		return runOutcome{ExitCode: 1, Canceled: true}, nil
+	}
+
+	tailBuffer := newLimitedBuffer(128 * 1024)
+	var fullOutput bytes.Buffer
+	outputWriter := io.MultiWriter(&fullOutput, tailBuffer)
+	if options.Verbose {
+		outputWriter = io.MultiWriter(os.Stdout, &fullOutput, tailBuffer)
+	}
+
+	runContext := parentContext
+	if check.Timeout > 0 {
+		var cancelRun context.CancelFunc
+		runContext, cancelRun = context.WithTimeout(parentContext, check.Timeout)
+		defer cancelRun()
+	}
+
+	environment := applyEnvOverrides(os.Environ(), check.Env)
+	findings, err := builtin.Run(runContext, check, builtin.Env{
+		Root:     repoRoot,
+		Dir:      workingDirectory,
+		PushRefs: options.PushRefs,
+		Output:   outputWriter,
+		Environ:  environment,
+		Command: func(ctx context.Context, shell string, commandText string) *exec.Cmd {
+			execName, execArgs := resolveCommand(shell, commandText, "")
+			command := exec.CommandContext(ctx, execName, execArgs...) //nolint:gosec // command comes from user config by design
+			command.Env = adjustEnvForShell(execName, environment)
+			return command
+		},
+	})
+	if err == nil && len(findings) == 0 {
+		return runOutcome{ExitCode: 0, Tail: tailBuffer.String()}, nil
+	}
+
+	outcome := runOutcome{ExitCode: 1}
+	switch {
+	case runContext.Err() == context.DeadlineExceeded:
+		outcome.TimedOut = true
+		outcome.Timeout = check.Timeout
+	case parentContext.Err() != nil:
+		outcome.Canceled = true
+	case err != nil:
+		fmt.Fprintf(outputWriter, "error: %s: %v\n", check.Builtin, err)
+	}
+	outcome.Tail = tailBuffer.String()
+
+	logPath, logErr := checkLogPath(repoRoot, checkIndex, check.Name, options)
+	if logErr != nil {
+		return outcome, logErr
+	}
+	if writeErr := os.WriteFile(logPath, fullOutput.Bytes(), 0o644); writeErr != nil {
+		return outcome, writeErr
+	}
+	outcome.LogPath = logPath
+	return outcome, nil
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// FixResult is the outcome of one check's `fix:` command.
+type FixResult struct {
+	Check    string
+	ExitCode int
+	Skipped  string // why the fixer did not run, if it didn't
+	Err      error  // the fixer could not be started or timed out
+}
+
+// Failed reports whether the fixer ran and did not succeed.
+func (r FixResult) Failed() bool {
+	return r.Skipped == "" && (r.Err != nil || r.ExitCode != 0)
+}
+
+// RunFixers runs the `fix:` command of each check that has one, one at a time in config
+// order (fixers rewrite files; running two at once invites conflicts). Output goes to
+// output. Checks without a fixer, or that would be skipped on this machine, are reported
+// as skipped.
+func RunFixers(repoRoot string, configuration *config.Config, output io.Writer) ([]FixResult, error) {
+	interpolation := InterpolationFor(repoRoot)
+	results := make([]FixResult, 0, len(configuration.Checks))
+	for _, checkDefinition := range configuration.Checks {
+		check, err := configuration.ExpandCheck(checkDefinition, interpolation)
+		if err != nil {
+			return results, err
+		}
+		result := FixResult{Check: check.Name}
+		switch {
+		case strings.TrimSpace(check.Fix) == "":
+			result.Skipped = "no fix command"
+		default:
+			result.Skipped = checkSkipReason(check)
+		}
+		if result.Skipped != "" {
+			results = append(results, result)
+			continue
+		}
+
+		fmt.Fprintf(output, "==> fix %s: %s\n", check.Name, check.Fix)
+		result.ExitCode, result.Err = runFixer(repoRoot, check, output)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func runFixer(repoRoot string, check config.Check, output io.Writer) (int, error) {
+	workingDirectory := repoRoot
+	if strings.TrimSpace(check.Cwd) != "" {
+		workingDirectory = filepath.Join(repoRoot, filepath.FromSlash(check.Cwd))
+	}
+
+	runContext := context.Background()
+	if check.Timeout > 0 {
+		var cancelRun context.CancelFunc
+		runContext, cancelRun = context.WithTimeout(runContext, check.Timeout)
+		defer cancelRun()
+	}
+
+	// Builtin checks have no shell of their own; their fixers use the OS default.
+	execName, execArgs := resolveCommand(check.Shell, check.Fix, "")
+	command := exec.CommandContext(runContext, execName, execArgs...) //nolint:gosec // command comes from user config by design
+	command.Dir = workingDirectory
+	command.Stdout = output
+	command.Stderr = output
+	command.Env = adjustEnvForShell(execName, applyEnvOverrides(os.Environ(), check.Env))
+
+	err := command.Run()
+	if runContext.Err() == context.DeadlineExceeded {
+		return 1, fmt.Errorf("timed out after %s", check.Timeout)
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
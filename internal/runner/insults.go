@@ -391,6 +391,13 @@ func pickFailingCheck(failures []string) string {
 // extractDetail tries to pull one useful human hint from failure output.
 // Think file:line, failing test name, etc. It is intentionally best effort.
 func extractDetail(category string, report Report, preferredCheck string) string {
+	if diagnostics := report.FailureDiagnostics[preferredCheck]; len(diagnostics) > 0 {
+		diagnostic := headlineDiagnostic(diagnostics)
+		if location := diagnostic.Location(); location != "" {
+			return location
+		}
+		return diagnostic.Message
+	}
 	if outputText := report.FailureTails[preferredCheck]; strings.TrimSpace(outputText) != "" {
 		if extracted := extractDetailFromOutput(category, outputText); extracted != "" {
 			return extracted
@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GitHub problem matchers (https://github.com/actions/toolkit/blob/main/docs/problem-matchers.md).
+// Many toolchains ship them as .github/*-matcher.json; checks reference those files with
+// `problemMatchers:` and the runner evaluates them over the full failure log, the way
+// the Actions runner does over a step's output.
+
+// ProblemMatcher is one compiled entry of a matcher file's "problemMatcher" array.
+type ProblemMatcher struct {
+	Owner    string
+	Severity string // default severity for issues whose pattern has no severity group
+	patterns []matcherPattern
+}
+
+type matcherPattern struct {
+	Regexp    string `json:"regexp"`
+	File      int    `json:"file"`
+	FromPath  int    `json:"fromPath"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	Severity  int    `json:"severity"`
+	Code      int    `json:"code"`
+	Message   int    `json:"message"`
+	Loop      bool   `json:"loop"`
+
+	re *regexp.Regexp
+}
+
+// matcherPatterns accepts "pattern" as an array or as a single object.
+type matcherPatterns []matcherPattern
+
+func (p *matcherPatterns) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var single matcherPattern
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		*p = matcherPatterns{single}
+		return nil
+	}
+	var list []matcherPattern
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+type problemMatcherFile struct {
+	ProblemMatcher []struct {
+		Owner    string          `json:"owner"`
+		Severity string          `json:"severity"`
+		Pattern  matcherPatterns `json:"pattern"`
+	} `json:"problemMatcher"`
+}
+
+// LoadProblemMatchers reads and compiles matcher files (repo-relative or absolute).
+func LoadProblemMatchers(repoRoot string, files []string) ([]*ProblemMatcher, error) {
+	var matchers []*ProblemMatcher
+	for _, file := range files {
+		fullPath := file
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(repoRoot, filepath.FromSlash(file))
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("problemMatchers: %w", err)
+		}
+		loaded, err := parseProblemMatchers(data)
+		if err != nil {
+			return nil, fmt.Errorf("problemMatchers: %s: %w", file, err)
+		}
+		matchers = append(matchers, loaded...)
+	}
+	return matchers, nil
+}
+
+func parseProblemMatchers(data []byte) ([]*ProblemMatcher, error) {
+	var document problemMatcherFile
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.ProblemMatcher) == 0 {
+		return nil, errors.New(`no "problemMatcher" entries`)
+	}
+
+	matchers := make([]*ProblemMatcher, 0, len(document.ProblemMatcher))
+	for i, entry := range document.ProblemMatcher {
+		owner := strings.TrimSpace(entry.Owner)
+		if owner == "" {
+			return nil, fmt.Errorf("problemMatcher[%d]: owner is required", i)
+		}
+		severity := strings.ToLower(strings.TrimSpace(entry.Severity))
+		if severity != "" && severity != "error" && severity != "warning" && severity != "notice" {
+			return nil, fmt.Errorf("%s: severity must be error, warning or notice", owner)
+		}
+		if len(entry.Pattern) == 0 {
+			return nil, fmt.Errorf("%s: at least one pattern is required", owner)
+		}
+
+		hasMessage := false
+		patterns := make([]matcherPattern, len(entry.Pattern))
+		for j, pattern := range entry.Pattern {
+			last := j == len(entry.Pattern)-1
+			if pattern.Loop && !last {
+				return nil, fmt.Errorf("%s: pattern[%d]: only the last pattern can loop", owner, j)
+			}
+			if pattern.Loop && len(entry.Pattern) == 1 {
+				return nil, fmt.Errorf("%s: loop needs more than one pattern", owner)
+			}
+			if pattern.Loop && pattern.Message == 0 {
+				return nil, fmt.Errorf("%s: pattern[%d]: a looping pattern must set message", owner, j)
+			}
+			if strings.TrimSpace(pattern.Regexp) == "" {
+				return nil, fmt.Errorf("%s: pattern[%d]: regexp is required", owner, j)
+			}
+			re, err := regexp.Compile(pattern.Regexp)
+			if err != nil {
+				return nil, fmt.Errorf("%s: pattern[%d]: %w", owner, j, err)
+			}
+			for _, group := range []int{pattern.File, pattern.FromPath, pattern.Line, pattern.EndLine, pattern.Column, pattern.EndColumn, pattern.Severity, pattern.Code, pattern.Message} {
+				if group < 0 || group > re.NumSubexp() {
+					return nil, fmt.Errorf("%s: pattern[%d]: group %d is not in the regexp", owner, j, group)
+				}
+			}
+			hasMessage = hasMessage || pattern.Message > 0
+			pattern.re = re
+			patterns[j] = pattern
+		}
+		if !hasMessage {
+			return nil, fmt.Errorf("%s: no pattern sets message", owner)
+		}
+		matchers = append(matchers, &ProblemMatcher{Owner: owner, Severity: severity, patterns: patterns})
+	}
+	return matchers, nil
+}
+
+// issueMatch accumulates the values of one issue across the lines of a multi-line match.
+type issueMatch struct {
+	file, fromPath, line, endLine, column, endColumn, severity, code, message string
+}
+
+// matcherRun is the per-log state of one matcher: state[i] is the running match after
+// patterns[0..i] matched consecutive lines.
+type matcherRun struct {
+	matcher *ProblemMatcher
+	state   []*issueMatch
+}
+
+// match feeds one line to the matcher. It mirrors the Actions runner: patterns are tried
+// last to first so a line can complete a running match and the next pattern never sees
+// the line that started it; a looping last pattern keeps its running match alive until
+// a line fails to match it.
+func (run *matcherRun) match(line string) (issueMatch, bool) {
+	patterns := run.matcher.patterns
+	if len(patterns) == 1 {
+		groups := patterns[0].re.FindStringSubmatch(line)
+		if groups == nil {
+			return issueMatch{}, false
+		}
+		return combineIssue(nil, patterns[0], groups), true
+	}
+
+	for i := len(patterns) - 1; i >= 0; i-- {
+		var running *issueMatch
+		if i > 0 {
+			running = run.state[i-1]
+			if running == nil {
+				continue
+			}
+		}
+		pattern := patterns[i]
+		last := i == len(patterns)-1
+		groups := pattern.re.FindStringSubmatch(line)
+		switch {
+		case groups != nil && last:
+			run.reset()
+			if pattern.Loop {
+				run.state[i-1] = running
+			}
+			return combineIssue(running, pattern, groups), true
+		case groups != nil:
+			issue := combineIssue(running, pattern, groups)
+			run.state[i] = &issue
+		case last:
+			run.state[i-1] = nil
+		default:
+			run.state[i] = nil
+		}
+	}
+	return issueMatch{}, false
+}
+
+func (run *matcherRun) reset() {
+	for i := range run.state {
+		run.state[i] = nil
+	}
+}
+
+func combineIssue(running *issueMatch, pattern matcherPattern, groups []string) issueMatch {
+	var issue issueMatch
+	if running != nil {
+		issue = *running
+	}
+	set := func(field *string, group int) {
+		if group > 0 {
+			*field = strings.TrimSpace(groups[group])
+		}
+	}
+	set(&issue.file, pattern.File)
+	set(&issue.fromPath, pattern.FromPath)
+	set(&issue.line, pattern.Line)
+	set(&issue.endLine, pattern.EndLine)
+	set(&issue.column, pattern.Column)
+	set(&issue.endColumn, pattern.EndColumn)
+	set(&issue.severity, pattern.Severity)
+	set(&issue.code, pattern.Code)
+	set(&issue.message, pattern.Message)
+	return issue
+}
+
+func (issue issueMatch) diagnostic(matcher *ProblemMatcher) Diagnostic {
+	file := issue.file
+	if issue.fromPath != "" && file != "" && !path.IsAbs(filepath.ToSlash(file)) {
+		// fromPath names the project file the path is relative to (a .csproj, say).
+		base := filepath.ToSlash(issue.fromPath)
+		if path.Ext(base) != "" {
+			base = path.Dir(base)
+		}
+		file = path.Join(base, filepath.ToSlash(file))
+	}
+	severity := issue.severity
+	if severity == "" {
+		severity = matcher.Severity
+	}
+	diagnostic := Diagnostic{
+		Source:   matcher.Owner,
+		File:     file,
+		Message:  issue.message,
+		Rule:     issue.code,
+		Severity: normalizeSeverity(severity),
+	}
+	diagnostic.Line, _ = strconv.Atoi(issue.line)
+	diagnostic.EndLine, _ = strconv.Atoi(issue.endLine)
+	diagnostic.Col, _ = strconv.Atoi(issue.column)
+	diagnostic.EndCol, _ = strconv.Atoi(issue.endColumn)
+	return diagnostic
+}
+
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	switch {
+	case strings.HasPrefix(severity, "warn"):
+		return "warning"
+	case severity == "notice", severity == "note", severity == "info":
+		return "notice"
+	default:
+		return "error"
+	}
+}
+
+var reANSIEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// MatchProblems runs matchers over every line of log. As in Actions, the first matcher
+// that produces an issue for a line wins, and issues without a message are dropped.
+func MatchProblems(matchers []*ProblemMatcher, log io.Reader) ([]Diagnostic, error) {
+	if len(matchers) == 0 {
+		return nil, nil
+	}
+	runs := make([]*matcherRun, len(matchers))
+	for i, matcher := range matchers {
+		runs[i] = &matcherRun{matcher: matcher, state: make([]*issueMatch, len(matcher.patterns)-1)}
+	}
+
+	var diagnostics []Diagnostic
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() && len(diagnostics) < maxDiagnostics {
+		line := reANSIEscape.ReplaceAllString(strings.TrimRight(scanner.Text(), "\r"), "")
+		for _, run := range runs {
+			issue, ok := run.match(line)
+			if !ok {
+				continue
+			}
+			if issue.message != "" {
+				diagnostics = append(diagnostics, issue.diagnostic(run.matcher))
+			}
+			break
+		}
+	}
+	return diagnostics, scanner.Err()
+}
+
+// matchFailureLog runs a failed check's matchers over its full log, or over the output
+// tail when the log is gone.
+func matchFailureLog(matchers []*ProblemMatcher, outcome runOutcome) []Diagnostic {
+	if len(matchers) == 0 {
+		return nil
+	}
+	var log io.Reader = strings.NewReader(outcome.Tail)
+	if strings.TrimSpace(outcome.LogPath) != "" {
+		if file, err := os.Open(outcome.LogPath); err == nil {
+			defer func() { _ = file.Close() }()
+			log = file
+		}
+	}
+	diagnostics, _ := MatchProblems(matchers, log)
+	return diagnostics
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// eslintStylishMatcher is the multi-line example from the Actions problem matcher docs.
+const eslintStylishMatcher = `{
+  "problemMatcher": [
+    {
+      "owner": "eslint-stylish",
+      "pattern": [
+        { "regexp": "^([^\\s].*)$", "file": 1 },
+        {
+          "regexp": "^\\s+(\\d+):(\\d+)\\s+(error|warning|info)\\s+(.*)\\s\\s+(.*)$",
+          "line": 1, "column": 2, "severity": 3, "message": 4, "code": 5,
+          "loop": true
+        }
+      ]
+    }
+  ]
+}`
+
+func mustParseMatchers(t *testing.T, document string) []*ProblemMatcher {
+	t.Helper()
+	matchers, err := parseProblemMatchers([]byte(document))
+	if err != nil {
+		t.Fatalf("parse matchers: %v", err)
+	}
+	return matchers
+}
+
+func TestMatchProblemsLoopsOverMultiLinePatterns(t *testing.T) {
+	matchers := mustParseMatchers(t, eslintStylishMatcher)
+	log := strings.Join([]string{
+		"test.js",
+		"  1:1   error  Missing \"use strict\" statement                 strict",
+		"  5:10  warning  'addOne' is defined but never used      no-unused-vars",
+		"",
+		"foo.js",
+		"\x1b[2m  36:10\x1b[22m  error  Expected parentheses around arrow function argument  arrow-parens",
+		"  37:13  error  Expected parentheses around arrow function argument  arrow-parens",
+		"  not an issue line",
+		"  38:1  error  orphan line after the loop broke  semi",
+		"",
+		"✖ 4 problems",
+	}, "\n")
+
+	diagnostics, err := MatchProblems(matchers, strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("MatchProblems: %v", err)
+	}
+	var got []string
+	for _, diagnostic := range diagnostics {
+		got = append(got, diagnostic.Severity+" "+diagnostic.String())
+	}
+	want := []string{
+		`error test.js:1:1: Missing "use strict" statement [strict]`,
+		"warning test.js:5:10: 'addOne' is defined but never used [no-unused-vars]",
+		"error foo.js:36:10: Expected parentheses around arrow function argument [arrow-parens]",
+		"error foo.js:37:13: Expected parentheses around arrow function argument [arrow-parens]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if diagnostics[0].Source != "eslint-stylish" {
+		t.Fatalf("source = %q", diagnostics[0].Source)
+	}
+}
+
+func TestMatchProblemsWithoutLoopNeedsConsecutiveLines(t *testing.T) {
+	matchers := mustParseMatchers(t, `{"problemMatcher": [{
+	  "owner": "acme", "severity": "warning",
+	  "pattern": [
+	    {"regexp": "^In (\\S+) \\(from (\\S+)\\):$", "file": 1, "fromPath": 2},
+	    {"regexp": "^  line (\\d+): (.+)$", "line": 1, "message": 2}
+	  ]
+	}, {
+	  "owner": "single",
+	  "pattern": {"regexp": "^FATAL (.+)$", "message": 1}
+	}]}`)
+	log := "In a.c (from proj/app.vcxproj):\n  line 3: shadowed\n  line 4: not part of a match\nIn b.c (from proj/app.vcxproj):\nnoise\n  line 9: dropped\nFATAL out of gizmos\n"
+
+	diagnostics, err := MatchProblems(matchers, strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("MatchProblems: %v", err)
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diagnostics)
+	}
+	if d := diagnostics[0]; d.File != "proj/a.c" || d.Line != 3 || d.Severity != "warning" || d.Message != "shadowed" {
+		t.Fatalf("unexpected multi-line diagnostic %+v", d)
+	}
+	if d := diagnostics[1]; d.Source != "single" || d.Message != "out of gizmos" || d.Severity != "error" || d.File != "" {
+		t.Fatalf("unexpected single-line diagnostic %+v", d)
+	}
+	if got := headlineDiagnostic(diagnostics); got.Source != "single" {
+		t.Fatalf("headline should prefer the error, got %+v", got)
+	}
+}
+
+func TestParseProblemMatchersRejectsBadFiles(t *testing.T) {
+	for _, tc := range []struct{ document, want string }{
+		{`{}`, "no \"problemMatcher\""},
+		{`{"problemMatcher": [{"pattern": [{"regexp": "(.*)", "message": 1}]}]}`, "owner is required"},
+		{`{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(.*)", "file": 1}]}]}`, "no pattern sets message"},
+		{`{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(.*)", "message": 1, "loop": true}]}]}`, "more than one pattern"},
+		{`{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(.*)", "file": 1, "loop": true}, {"regexp": "(.*)", "message": 1}]}]}`, "only the last pattern"},
+		{`{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(.*)", "message": 2}]}]}`, "group 2"},
+		{`{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(?<=a)b", "message": 0}]}]}`, "pattern[0]"},
+		{`{"problemMatcher": [{"owner": "x", "severity": "fatal", "pattern": [{"regexp": "(.*)", "message": 1}]}]}`, "severity"},
+	} {
+		if _, err := parseProblemMatchers([]byte(tc.document)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected error containing %q, got %v for %s", tc.want, err, tc.document)
+		}
+	}
+}
+
+func TestRunAllReportMatchesTheFullLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture command uses sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available on this system")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("create git dir: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".github"), 0o755); err != nil {
+		t.Fatalf("create .github: %v", err)
+	}
+	matcher := `{"problemMatcher": [{"owner": "acme", "pattern": [{"regexp": "^ACME (\\S+):(\\d+) (.+)$", "file": 1, "line": 2, "message": 3}]}]}`
+	if err := os.WriteFile(filepath.Join(root, ".github", "acme-matcher.json"), []byte(matcher), 0o644); err != nil {
+		t.Fatalf("write matcher: %v", err)
+	}
+
+	// The diagnostic scrolls out of the 128 KiB tail; only the full log still has it.
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{{
+			Name:            "acme",
+			Run:             "echo 'ACME src/x.acme:7 bad vibes'; i=0; while [ $i -lt 3000 ]; do echo 'padding padding padding padding padding padding'; i=$((i+1)); done; exit 2",
+			ProblemMatchers: config.StringList{".github/acme-matcher.json"},
+		}},
+	}
+	rep, err := RunAllReport(root, cfg, Options{MaxParallel: 1})
+	if err != nil {
+		t.Fatalf("RunAllReport error: %v", err)
+	}
+	if strings.Contains(rep.FailureTails["acme"], "ACME") {
+		t.Fatalf("fixture should push the diagnostic out of the tail")
+	}
+	if got, want := rep.FailureHeadlines["acme"], "src/x.acme:7: bad vibes"; got != want {
+		t.Fatalf("headline = %q, want %q", got, want)
+	}
+	if got := extractDetail("any", rep, "acme"); got != "src/x.acme:7" {
+		t.Fatalf("insult detail = %q", got)
+	}
+
+	cfg.Checks[0].ProblemMatchers = config.StringList{".github/missing-matcher.json"}
+	if _, err := RunAllReport(root, cfg, Options{MaxParallel: 1}); err == nil || !strings.Contains(err.Error(), "problemMatchers") {
+		t.Fatalf("expected a missing matcher file to fail the run, got %v", err)
+	}
+}
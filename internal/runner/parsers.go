@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// maxDiagnostics caps how many diagnostics are kept per failing check.
+const maxDiagnostics = 50
+
+// Diagnostic is one problem a problem matcher or config parser found in a check's output.
+type Diagnostic struct {
+	Source   string `json:"source,omitempty"` // matcher owner or parser name
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Col      int    `json:"col,omitempty"`
+	EndLine  int    `json:"endLine,omitempty"`
+	EndCol   int    `json:"endCol,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"` // error, warning or notice; empty from parsers, which only see errors
+}
+
+// String formats the diagnostic the way compilers do: file:line:col: message [rule].
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(d.Location())
+	if d.File != "" && d.Message != "" {
+		b.WriteString(": ")
+	}
+	b.WriteString(d.Message)
+	if d.Rule != "" {
+		fmt.Fprintf(&b, " [%s]", d.Rule)
+	}
+	return b.String()
+}
+
+// Location formats file:line:col, leaving out what is unknown.
+func (d Diagnostic) Location() string {
+	switch {
+	case d.File == "":
+		return ""
+	case d.Line <= 0:
+		return d.File
+	case d.Col <= 0:
+		return fmt.Sprintf("%s:%d", d.File, d.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Col)
+	}
+}
+
+// headlineDiagnostic picks the diagnostic to headline a failure with: the first error,
+// or the first diagnostic when there are only warnings and notices.
+func headlineDiagnostic(diagnostics []Diagnostic) Diagnostic {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == "" || diagnostic.Severity == "error" {
+			return diagnostic
+		}
+	}
+	return diagnostics[0]
+}
+
+// ParseDiagnostics runs the parsers over output and returns what they matched. Parsers
+// are tried in order and the first one that matches anything wins, so a check's own
+// `parser:` takes precedence over shared ones.
+func ParseDiagnostics(parsers []config.Parser, output string) []Diagnostic {
+	if len(parsers) == 0 {
+		return nil
+	}
+	normalizedOutput := normalizeOutputNewlines(output)
+	for _, parser := range parsers {
+		re, err := parser.Compile()
+		if err != nil {
+			// Load already rejected bad parsers; nothing useful to do here.
+			continue
+		}
+		var diagnostics []Diagnostic
+		for _, match := range re.FindAllStringSubmatch(normalizedOutput, maxDiagnostics) {
+			diagnostic := Diagnostic{Source: parser.Name}
+			for groupIndex, group := range re.SubexpNames() {
+				value := strings.TrimSpace(match[groupIndex])
+				switch group {
+				case "file":
+					diagnostic.File = value
+				case "line":
+					diagnostic.Line, _ = strconv.Atoi(value)
+				case "col":
+					diagnostic.Col, _ = strconv.Atoi(value)
+				case "message":
+					diagnostic.Message = value
+				case "rule":
+					diagnostic.Rule = value
+				}
+			}
+			if diagnostic.File == "" && diagnostic.Message == "" {
+				continue
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+		if len(diagnostics) > 0 {
+			return diagnostics
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func TestParseDiagnosticsUsesFirstMatchingParser(t *testing.T) {
+	parsers := []config.Parser{
+		{Name: "none", Regex: `^never (?P<message>.+)$`},
+		{Name: "acme", Regex: `^ACME (?P<rule>[A-Z]+\d+) (?P<file>[^:]+):(?P<line>\d+):(?P<col>\d+) (?P<message>.+)$`},
+		{Name: "later", Regex: `^(?P<message>.+)$`},
+	}
+	output := "building...\r\nACME QX12 src/a.c:4:2 widget is frobbed\r\nACME QX7 src/b.c:9:1 too many gizmos\r\n"
+
+	diagnostics := ParseDiagnostics(parsers, output)
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diagnostics)
+	}
+	first := diagnostics[0]
+	if first.Source != "acme" || first.File != "src/a.c" || first.Line != 4 || first.Col != 2 || first.Rule != "QX12" {
+		t.Fatalf("unexpected diagnostic %+v", first)
+	}
+	if got, want := first.String(), "src/a.c:4:2: widget is frobbed [QX12]"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+	if got := (Diagnostic{Message: "boom"}).String(); got != "boom" {
+		t.Fatalf("message-only String() = %q", got)
+	}
+	if ParseDiagnostics(parsers[:1], output) != nil {
+		t.Fatalf("expected no diagnostics when no parser matches")
+	}
+}
+
+func TestRunAllReportHeadlineFromConfigParser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture command uses sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available on this system")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("create git dir: %v", err)
+	}
+
+	// The first line would otherwise match the built-in "error:" rule.
+	cfg := &config.Config{
+		Version: 1,
+		Parsers: []config.Parser{{Name: "acme", Regex: `^ACME (?P<file>\S+) line (?P<line>\d+): (?P<message>.+)$`}},
+		Checks: []config.Check{
+			{Name: "acme", Parser: "acme", Run: "echo 'error: see below'; echo 'ACME pkg/x.acme line 12: bad vibes'; exit 3"},
+			{Name: "other", Run: "echo 'error: see below'; exit 3"},
+		},
+	}
+	rep, err := RunAllReport(root, cfg, Options{MaxParallel: 1})
+	if err != nil {
+		t.Fatalf("RunAllReport error: %v", err)
+	}
+	if got, want := rep.FailureHeadlines["acme"], "pkg/x.acme:12: bad vibes"; got != want {
+		t.Fatalf("headline = %q, want %q", got, want)
+	}
+	if len(rep.FailureDiagnostics["acme"]) != 1 {
+		t.Fatalf("expected diagnostics for acme, got %+v", rep.FailureDiagnostics)
+	}
+	if got := rep.FailureHeadlines["other"]; got != ExtractHeadline("other", "error: see below") {
+		t.Fatalf("checks without a parser keep the built-in rules, got %q", got)
+	}
+	if _, ok := rep.FailureDiagnostics["other"]; ok {
+		t.Fatalf("unexpected diagnostics for other")
+	}
+}
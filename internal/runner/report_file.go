@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// reportFileVersion is bumped when ReportFile changes incompatibly.
+const reportFileVersion = 1
+
+// ReportFile is the JSON form of a Report: what `check --report-json` writes, and the
+// record of the last run that `rerun` reads.
+type ReportFile struct {
+	Version  int            `json:"version"`
+	Profile  string         `json:"profile,omitempty"`
+	Passed   bool           `json:"passed"`
+	Failures []CheckFailure `json:"failures"`
+	Canceled []string       `json:"canceled,omitempty"`
+	Skipped  []CheckSkip    `json:"skipped,omitempty"`
+}
+
+// CheckFailure is one failed check in a ReportFile.
+type CheckFailure struct {
+	Check       string       `json:"check"`
+	Headline    string       `json:"headline,omitempty"`
+	Log         string       `json:"log,omitempty"`
+	FailedTests []TestResult `json:"failedTests,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// CheckSkip is one skipped check in a ReportFile.
+type CheckSkip struct {
+	Check  string `json:"check"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// File converts the report to its JSON form.
+func (r Report) File() ReportFile {
+	file := ReportFile{
+		Version:  reportFileVersion,
+		Profile:  r.Profile,
+		Passed:   len(r.Failures) == 0,
+		Failures: make([]CheckFailure, 0, len(r.Failures)),
+		Canceled: r.Canceled,
+	}
+	for _, name := range r.Failures {
+		file.Failures = append(file.Failures, CheckFailure{
+			Check:       name,
+			Headline:    r.FailureHeadlines[name],
+			Log:         r.LogFiles[name],
+			FailedTests: r.FailedTests[name],
+			Diagnostics: r.FailureDiagnostics[name],
+		})
+	}
+	for _, name := range r.Skipped {
+		file.Skipped = append(file.Skipped, CheckSkip{Check: name, Reason: r.SkipReasons[name]})
+	}
+	return file
+}
+
+// Failure returns the named check's failure, if it failed.
+func (f ReportFile) Failure(check string) (CheckFailure, bool) {
+	for _, failure := range f.Failures {
+		if failure.Check == check {
+			return failure, true
+		}
+	}
+	return CheckFailure{}, false
+}
+
+// WriteReportFile writes the report as JSON to path, creating its directory.
+func WriteReportFile(path string, report Report) error {
+	data, err := json.MarshalIndent(report.File(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadReportFile reads a report written by WriteReportFile.
+func ReadReportFile(path string) (ReportFile, error) {
+	var file ReportFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Version != reportFileVersion {
+		return file, fmt.Errorf("%s: unsupported report version %d", path, file.Version)
+	}
+	return file, nil
+}
+
+// LastReportPath is where `check` records its last report: next to the default log
+// directory, in the git dir when there is one.
+func LastReportPath(repoRoot string) string {
+	if gitDir, ok := resolveGitDir(repoRoot); ok {
+		return filepath.Join(gitDir, "build-bouncer", "last-report.json")
+	}
+	return filepath.Join(repoRoot, config.ConfigDirName, "last-report.json")
+}
@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// RerunTemplates are the built-in `rerun:` templates, by test runner. JUnit XML can
+// come from any tool, so it has none. {run} gets a trailing "--" for cargo test when the
+// command does not already have one.
+var RerunTemplates = map[string]string{
+	TestRunnerGo:     "{run} -run {regex}",
+	TestRunnerPytest: "{run} {tests}",
+	TestRunnerJest:   "{run} -t {regex}",
+	TestRunnerDotnet: "{run} --filter {filter}",
+	TestRunnerCargo:  "{run} --exact {tests}",
+}
+
+// rerunCommands are the commands a built-in template knows how to extend, by test
+// runner. Anything else (`make test`, `npm test`, a wrapper script) may not pass the
+// filter flags through, so it needs its own `rerun:`.
+var rerunCommands = map[string][]string{
+	TestRunnerGo:     {"go"},
+	TestRunnerPytest: {"pytest", "py.test"},
+	TestRunnerJest:   {"jest"},
+	TestRunnerDotnet: {"dotnet"},
+	TestRunnerCargo:  {"cargo"},
+}
+
+var reRerunPlaceholder = regexp.MustCompile(`\{(run|tests|regex|filter)\}`)
+
+// RerunCommand builds the command that repeats only tests, the failed tests of check,
+// from the check's `rerun:` template or the built-in one for their runner:
+//
+//	{run}    the check's run command
+//	{tests}  the test IDs, each quoted for the check's shell
+//	{regex}  ^(id1|id2)$ with the IDs escaped, quoted
+//	{filter} a dotnet test --filter expression, quoted
+func RerunCommand(check config.Check, tests []TestResult) (string, error) {
+	if len(tests) == 0 {
+		return "", fmt.Errorf("%s: no failed tests to rerun", check.Name)
+	}
+	runnerName := tests[0].Runner
+	template := strings.TrimSpace(check.Rerun)
+	if template == "" {
+		template = RerunTemplates[runnerName]
+		if template == "" {
+			return "", fmt.Errorf("%s: no rerun template for %s tests; set rerun: on the check", check.Name, runnerLabel(runnerName))
+		}
+		if !runsTestRunner(check.Run, runnerName) {
+			return "", fmt.Errorf("%s: run does not start with %s, so the built-in rerun template may not apply; set rerun: on the check", check.Name, strings.Join(rerunCommands[runnerName], " or "))
+		}
+	}
+
+	var ids, patterns, filters []string
+	seen := map[string]bool{}
+	for _, test := range tests {
+		id := test.rerunID()
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		patterns = append(patterns, regexp.QuoteMeta(id))
+		filters = append(filters, "FullyQualifiedName="+id)
+	}
+
+	quote := shellQuoter(check.Shell)
+	quotedIDs := make([]string, len(ids))
+	for i, id := range ids {
+		quotedIDs[i] = quote(id)
+	}
+	values := map[string]string{
+		"run":    rerunBase(check.Run, runnerName, check.Rerun == ""),
+		"tests":  strings.Join(quotedIDs, " "),
+		"regex":  quote("^(" + strings.Join(patterns, "|") + ")$"),
+		"filter": quote(strings.Join(filters, "|")),
+	}
+	return reRerunPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	}), nil
+}
+
+func runnerLabel(runnerName string) string {
+	if runnerName == "" {
+		return "these"
+	}
+	return runnerName
+}
+
+// rerunID is how the test's runner names it on the command line: the top-level go
+// test (subtests fail with their parent), the pytest node ID, jest's full name
+// (describe blocks and test joined by spaces), the fully qualified .NET method and the
+// cargo test path.
+func (t TestResult) rerunID() string {
+	switch t.Runner {
+	case TestRunnerGo:
+		name, _, _ := strings.Cut(t.Name, "/")
+		return name
+	case TestRunnerPytest:
+		if t.Name == "" {
+			return t.Package
+		}
+		return t.Package + "::" + t.Name
+	case TestRunnerJest:
+		return strings.ReplaceAll(t.Name, " › ", " ")
+	case TestRunnerDotnet:
+		if t.Package == "" {
+			return t.Name
+		}
+		return t.Package + "." + t.Name
+	default:
+		return t.Name
+	}
+}
+
+// runsTestRunner reports whether run invokes runnerName's own command, directly or
+// (for jest) through npx, yarn, or pnpm.
+func runsTestRunner(run string, runnerName string) bool {
+	fields := strings.Fields(run)
+	if len(fields) == 0 {
+		return false
+	}
+	command := commandName(fields[0])
+	if runnerName == TestRunnerJest {
+		switch command {
+		case "npx", "yarn", "pnpm":
+			fields = fields[1:]
+			if len(fields) > 1 && fields[0] == "exec" {
+				fields = fields[1:]
+			}
+			if len(fields) == 0 {
+				return false
+			}
+			command = commandName(fields[0])
+		}
+	}
+	return slices.Contains(rerunCommands[runnerName], command)
+}
+
+// commandName is a command word without its directory or Windows extension.
+func commandName(word string) string {
+	name := strings.ToLower(filepath.Base(word))
+	for _, ext := range []string{".exe", ".cmd"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// rerunBase is the {run} of a built-in template: the check's command, with "--"
+// appended when cargo has to pass the filter flags through to the test binary.
+func rerunBase(run string, runnerName string, builtin bool) string {
+	run = strings.TrimSpace(run)
+	if !builtin || runnerName != TestRunnerCargo || strings.Contains(" "+run+" ", " -- ") {
+		return run
+	}
+	return run + " --"
+}
+
+// shellQuoter returns a function that quotes one argument for the shell a check with
+// the given `shell:` runs under.
+func shellQuoter(shell string) func(string) string {
+	executable, _ := resolveCommand(shell, "", "")
+	switch strings.TrimSuffix(strings.ToLower(filepath.Base(executable)), ".exe") {
+	case "cmd":
+		return func(arg string) string {
+			return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+	case "pwsh", "powershell":
+		return func(arg string) string {
+			return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+		}
+	default:
+		return func(arg string) string {
+			return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+}
@@ -3,6 +3,7 @@
 package runner
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/berniemackie97/build-bouncer/internal/config"
+	"github.com/berniemackie97/build-bouncer/internal/git"
 )
 
 type ProgressEvent struct {
@@ -32,16 +34,38 @@ type Options struct {
 	MaxParallel int
 	FailFast    bool
 	Progress    func(e ProgressEvent)
+
+	// ChangedFiles are the files being pushed, used to skip checks whose `paths:` match
+	// none of them. nil means unknown: every check runs.
+	ChangedFiles []string
+
+	// PushRefs is the pre-push hook input (see git.PushRefsEnv). Built-in checks use it
+	// to scan exactly what is being pushed.
+	PushRefs string
 }
 
 type Report struct {
 	Failures         []string
 	FailureTails     map[string]string // checkName -> output tail
 	FailureHeadlines map[string]string // checkName -> headline
-	Canceled         []string
-	Skipped          []string
-	SkipReasons      map[string]string // checkName -> reason
-	LogFiles         map[string]string // checkName -> full log (only on failure)
+
+	// FailureDiagnostics holds the diagnostics from the check's structured output, or
+	// else what its problem matchers (or, failing those, its config parsers) found.
+	FailureDiagnostics map[string][]Diagnostic // checkName -> diagnostics
+
+	// TestResults holds the tests from each check's structured output, pass or fail.
+	TestResults map[string][]TestResult // checkName -> tests
+
+	// FailedTests lists each failing check's failed tests: from its structured output,
+	// or else from go test, pytest, jest, dotnet or cargo output in its log.
+	FailedTests map[string][]TestResult // checkName -> failed tests
+	Canceled    []string
+	Skipped     []string
+	SkipReasons map[string]string // checkName -> reason
+	LogFiles    map[string]string // checkName -> full log (only on failure)
+
+	// Profile is the config profile the run applied; the caller sets it so rerun can too.
+	Profile string
 }
 
 type limitedBuffer struct {
@@ -58,6 +82,10 @@ type runOutcome struct {
 	Canceled bool
 	Skipped  bool
 	Reason   string
+
+	// Structured is the check's parsed `output:`, if it has one and it could be read.
+	Structured    *StructuredOutput
+	StructuredErr error
 }
 
 type checkJob struct {
@@ -102,13 +130,26 @@ func (buf *limitedBuffer) String() string {
 	return string(buf.buffer)
 }
 
+// InterpolationFor builds the `${{ }}` context checks expand against for this repo.
+func InterpolationFor(repoRoot string) config.Interpolation {
+	return config.Interpolation{
+		RepoRoot:  repoRoot,
+		Branch:    git.CurrentBranch(repoRoot),
+		OS:        CurrentOS(),
+		LookupEnv: os.LookupEnv,
+	}
+}
+
 func RunAllReport(repoRoot string, configuration *config.Config, options Options) (Report, error) {
 	report := Report{
-		Failures:         []string{},
-		FailureTails:     map[string]string{},
-		FailureHeadlines: map[string]string{},
-		LogFiles:         map[string]string{},
-		SkipReasons:      map[string]string{},
+		Failures:           []string{},
+		FailureTails:       map[string]string{},
+		FailureHeadlines:   map[string]string{},
+		FailureDiagnostics: map[string][]Diagnostic{},
+		TestResults:        map[string][]TestResult{},
+		FailedTests:        map[string][]TestResult{},
+		LogFiles:           map[string]string{},
+		SkipReasons:        map[string]string{},
 	}
 
 	totalChecks := len(configuration.Checks)
@@ -116,6 +157,26 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 		return report, nil
 	}
 
+	interpolation := InterpolationFor(repoRoot)
+	checks := make([]config.Check, 0, totalChecks)
+	for _, checkDefinition := range configuration.Checks {
+		expanded, err := configuration.ExpandCheck(checkDefinition, interpolation)
+		if err != nil {
+			return report, err
+		}
+		checks = append(checks, expanded)
+	}
+
+	// Load matcher files before anything runs: a broken one is a config error.
+	matchers := make([][]*ProblemMatcher, len(checks))
+	for checkIndex, checkDefinition := range checks {
+		loaded, err := LoadProblemMatchers(repoRoot, checkDefinition.ProblemMatchers)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", checkDefinition.Name, err)
+		}
+		matchers[checkIndex] = loaded
+	}
+
 	maxParallel := options.MaxParallel
 	if maxParallel <= 0 {
 		maxParallel = 1
@@ -149,7 +210,11 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 				})
 			}
 
-			if skipReason := checkSkipReason(checkDefinition); strings.TrimSpace(skipReason) != "" {
+			skipReason := checkSkipReason(checkDefinition)
+			if skipReason == "" {
+				skipReason = changedPathsSkipReason(checkDefinition, options.ChangedFiles)
+			}
+			if strings.TrimSpace(skipReason) != "" {
 				if options.Progress != nil {
 					options.Progress(ProgressEvent{
 						Stage:    "end",
@@ -190,18 +255,45 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 				workingDirectory = filepath.Join(repoRoot, filepath.FromSlash(checkDefinition.Cwd))
 			}
 
-			outcome, runErr := runOne(
-				runContext,
-				repoRoot,
-				workingDirectory,
-				job.index,
-				checkName,
-				checkDefinition.Run,
-				checkDefinition.Shell,
-				checkDefinition.Env,
-				checkDefinition.Timeout,
-				options,
-			)
+			var outcome runOutcome
+			var runErr error
+			if checkDefinition.Builtin != "" {
+				outcome, runErr = runBuiltin(runContext, repoRoot, workingDirectory, job.index, checkDefinition, options)
+			} else {
+				var echo io.Writer
+				if options.Verbose {
+					echo = os.Stdout
+				}
+				// A structured stream on stdout is captured rather than echoed; verbose
+				// mode shows its readable form once the check is done.
+				var captured *bytes.Buffer
+				if checkDefinition.Output != nil && checkDefinition.Output.File == "" {
+					captured = &bytes.Buffer{}
+					echo = captured
+				}
+				started := time.Now()
+				outcome, runErr = runOne(
+					runContext,
+					repoRoot,
+					workingDirectory,
+					job.index,
+					checkName,
+					checkDefinition.Run,
+					checkDefinition.Shell,
+					checkDefinition.Env,
+					checkDefinition.Timeout,
+					echo,
+					options,
+				)
+				if runErr == nil && checkDefinition.Output != nil {
+					readStructuredOutcome(&outcome, repoRoot, workingDirectory, *checkDefinition.Output, captured, started)
+					if options.Verbose {
+						outputMutex.Lock()
+						printStructuredOutcome(checkName, outcome, captured != nil)
+						outputMutex.Unlock()
+					}
+				}
+			}
 
 			if options.Progress != nil {
 				options.Progress(ProgressEvent{
@@ -254,7 +346,7 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 	go func() {
 		defer close(dispatchDone)
 
-		for checkIndex, checkDefinition := range configuration.Checks {
+		for checkIndex, checkDefinition := range checks {
 			select {
 			case <-stopDispatch:
 				close(jobsChannel)
@@ -300,6 +392,11 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 			continue
 		}
 
+		structured := result.outcome.Structured
+		if structured != nil && len(structured.Tests) > 0 {
+			report.TestResults[result.name] = structured.Tests
+		}
+
 		if result.outcome.ExitCode != 0 || result.outcome.TimedOut {
 			report.Failures = append(report.Failures, result.name)
 			report.FailureTails[result.name] = result.outcome.Tail
@@ -308,8 +405,39 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 				report.LogFiles[result.name] = result.outcome.LogPath
 			}
 
+			// Structured output, problem matchers and config parsers know the check's
+			// tools; they go before the built-in rules.
+			var diagnostics []Diagnostic
+			if structured != nil {
+				diagnostics = structured.Diagnostics[:min(len(structured.Diagnostics), maxDiagnostics)]
+			}
+			if len(diagnostics) == 0 {
+				diagnostics = matchFailureLog(matchers[result.index], result.outcome)
+			}
+			if len(diagnostics) == 0 {
+				diagnostics = ParseDiagnostics(configuration.ParsersFor(checks[result.index]), result.outcome.Tail)
+			}
+			if len(diagnostics) > 0 {
+				report.FailureDiagnostics[result.name] = diagnostics
+			}
+
+			var failedTests []TestResult
+			if structured != nil {
+				failedTests = structured.FailedTests()
+			}
+			if len(failedTests) == 0 && structured == nil {
+				failedTests = failedTestsFromLog(result.outcome)
+			}
+			if len(failedTests) > 0 {
+				report.FailedTests[result.name] = failedTests[:min(len(failedTests), maxFailedTests)]
+			}
+
 			if result.outcome.TimedOut {
 				report.FailureHeadlines[result.name] = fmt.Sprintf("Timed out after %s", result.outcome.Timeout)
+			} else if headline := structuredHeadline(structured); headline != "" {
+				report.FailureHeadlines[result.name] = trimHeadline(headline)
+			} else if len(diagnostics) > 0 {
+				report.FailureHeadlines[result.name] = trimHeadline(headlineDiagnostic(diagnostics).String())
 			} else if headline := strings.TrimSpace(ExtractHeadline(result.name, result.outcome.Tail)); headline != "" {
 				report.FailureHeadlines[result.name] = headline
 			}
@@ -324,7 +452,7 @@ func RunAllReport(repoRoot string, configuration *config.Config, options Options
 	<-dispatchDone
 
 	if failFastTriggered {
-		for checkIndex, checkDefinition := range configuration.Checks {
+		for checkIndex, checkDefinition := range checks {
 			if !jobScheduled[checkIndex] {
 				report.Canceled = append(report.Canceled, checkDefinition.Name)
 			}
@@ -363,6 +491,7 @@ func runOne(
 	explicitShell string,
 	environmentOverrides map[string]string,
 	timeoutDuration time.Duration,
+	echo io.Writer,
 	options Options,
 ) (runOutcome, error) {
 	if parentContext.Err() != nil {
@@ -371,22 +500,11 @@ func runOne(
 
 	tailBuffer := newLimitedBuffer(128 * 1024)
 
-	logDirectory := strings.TrimSpace(options.LogDir)
-	if logDirectory == "" {
-		logDirectory = resolveDefaultLogDir(repoRoot)
-	}
-	if err := os.MkdirAll(logDirectory, 0o755); err != nil {
+	logPath, err := checkLogPath(repoRoot, checkIndex, checkName, options)
+	if err != nil {
 		return runOutcome{ExitCode: 1}, err
 	}
 
-	logFileName := fmt.Sprintf(
-		"%s_%02d_%s.log",
-		time.Now().Format("20060102_150405"),
-		checkIndex,
-		sanitize(checkName),
-	)
-	logPath := filepath.Join(logDirectory, logFileName)
-
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
 	if err != nil {
 		return runOutcome{ExitCode: 1}, err
@@ -399,8 +517,8 @@ func runOne(
 	}
 
 	outputWriter := io.MultiWriter(logFile, tailBuffer)
-	if options.Verbose {
-		outputWriter = io.MultiWriter(os.Stdout, logFile, tailBuffer)
+	if echo != nil {
+		outputWriter = io.MultiWriter(echo, logFile, tailBuffer)
 	}
 
 	runContext := parentContext
@@ -487,6 +605,25 @@ func runOne(
 	}
 }
 
+// checkLogPath names the log file for one check run, creating the log directory.
+func checkLogPath(repoRoot string, checkIndex int, checkName string, options Options) (string, error) {
+	logDirectory := strings.TrimSpace(options.LogDir)
+	if logDirectory == "" {
+		logDirectory = resolveDefaultLogDir(repoRoot)
+	}
+	if err := os.MkdirAll(logDirectory, 0o755); err != nil {
+		return "", err
+	}
+
+	logFileName := fmt.Sprintf(
+		"%s_%02d_%s.log",
+		time.Now().Format("20060102_150405"),
+		checkIndex,
+		sanitize(checkName),
+	)
+	return filepath.Join(logDirectory, logFileName), nil
+}
+
 func removeFileWithRetries(path string) error {
 	// On Windows you can still hit short-lived locks (AV, indexing, etc).
 	// A few tiny retries makes this deterministic for tests and less annoying in real life.
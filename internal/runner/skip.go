@@ -28,6 +28,19 @@ func checkSkipReason(check config.Check) string {
 	return ""
 }
 
+// changedPathsSkipReason skips a check with `paths:` when none of the changed files match.
+// A nil changed list means the changes are unknown, so the check runs.
+func changedPathsSkipReason(check config.Check, changed []string) string {
+	if len(check.Paths) == 0 || changed == nil {
+		return ""
+	}
+	filter, err := config.CompilePathFilter(check.Paths)
+	if err != nil || filter.MatchAny(changed) {
+		return ""
+	}
+	return "no changes match paths"
+}
+
 func SkipReason(check config.Check) string {
 	return checkSkipReason(check)
 }
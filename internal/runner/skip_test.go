@@ -80,3 +80,35 @@ func TestRunAllReportSkipsMissingTools(t *testing.T) {
 		t.Fatalf("expected missing tool reason, got %q", reason)
 	}
 }
+
+func TestRunAllReportSkipsChecksWithoutMatchingChanges(t *testing.T) {
+	root := t.TempDir()
+
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{
+			{Name: "go", Run: "echo go", Paths: config.StringList{"*.go"}},
+			{Name: "web", Run: "echo web", Paths: config.StringList{"web/**"}},
+			{Name: "always", Run: "echo always"},
+		},
+	}
+
+	rep, err := RunAllReport(root, cfg, Options{ChangedFiles: []string{"cmd/main.go"}})
+	if err != nil {
+		t.Fatalf("RunAllReport error: %v", err)
+	}
+	if len(rep.Skipped) != 1 || rep.Skipped[0] != "web" {
+		t.Fatalf("expected only web skipped, got %+v", rep.Skipped)
+	}
+	if reason := rep.SkipReasons["web"]; reason != "no changes match paths" {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+
+	rep, err = RunAllReport(root, cfg, Options{})
+	if err != nil {
+		t.Fatalf("RunAllReport error: %v", err)
+	}
+	if len(rep.Skipped) != 0 {
+		t.Fatalf("unknown changes should run everything, skipped %+v", rep.Skipped)
+	}
+}
@@ -0,0 +1,590 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+// Structured output (`output:` on a check): tools that can describe their results as
+// JSON or JUnit XML get per-test and per-diagnostic results instead of a scraped tail.
+
+// TestResult is one test from a check's structured output, or one failed test
+// ExtractFailedTests found in its plain output.
+type TestResult struct {
+	Runner   string        `json:"runner,omitempty"`  // one of the TestRunner constants
+	Package  string        `json:"package,omitempty"` // go package, test file, crate, .NET class, JUnit classname or suite
+	Name     string        `json:"name"`
+	Status   string        `json:"status"` // pass, fail or skip
+	Duration time.Duration `json:"durationNs,omitempty"`
+	Message  string        `json:"message,omitempty"` // first line of the failure, usually the assertion
+	Output   string        `json:"output,omitempty"`  // what the test printed (structured failures only)
+}
+
+// StructuredOutput is a check's parsed `output:`.
+type StructuredOutput struct {
+	Format      string
+	Tests       []TestResult
+	Diagnostics []Diagnostic
+
+	// Text is the human-readable form: what the tool would have printed without its
+	// machine-readable flag, failures first and foremost.
+	Text string
+}
+
+// FailedTests returns the tests that failed, in the order they finished.
+func (s *StructuredOutput) FailedTests() []TestResult {
+	var failed []TestResult
+	for _, test := range s.Tests {
+		if test.Status == "fail" {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
+
+// Summary counts tests by status and diagnostics by severity, e.g.
+// "12 tests: 10 passed, 1 failed, 1 skipped; 2 errors".
+func (s *StructuredOutput) Summary() string {
+	var parts []string
+	if len(s.Tests) > 0 {
+		counts := map[string]int{}
+		for _, test := range s.Tests {
+			counts[test.Status]++
+		}
+		testParts := []string{fmt.Sprintf("%d passed", counts["pass"])}
+		if counts["fail"] > 0 {
+			testParts = append(testParts, fmt.Sprintf("%d failed", counts["fail"]))
+		}
+		if counts["skip"] > 0 {
+			testParts = append(testParts, fmt.Sprintf("%d skipped", counts["skip"]))
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", plural(len(s.Tests), "test"), strings.Join(testParts, ", ")))
+	}
+	if len(s.Diagnostics) > 0 {
+		counts := map[string]int{}
+		for _, diagnostic := range s.Diagnostics {
+			counts[diagnostic.Severity]++
+		}
+		var diagnosticParts []string
+		for _, severity := range []string{"error", "warning", "notice"} {
+			if counts[severity] > 0 {
+				diagnosticParts = append(diagnosticParts, plural(counts[severity], severity))
+			}
+		}
+		parts = append(parts, strings.Join(diagnosticParts, ", "))
+	}
+	if len(parts) == 0 {
+		return "no tests or diagnostics"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// ParseStructuredOutput parses data in one of config.OutputFormats. Relative file names
+// in diagnostics are taken as relative to dir (the check's cwd, relative to the repo
+// root); absolute ones under repoRoot are made repo-relative.
+func ParseStructuredOutput(format string, data []byte, repoRoot string, dir string) (*StructuredOutput, error) {
+	out := &StructuredOutput{Format: format}
+	paths := filePaths{repoRoot: repoRoot, dir: dir}
+	var err error
+	switch format {
+	case "gotest-json":
+		err = parseGoTestJSON(out, data)
+	case "cargo-json":
+		err = parseCargoJSON(out, data, paths)
+	case "eslint-json":
+		err = parseESLintJSON(out, data, paths)
+	case "golangci-json":
+		err = parseGolangciJSON(out, data, paths)
+	case "ruff-json":
+		err = parseRuffJSON(out, data, paths)
+	case "junit":
+		err = parseJUnit(out, data)
+	default:
+		err = fmt.Errorf("unknown output format %q (use %s)", format, strings.Join(config.OutputFormats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type filePaths struct {
+	repoRoot string
+	dir      string
+}
+
+func (p filePaths) rel(file string) string {
+	if file == "" {
+		return ""
+	}
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(p.repoRoot, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(file)
+	}
+	return path.Join(p.dir, filepath.ToSlash(file))
+}
+
+// jsonLines calls fn for each line of data that is a JSON object; the other lines (a
+// tool's plain stderr mixed into the stream) go to text.
+func jsonLines(data []byte, text *strings.Builder, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 && line[0] == '{' && json.Valid(line) {
+			if err := fn(line); err != nil {
+				return err
+			}
+			continue
+		}
+		text.Write(scanner.Bytes())
+		text.WriteByte('\n')
+	}
+	return scanner.Err()
+}
+
+// decodeJSONDocument decodes the first JSON document in data that starts a line with
+// '[' or '{', skipping whatever a wrapper script printed before it.
+func decodeJSONDocument(data []byte, v any) error {
+	for offset := 0; offset < len(data); {
+		rest := data[offset:]
+		trimmed := bytes.TrimLeft(rest, " \t\r")
+		if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+			if err := json.NewDecoder(bytes.NewReader(trimmed)).Decode(v); err == nil {
+				return nil
+			}
+		}
+		next := bytes.IndexByte(rest, '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	return errors.New("no JSON document found")
+}
+
+// firstAssertionLine returns the first line of a failure that isn't runner chatter
+// (=== RUN, --- FAIL, ...): usually the assertion and where it failed.
+func firstAssertionLine(output string) string {
+	for _, line := range strings.Split(normalizeOutputNewlines(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "=== ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---- ") {
+			continue
+		}
+		return trimHeadline(line)
+	}
+	return ""
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+func parseGoTestJSON(out *StructuredOutput, data []byte) error {
+	var text strings.Builder
+	testOutput := map[string]*strings.Builder{}
+	err := jsonLines(data, &text, func(line []byte) error {
+		var event goTestEvent
+		if err := json.Unmarshal(line, &event); err != nil || event.Action == "" {
+			return nil
+		}
+		if event.Test == "" {
+			// Package lines (ok/FAIL pkg, build output) read as they would without -json.
+			if event.Action == "output" || event.Action == "build-output" {
+				text.WriteString(event.Output)
+			}
+			return nil
+		}
+
+		key := event.Package + "\x00" + event.Test
+		switch event.Action {
+		case "output":
+			if testOutput[key] == nil {
+				testOutput[key] = &strings.Builder{}
+			}
+			testOutput[key].WriteString(event.Output)
+		case "pass", "fail", "skip":
+			result := TestResult{Runner: TestRunnerGo, Package: event.Package, Name: event.Test, Status: event.Action, Duration: secondsDuration(event.Elapsed)}
+			if event.Action == "fail" && testOutput[key] != nil {
+				result.Output = testOutput[key].String()
+				result.Message = firstAssertionLine(result.Output)
+				text.WriteString(result.Output)
+			}
+			delete(testOutput, key)
+			out.Tests = append(out.Tests, result)
+		}
+		return nil
+	})
+	out.Text = text.String()
+	return err
+}
+
+type cargoMessage struct {
+	Reason  string `json:"reason"`
+	Message *struct {
+		Message  string `json:"message"`
+		Level    string `json:"level"`
+		Rendered string `json:"rendered"`
+		Code     *struct {
+			Code string `json:"code"`
+		} `json:"code"`
+		Spans []struct {
+			FileName    string `json:"file_name"`
+			LineStart   int    `json:"line_start"`
+			LineEnd     int    `json:"line_end"`
+			ColumnStart int    `json:"column_start"`
+			ColumnEnd   int    `json:"column_end"`
+			IsPrimary   bool   `json:"is_primary"`
+		} `json:"spans"`
+	} `json:"message"`
+
+	// libtest's JSON (cargo test -- -Z unstable-options --format json).
+	Type     string  `json:"type"`
+	Event    string  `json:"event"`
+	Name     string  `json:"name"`
+	ExecTime float64 `json:"exec_time"`
+	Stdout   string  `json:"stdout"`
+}
+
+func parseCargoJSON(out *StructuredOutput, data []byte, paths filePaths) error {
+	var text strings.Builder
+	err := jsonLines(data, &text, func(line []byte) error {
+		var message cargoMessage
+		if err := json.Unmarshal(line, &message); err != nil {
+			return nil
+		}
+		switch {
+		case message.Reason == "compiler-message" && message.Message != nil:
+			level := message.Message.Level
+			if level != "error" && level != "warning" {
+				return nil
+			}
+			diagnostic := Diagnostic{Source: "cargo", Message: message.Message.Message, Severity: level}
+			if message.Message.Code != nil {
+				diagnostic.Rule = message.Message.Code.Code
+			}
+			for _, span := range message.Message.Spans {
+				if span.IsPrimary {
+					diagnostic.File = paths.rel(span.FileName)
+					diagnostic.Line, diagnostic.EndLine = span.LineStart, span.LineEnd
+					diagnostic.Col, diagnostic.EndCol = span.ColumnStart, span.ColumnEnd
+					break
+				}
+			}
+			out.Diagnostics = append(out.Diagnostics, diagnostic)
+			text.WriteString(message.Message.Rendered)
+		case message.Type == "test":
+			status := map[string]string{"ok": "pass", "failed": "fail", "ignored": "skip"}[message.Event]
+			if status == "" {
+				return nil
+			}
+			result := TestResult{Runner: TestRunnerCargo, Name: message.Name, Status: status, Duration: secondsDuration(message.ExecTime)}
+			if status == "fail" {
+				result.Output = message.Stdout
+				result.Message = firstAssertionLine(message.Stdout)
+				fmt.Fprintf(&text, "---- %s stdout ----\n%s\n", message.Name, strings.TrimRight(message.Stdout, "\n"))
+			}
+			out.Tests = append(out.Tests, result)
+		}
+		return nil
+	})
+	out.Text = text.String()
+	return err
+}
+
+func parseESLintJSON(out *StructuredOutput, data []byte, paths filePaths) error {
+	var files []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID    string `json:"ruleId"`
+			Severity  int    `json:"severity"`
+			Message   string `json:"message"`
+			Line      int    `json:"line"`
+			Column    int    `json:"column"`
+			EndLine   int    `json:"endLine"`
+			EndColumn int    `json:"endColumn"`
+		} `json:"messages"`
+	}
+	if err := decodeJSONDocument(data, &files); err != nil {
+		return err
+	}
+	for _, file := range files {
+		for _, message := range file.Messages {
+			severity := "warning"
+			if message.Severity >= 2 {
+				severity = "error"
+			}
+			out.Diagnostics = append(out.Diagnostics, Diagnostic{
+				Source:   "eslint",
+				File:     paths.rel(file.FilePath),
+				Line:     message.Line,
+				Col:      message.Column,
+				EndLine:  message.EndLine,
+				EndCol:   message.EndColumn,
+				Message:  message.Message,
+				Rule:     message.RuleID,
+				Severity: severity,
+			})
+		}
+	}
+	out.Text = diagnosticsText(out.Diagnostics)
+	return nil
+}
+
+func parseGolangciJSON(out *StructuredOutput, data []byte, paths filePaths) error {
+	var report struct {
+		Issues []struct {
+			FromLinter string `json:"FromLinter"`
+			Text       string `json:"Text"`
+			Severity   string `json:"Severity"`
+			Pos        struct {
+				Filename string `json:"Filename"`
+				Line     int    `json:"Line"`
+				Column   int    `json:"Column"`
+			} `json:"Pos"`
+		} `json:"Issues"`
+	}
+	if err := decodeJSONDocument(data, &report); err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		out.Diagnostics = append(out.Diagnostics, Diagnostic{
+			Source:   "golangci-lint",
+			File:     paths.rel(issue.Pos.Filename),
+			Line:     issue.Pos.Line,
+			Col:      issue.Pos.Column,
+			Message:  issue.Text,
+			Rule:     issue.FromLinter,
+			Severity: normalizeSeverity(issue.Severity),
+		})
+	}
+	out.Text = diagnosticsText(out.Diagnostics)
+	return nil
+}
+
+func parseRuffJSON(out *StructuredOutput, data []byte, paths filePaths) error {
+	type location struct {
+		Row    int `json:"row"`
+		Column int `json:"column"`
+	}
+	var issues []struct {
+		Code        string   `json:"code"`
+		Message     string   `json:"message"`
+		Filename    string   `json:"filename"`
+		Location    location `json:"location"`
+		EndLocation location `json:"end_location"`
+	}
+	if err := decodeJSONDocument(data, &issues); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		out.Diagnostics = append(out.Diagnostics, Diagnostic{
+			Source:   "ruff",
+			File:     paths.rel(issue.Filename),
+			Line:     issue.Location.Row,
+			Col:      issue.Location.Column,
+			EndLine:  issue.EndLocation.Row,
+			EndCol:   issue.EndLocation.Column,
+			Message:  issue.Message,
+			Rule:     issue.Code,
+			Severity: "error",
+		})
+	}
+	out.Text = diagnosticsText(out.Diagnostics)
+	return nil
+}
+
+func diagnosticsText(diagnostics []Diagnostic) string {
+	var text strings.Builder
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(&text, "%s: %s\n", diagnostic.Severity, diagnostic.String())
+	}
+	return text.String()
+}
+
+// junitSuite covers both a <testsuites> root and a lone <testsuite>; suites nest.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func parseJUnit(out *StructuredOutput, data []byte) error {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("junit: %w", err)
+	}
+	var text strings.Builder
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, testCase := range suite.Cases {
+			result := TestResult{Runner: TestRunnerJUnit, Package: testCase.Classname, Name: testCase.Name, Status: "pass"}
+			if result.Package == "" {
+				result.Package = suite.Name
+			}
+			if seconds, err := strconv.ParseFloat(strings.ReplaceAll(testCase.Time, ",", ""), 64); err == nil {
+				result.Duration = secondsDuration(seconds)
+			}
+			problem := testCase.Failure
+			if problem == nil {
+				problem = testCase.Error
+			}
+			switch {
+			case problem != nil:
+				result.Status = "fail"
+				result.Output = strings.TrimSpace(problem.Text)
+				result.Message = trimHeadline(problem.Message)
+				if result.Message == "" {
+					result.Message = firstAssertionLine(problem.Text)
+				}
+				id := result.Name
+				if result.Package != "" {
+					id = result.Package + "::" + result.Name
+				}
+				fmt.Fprintf(&text, "FAILED %s - %s\n", id, result.Message)
+				for _, line := range strings.Split(normalizeOutputNewlines(result.Output), "\n") {
+					fmt.Fprintf(&text, "    %s\n", line)
+				}
+			case testCase.Skipped != nil:
+				result.Status = "skip"
+			}
+			out.Tests = append(out.Tests, result)
+		}
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	out.Text = text.String()
+	return nil
+}
+
+// readStructuredOutcome parses a finished check's `output:` into outcome. When the
+// results came on stdout, the tail becomes their readable form, so headlines, --tail and
+// insults see what the tool would have printed without its machine-readable flag.
+func readStructuredOutcome(outcome *runOutcome, repoRoot string, workingDirectory string, output config.Output, captured *bytes.Buffer, started time.Time) {
+	if outcome.Canceled {
+		return
+	}
+	data, err := structuredData(workingDirectory, output, captured, started)
+	if err == nil {
+		dir := ""
+		if rel, relErr := filepath.Rel(repoRoot, workingDirectory); relErr == nil && rel != "." {
+			dir = filepath.ToSlash(rel)
+		}
+		outcome.Structured, err = ParseStructuredOutput(output.Format, data, repoRoot, dir)
+	}
+	if err != nil {
+		outcome.StructuredErr = fmt.Errorf("output (%s): %w", output.Format, err)
+		if outcome.ExitCode != 0 {
+			outcome.Tail += fmt.Sprintf("\nbuild-bouncer could not read the check's %v\n", outcome.StructuredErr)
+		}
+		return
+	}
+	if captured != nil {
+		tail := newLimitedBuffer(128 * 1024)
+		_, _ = tail.Write([]byte(outcome.Structured.Text + outcome.Structured.Summary() + "\n"))
+		outcome.Tail = tail.String()
+	}
+}
+
+func structuredData(workingDirectory string, output config.Output, captured *bytes.Buffer, started time.Time) ([]byte, error) {
+	if captured != nil {
+		return captured.Bytes(), nil
+	}
+	file := output.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(workingDirectory, filepath.FromSlash(file))
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	// Leave a little slack for coarse file system timestamps.
+	if info.ModTime().Before(started.Add(-2 * time.Second)) {
+		return nil, fmt.Errorf("%s was not written by this run", output.File)
+	}
+	return os.ReadFile(file)
+}
+
+// printStructuredOutcome is verbose mode's view of a structured check: the readable
+// form when the raw stream was captured, then a one-line summary.
+func printStructuredOutcome(checkName string, outcome runOutcome, captured bool) {
+	switch {
+	case outcome.StructuredErr != nil:
+		fmt.Printf("!! %s %v\n", checkName, outcome.StructuredErr)
+	case outcome.Structured != nil:
+		if text := strings.TrimRight(outcome.Structured.Text, "\n"); captured && text != "" {
+			fmt.Println(text)
+		}
+		fmt.Printf("-- %s: %s\n", checkName, outcome.Structured.Summary())
+	}
+}
+
+// structuredHeadline headlines a failure from its structured output: the first error,
+// else the first failed test, else the first warning.
+func structuredHeadline(structured *StructuredOutput) string {
+	if structured == nil {
+		return ""
+	}
+	for _, diagnostic := range structured.Diagnostics {
+		if diagnostic.Severity == "error" {
+			return diagnostic.String()
+		}
+	}
+	if failed := structured.FailedTests(); len(failed) > 0 {
+		headline := "Test failed: " + failed[0].Name
+		if failed[0].Message != "" {
+			headline += ": " + failed[0].Message
+		}
+		return headline
+	}
+	if len(structured.Diagnostics) > 0 {
+		return structured.Diagnostics[0].String()
+	}
+	return ""
+}
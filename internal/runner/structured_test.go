@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+const goTestJSONFixture = `{"Action":"start","Package":"example.com/m/calc"}
+{"Action":"run","Package":"example.com/m/calc","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/m/calc","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/m/calc","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}
+{"Action":"pass","Package":"example.com/m/calc","Test":"TestAdd","Elapsed":0.01}
+{"Action":"run","Package":"example.com/m/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv","Output":"=== RUN   TestDiv\n"}
+{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv","Output":"    calc_test.go:14: Div(1, 0) = 0, want error\n"}
+{"Action":"output","Package":"example.com/m/calc","Test":"TestDiv","Output":"--- FAIL: TestDiv (0.25s)\n"}
+{"Action":"fail","Package":"example.com/m/calc","Test":"TestDiv","Elapsed":0.25}
+{"Action":"skip","Package":"example.com/m/calc","Test":"TestSlow","Elapsed":0}
+{"Action":"output","Package":"example.com/m/calc","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/m/calc","Output":"FAIL\texample.com/m/calc\t0.3s\n"}
+{"Action":"fail","Package":"example.com/m/calc","Elapsed":0.3}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	out, err := ParseStructuredOutput("gotest-json", []byte("# stray stderr line\n"+goTestJSONFixture), "/repo", "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := out.Summary(); got != "3 tests: 1 passed, 1 failed, 1 skipped" {
+		t.Fatalf("summary = %q", got)
+	}
+	failed := out.FailedTests()
+	if len(failed) != 1 {
+		t.Fatalf("expected one failed test, got %+v", failed)
+	}
+	if f := failed[0]; f.Package != "example.com/m/calc" || f.Name != "TestDiv" || f.Duration != 250*time.Millisecond || f.Message != "calc_test.go:14: Div(1, 0) = 0, want error" {
+		t.Fatalf("unexpected failed test %+v", f)
+	}
+	for _, want := range []string{"# stray stderr line", "--- FAIL: TestDiv (0.25s)", "FAIL\texample.com/m/calc\t0.3s"} {
+		if !strings.Contains(out.Text, want) {
+			t.Fatalf("text should contain %q:\n%s", want, out.Text)
+		}
+	}
+	if strings.Contains(out.Text, "--- PASS") {
+		t.Fatalf("passing test output should be dropped:\n%s", out.Text)
+	}
+	if got := structuredHeadline(out); got != "Test failed: TestDiv: calc_test.go:14: Div(1, 0) = 0, want error" {
+		t.Fatalf("headline = %q", got)
+	}
+}
+
+func TestParseCargoJSON(t *testing.T) {
+	data := `{"reason":"compiler-artifact","package_id":"app 0.1.0"}
+{"reason":"compiler-message","message":{"message":"unused variable: ` + "`x`" + `","level":"warning","rendered":"warning: unused variable\n","code":{"code":"unused_variables"},"spans":[{"file_name":"src/main.rs","line_start":3,"line_end":3,"column_start":9,"column_end":10,"is_primary":true}]}}
+{"reason":"compiler-message","message":{"message":"mismatched types","level":"error","rendered":"error[E0308]: mismatched types\n","code":{"code":"E0308"},"spans":[{"file_name":"src/lib.rs","line_start":10,"line_end":10,"column_start":5,"column_end":8,"is_primary":true}]}}
+{"reason":"compiler-message","message":{"message":"aborting","level":"failure-note","rendered":"","code":null,"spans":[]}}
+{"type":"test","event":"failed","name":"tests::adds","exec_time":0.5,"stdout":"thread 'tests::adds' panicked at src/lib.rs:20:9:\nassertion failed\n"}
+{"reason":"build-finished","success":false}
+`
+	out, err := ParseStructuredOutput("cargo-json", []byte(data), "/repo", "crates/app")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(out.Diagnostics) != 2 || out.Diagnostics[1].File != "crates/app/src/lib.rs" || out.Diagnostics[1].Rule != "E0308" {
+		t.Fatalf("unexpected diagnostics %+v", out.Diagnostics)
+	}
+	if got := structuredHeadline(out); got != "crates/app/src/lib.rs:10:5: mismatched types [E0308]" {
+		t.Fatalf("headline should prefer the error, got %q", got)
+	}
+	if failed := out.FailedTests(); len(failed) != 1 || failed[0].Message != "thread 'tests::adds' panicked at src/lib.rs:20:9:" {
+		t.Fatalf("unexpected failed tests %+v", failed)
+	}
+	if !strings.Contains(out.Text, "error[E0308]: mismatched types") {
+		t.Fatalf("text should hold the rendered messages:\n%s", out.Text)
+	}
+}
+
+func TestParseLinterJSON(t *testing.T) {
+	eslint := "> web@1.0.0 lint\n> eslint -f json .\n\n" + `[{"filePath":"/repo/web/src/app.ts","messages":[{"ruleId":"no-undef","severity":2,"message":"'x' is not defined.","line":4,"column":7},{"ruleId":"semi","severity":1,"message":"Missing semicolon.","line":5,"column":2}]}]`
+	out, err := ParseStructuredOutput("eslint-json", []byte(eslint), "/repo", "web")
+	if err != nil {
+		t.Fatalf("eslint: %v", err)
+	}
+	if got := out.Summary(); got != "1 error, 1 warning" {
+		t.Fatalf("eslint summary = %q", got)
+	}
+	if got := out.Diagnostics[0].String(); got != "web/src/app.ts:4:7: 'x' is not defined. [no-undef]" {
+		t.Fatalf("eslint diagnostic = %q", got)
+	}
+
+	golangci := `{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Severity":"","Pos":{"Filename":"cmd/main.go","Line":12,"Column":3}}],"Report":{}}` + "\nlevel=warning msg=\"ignored\"\n"
+	out, err = ParseStructuredOutput("golangci-json", []byte(golangci), "/repo", "")
+	if err != nil {
+		t.Fatalf("golangci: %v", err)
+	}
+	if len(out.Diagnostics) != 1 || out.Diagnostics[0].String() != "cmd/main.go:12:3: Error return value is not checked [errcheck]" {
+		t.Fatalf("golangci diagnostics %+v", out.Diagnostics)
+	}
+
+	ruff := `[{"code":"F401","message":"` + "`os` imported but unused" + `","filename":"/repo/py/app.py","location":{"row":1,"column":8},"end_location":{"row":1,"column":10}}]`
+	out, err = ParseStructuredOutput("ruff-json", []byte(ruff), "/repo", "py")
+	if err != nil {
+		t.Fatalf("ruff: %v", err)
+	}
+	if d := out.Diagnostics[0]; d.File != "py/app.py" || d.Line != 1 || d.EndCol != 10 || d.Rule != "F401" || d.Severity != "error" {
+		t.Fatalf("ruff diagnostic %+v", d)
+	}
+
+	if _, err := ParseStructuredOutput("eslint-json", []byte("Oops! Something went wrong!"), "/repo", ""); err == nil {
+		t.Fatalf("expected an error when there is no JSON")
+	}
+}
+
+const junitFixture = `<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="3">
+    <testcase classname="tests.test_api" name="test_ok" time="0.010"/>
+    <testcase classname="tests.test_api" name="test_create" time="1,234.5">
+      <failure message="AssertionError: assert 404 == 201">def test_create():
+&gt;       assert resp.status == 201
+E       AssertionError: assert 404 == 201</failure>
+    </testcase>
+    <testcase classname="tests.test_api" name="test_later" time="0"><skipped message="todo"/></testcase>
+  </testsuite>
+</testsuites>`
+
+func TestParseJUnit(t *testing.T) {
+	out, err := ParseStructuredOutput("junit", []byte(junitFixture), "/repo", "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := out.Summary(); got != "3 tests: 1 passed, 1 failed, 1 skipped" {
+		t.Fatalf("summary = %q", got)
+	}
+	failed := out.FailedTests()[0]
+	if failed.Package != "tests.test_api" || failed.Message != "AssertionError: assert 404 == 201" || failed.Duration != 1234500*time.Millisecond {
+		t.Fatalf("unexpected failed test %+v", failed)
+	}
+	if !strings.Contains(out.Text, "FAILED tests.test_api::test_create - AssertionError: assert 404 == 201\n    def test_create():") {
+		t.Fatalf("unexpected text:\n%s", out.Text)
+	}
+	if _, err := ParseStructuredOutput("junit", []byte("<testsuite><testcase"), "/repo", ""); err == nil {
+		t.Fatalf("expected an error for broken XML")
+	}
+}
+
+func TestRunAllReportReadsStructuredOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fixture commands use sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available on this system")
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("create git dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "gotest.json"), []byte(goTestJSONFixture), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "junit.xml"), []byte(junitFixture), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	cfg := &config.Config{
+		Version: 1,
+		Checks: []config.Check{
+			{Name: "go", Run: "cat gotest.json; exit 1", Output: &config.Output{Format: "gotest-json"}},
+			{Name: "py", Run: "cp junit.xml report.xml; echo 'raw pytest output'; exit 1", Output: &config.Output{Format: "junit", File: "report.xml"}},
+			{Name: "stale", Run: "exit 1", Output: &config.Output{Format: "junit", File: "junit.xml"}},
+		},
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "junit.xml"), old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	rep, err := RunAllReport(root, cfg, Options{MaxParallel: 1})
+	if err != nil {
+		t.Fatalf("RunAllReport error: %v", err)
+	}
+	if got := rep.FailureHeadlines["go"]; got != "Test failed: TestDiv: calc_test.go:14: Div(1, 0) = 0, want error" {
+		t.Fatalf("go headline = %q", got)
+	}
+	if tail := rep.FailureTails["go"]; strings.Contains(tail, `"Action"`) || !strings.Contains(tail, "--- FAIL: TestDiv") || !strings.Contains(tail, "3 tests: 1 passed, 1 failed, 1 skipped") {
+		t.Fatalf("go tail should be the readable form:\n%s", tail)
+	}
+	if len(rep.TestResults["go"]) != 3 || len(rep.TestResults["py"]) != 3 {
+		t.Fatalf("unexpected test results %+v", rep.TestResults)
+	}
+	if got := rep.FailureHeadlines["py"]; got != "Test failed: test_create: AssertionError: assert 404 == 201" {
+		t.Fatalf("py headline = %q", got)
+	}
+	if !strings.Contains(rep.FailureTails["py"], "raw pytest output") {
+		t.Fatalf("a file-based check keeps its own output as the tail:\n%s", rep.FailureTails["py"])
+	}
+	if _, ok := rep.TestResults["stale"]; ok || !strings.Contains(rep.FailureTails["stale"], "was not written by this run") {
+		t.Fatalf("a stale output file should be ignored and reported, tail:\n%s", rep.FailureTails["stale"])
+	}
+}
@@ -0,0 +1,331 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFailedTests caps how many failed tests are kept per check.
+const maxFailedTests = 200
+
+// The test runners whose plain output ExtractFailedTests understands. TestResult.Runner
+// holds one of these (or "junit" for JUnit XML, which can come from anything).
+const (
+	TestRunnerGo     = "go"
+	TestRunnerPytest = "pytest"
+	TestRunnerJest   = "jest"
+	TestRunnerDotnet = "dotnet"
+	TestRunnerCargo  = "cargo"
+	TestRunnerJUnit  = "junit"
+)
+
+var (
+	reGoTestFailLine   = regexp.MustCompile(`^(\s*)--- FAIL: (\S+) \(([\d.]+)s\)`)
+	reGoTestPkgResult  = regexp.MustCompile(`^(?:FAIL|ok)\s+(\S+)(?:\s|$)`)
+	rePytestSummary    = regexp.MustCompile(`^(?:FAILED|ERROR) (.+?)(?: - (.*))?$`)
+	reJestFileResult   = regexp.MustCompile(`^\s*FAIL\s+(\S+)`)
+	reJestBulletLine   = regexp.MustCompile(`^\s*● (.+)$`)
+	reJestCrossLine    = regexp.MustCompile(`^\s*[✕×] (.+?) \((\d+) ms\)$`)
+	reDotnetFailedLine = regexp.MustCompile(`^\s*Failed (\S.*?) \[([^\]]+)\]\s*$`)
+	reCargoFailedLine  = regexp.MustCompile(`^test (\S+) \.\.\. FAILED$`)
+	reCargoStdoutLine  = regexp.MustCompile(`^---- (\S+) stdout ----$`)
+	reCargoRunningLine = regexp.MustCompile(`^\s*Running (?:unittests )?\S+ \((?:.*[/\\])?([^/\\)]+?)(?:-[0-9a-f]{8,})?(?:\.exe)?\)$`)
+	reCargoPanicLine   = regexp.MustCompile(`panicked at (?:'(.*)', )?(\S+?:\d+:\d+):?$`)
+)
+
+// ExtractFailedTests lists the failed tests in a check's plain output from go test,
+// pytest, jest, dotnet test or cargo test. The first runner with failures in the
+// output wins.
+func ExtractFailedTests(output string) []TestResult {
+	lines := strings.Split(normalizeOutputNewlines(output), "\n")
+	for _, extract := range []func([]string) []TestResult{
+		goFailedTests,
+		pytestFailedTests,
+		jestFailedTests,
+		dotnetFailedTests,
+		cargoFailedTests,
+	} {
+		if tests := extract(lines); len(tests) > 0 {
+			return tests[:min(len(tests), maxFailedTests)]
+		}
+	}
+	return nil
+}
+
+// Label names the test the way its runner reports it, package or file first.
+func (t TestResult) Label() string {
+	switch {
+	case t.Runner == TestRunnerPytest:
+		return t.rerunID()
+	case t.Runner == TestRunnerJest && t.Package != "":
+		return t.Package + " › " + t.Name
+	case t.Runner == TestRunnerCargo && t.Package != "":
+		return t.Package + "::" + t.Name
+	case t.Package != "":
+		return t.Package + "." + t.Name
+	default:
+		return t.Name
+	}
+}
+
+// String formats the test as "label (duration): message", leaving out what is unknown.
+func (t TestResult) String() string {
+	var b strings.Builder
+	b.WriteString(t.Label())
+	if t.Duration > 0 {
+		b.WriteString(" (" + t.Duration.Round(time.Millisecond).String() + ")")
+	}
+	if t.Message != "" {
+		b.WriteString(": " + t.Message)
+	}
+	return b.String()
+}
+
+// failedTestsFromLog reads a failed check's full log (or its tail, when the log is gone)
+// for ExtractFailedTests. Only the end of a very large log is read.
+func failedTestsFromLog(outcome runOutcome) []TestResult {
+	const maxLogBytes = 32 << 20
+	output := outcome.Tail
+	if strings.TrimSpace(outcome.LogPath) != "" {
+		if file, err := os.Open(outcome.LogPath); err == nil {
+			if info, statErr := file.Stat(); statErr == nil && info.Size() > maxLogBytes {
+				_, _ = file.Seek(-maxLogBytes, io.SeekEnd)
+			}
+			if data, readErr := io.ReadAll(file); readErr == nil {
+				output = string(data)
+			}
+			_ = file.Close()
+		}
+	}
+	return ExtractFailedTests(output)
+}
+
+// isRunnerChatter reports lines that frame a test's output rather than explain it.
+func isRunnerChatter(line string) bool {
+	return strings.HasPrefix(line, "=== ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---- ")
+}
+
+func goFailedTests(lines []string) []TestResult {
+	var tests []TestResult
+	pending := 0 // tests[pending:] have not seen their package's result line yet
+	for i, line := range lines {
+		if match := reGoTestPkgResult.FindStringSubmatch(line); match != nil {
+			for j := pending; j < len(tests); j++ {
+				tests[j].Package = match[1]
+			}
+			pending = len(tests)
+			continue
+		}
+		match := reGoTestFailLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		seconds, _ := strconv.ParseFloat(match[3], 64)
+		tests = append(tests, TestResult{
+			Runner:   TestRunnerGo,
+			Name:     match[2],
+			Status:   "fail",
+			Duration: secondsDuration(seconds),
+			Message:  goFailureMessage(lines, i, len(match[1]), match[2]),
+		})
+	}
+	return tests
+}
+
+// goFailureMessage finds a go test failure's first line: indented under the --- FAIL
+// line without -v, or between its === RUN and --- FAIL lines with -v.
+func goFailureMessage(lines []string, failIndex int, indent int, name string) string {
+	for _, line := range lines[failIndex+1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " \t")) <= indent || isRunnerChatter(trimmed) {
+			break
+		}
+		return trimHeadline(trimmed)
+	}
+	for i := failIndex - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed != "=== RUN   "+name && trimmed != "=== RUN "+name {
+			continue
+		}
+		for _, line := range lines[i+1 : failIndex] {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !isRunnerChatter(trimmed) {
+				return trimHeadline(trimmed)
+			}
+		}
+		break
+	}
+	return ""
+}
+
+func pytestFailedTests(lines []string) []TestResult {
+	var tests []TestResult
+	seen := map[string]bool{}
+	for _, line := range lines {
+		match := rePytestSummary.FindStringSubmatch(strings.TrimRight(line, " "))
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		file, name, _ := strings.Cut(match[1], "::")
+		tests = append(tests, TestResult{
+			Runner:  TestRunnerPytest,
+			Package: file,
+			Name:    name,
+			Status:  "fail",
+			Message: trimHeadline(match[2]),
+		})
+	}
+	return tests
+}
+
+func jestFailedTests(lines []string) []TestResult {
+	durations := map[string]time.Duration{}
+	for _, line := range lines {
+		if match := reJestCrossLine.FindStringSubmatch(line); match != nil {
+			milliseconds, _ := strconv.Atoi(match[2])
+			durations[match[1]] = time.Duration(milliseconds) * time.Millisecond
+		}
+	}
+
+	var tests []TestResult
+	seen := map[string]bool{}
+	file := ""
+	for i, line := range lines {
+		if match := reJestFileResult.FindStringSubmatch(line); match != nil {
+			file = match[1]
+			continue
+		}
+		match := reJestBulletLine.FindStringSubmatch(line)
+		if match == nil || file == "" {
+			continue
+		}
+		name := strings.TrimSpace(match[1])
+		if name == "Console" || name == "Test suite failed to run" || seen[file+"\x00"+name] {
+			continue
+		}
+		seen[file+"\x00"+name] = true
+		parts := strings.Split(name, " › ")
+		test := TestResult{
+			Runner:   TestRunnerJest,
+			Package:  file,
+			Name:     name,
+			Status:   "fail",
+			Duration: durations[parts[len(parts)-1]],
+		}
+		for _, next := range lines[i+1:] {
+			if trimmed := strings.TrimSpace(next); trimmed != "" {
+				if !reJestBulletLine.MatchString(next) {
+					test.Message = trimHeadline(trimmed)
+				}
+				break
+			}
+		}
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+func dotnetFailedTests(lines []string) []TestResult {
+	var tests []TestResult
+	for i, line := range lines {
+		match := reDotnetFailedLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		fullName := match[1]
+		// Split Namespace.Class from Method, ignoring dots inside theory arguments.
+		head := fullName
+		if paren := strings.IndexByte(head, '('); paren >= 0 {
+			head = head[:paren]
+		}
+		test := TestResult{Runner: TestRunnerDotnet, Name: fullName, Status: "fail", Duration: parseDotnetDuration(match[2])}
+		if dot := strings.LastIndexByte(head, '.'); dot >= 0 {
+			test.Package, test.Name = fullName[:dot], fullName[dot+1:]
+		}
+		for j := i + 1; j < len(lines) && j < i+8; j++ {
+			if strings.TrimSpace(lines[j]) != "Error Message:" {
+				continue
+			}
+			for _, next := range lines[j+1:] {
+				if trimmed := strings.TrimSpace(next); trimmed != "" {
+					test.Message = trimHeadline(trimmed)
+					break
+				}
+			}
+			break
+		}
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// parseDotnetDuration reads dotnet's "[12 ms]", "[1 s]" or "[2 m 3 s]".
+func parseDotnetDuration(text string) time.Duration {
+	fields := strings.Fields(strings.TrimPrefix(text, "< "))
+	var total time.Duration
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return 0
+		}
+		switch fields[i+1] {
+		case "ms":
+			total += time.Duration(value) * time.Millisecond
+		case "s":
+			total += time.Duration(value) * time.Second
+		case "m":
+			total += time.Duration(value) * time.Minute
+		case "h":
+			total += time.Duration(value) * time.Hour
+		}
+	}
+	return total
+}
+
+func cargoFailedTests(lines []string) []TestResult {
+	messages := map[string]string{}
+	for i, line := range lines {
+		match := reCargoStdoutLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for j := i + 1; j < len(lines) && j < i+6; j++ {
+			panicMatch := reCargoPanicLine.FindStringSubmatch(lines[j])
+			if panicMatch == nil {
+				continue
+			}
+			message := panicMatch[1]
+			if message == "" && j+1 < len(lines) {
+				message = strings.TrimSpace(lines[j+1])
+			}
+			messages[match[1]] = trimHeadline(panicMatch[2] + ": " + message)
+			break
+		}
+	}
+
+	var tests []TestResult
+	crate := ""
+	for _, line := range lines {
+		if match := reCargoRunningLine.FindStringSubmatch(line); match != nil {
+			crate = match[1]
+			continue
+		}
+		if match := reCargoFailedLine.FindStringSubmatch(line); match != nil {
+			tests = append(tests, TestResult{
+				Runner:  TestRunnerCargo,
+				Package: crate,
+				Name:    match[1],
+				Status:  "fail",
+				Message: messages[match[1]],
+			})
+		}
+	}
+	return tests
+}
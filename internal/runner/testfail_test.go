@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berniemackie97/build-bouncer/internal/config"
+)
+
+func TestExtractFailedTestsByRunner(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   []TestResult
+	}{
+		{
+			name: "go",
+			output: "--- FAIL: TestAdd (0.12s)\n" +
+				"    math_test.go:14: got 3, want 4\n" +
+				"--- FAIL: TestTable (0.00s)\n" +
+				"    --- FAIL: TestTable/neg (0.00s)\n" +
+				"        math_test.go:30: negative\n" +
+				"FAIL\n" +
+				"FAIL\texample.com/app/math\t0.130s\n" +
+				"ok  \texample.com/app/other\t0.010s\n",
+			want: []TestResult{
+				{Runner: "go", Package: "example.com/app/math", Name: "TestAdd", Duration: 120 * time.Millisecond, Message: "math_test.go:14: got 3, want 4"},
+				{Runner: "go", Package: "example.com/app/math", Name: "TestTable"},
+				{Runner: "go", Package: "example.com/app/math", Name: "TestTable/neg", Message: "math_test.go:30: negative"},
+			},
+		},
+		{
+			name: "go verbose",
+			output: "=== RUN   TestAdd\n" +
+				"    math_test.go:14: got 3, want 4\n" +
+				"--- FAIL: TestAdd (0.00s)\n" +
+				"FAIL\texample.com/app/math\t0.004s\n",
+			want: []TestResult{
+				{Runner: "go", Package: "example.com/app/math", Name: "TestAdd", Message: "math_test.go:14: got 3, want 4"},
+			},
+		},
+		{
+			name: "pytest",
+			output: "=========================== short test summary info ============================\n" +
+				"FAILED tests/test_api.py::TestUsers::test_create - assert 500 == 201\n" +
+				"ERROR tests/test_db.py - ModuleNotFoundError: No module named 'psycopg'\n" +
+				"========================= 1 failed, 1 error in 0.52s ==========================\n",
+			want: []TestResult{
+				{Runner: "pytest", Package: "tests/test_api.py", Name: "TestUsers::test_create", Message: "assert 500 == 201"},
+				{Runner: "pytest", Package: "tests/test_db.py", Message: "ModuleNotFoundError: No module named 'psycopg'"},
+			},
+		},
+		{
+			name: "jest",
+			output: "FAIL src/cart.test.js\n" +
+				"  Cart\n" +
+				"    ✓ adds items (3 ms)\n" +
+				"    ✕ applies discounts (7 ms)\n" +
+				"\n" +
+				"  ● Cart › applies discounts\n" +
+				"\n" +
+				"    expect(received).toBe(expected) // Object.is equality\n" +
+				"\n" +
+				"  ● Console\n" +
+				"\n" +
+				"    console.log\n",
+			want: []TestResult{
+				{Runner: "jest", Package: "src/cart.test.js", Name: "Cart › applies discounts", Duration: 7 * time.Millisecond, Message: "expect(received).toBe(expected) // Object.is equality"},
+			},
+		},
+		{
+			name: "dotnet",
+			output: "  Failed App.Tests.CartTests.AppliesDiscount(rate: 0.5) [12 ms]\n" +
+				"  Error Message:\n" +
+				"   Assert.Equal() Failure: Values differ\n" +
+				"  Stack Trace:\n" +
+				"     at App.Tests.CartTests.AppliesDiscount(Double rate)\n" +
+				"Failed!  - Failed:     1, Passed:    10, Skipped:     0, Total:    11\n",
+			want: []TestResult{
+				{Runner: "dotnet", Package: "App.Tests.CartTests", Name: "AppliesDiscount(rate: 0.5)", Duration: 12 * time.Millisecond, Message: "Assert.Equal() Failure: Values differ"},
+			},
+		},
+		{
+			name: "cargo",
+			output: "     Running unittests src/lib.rs (target/debug/deps/shop-1a2b3c4d5e6f7a8b)\n" +
+				"test cart::tests::adds ... ok\n" +
+				"test cart::tests::discounts ... FAILED\n" +
+				"\n" +
+				"failures:\n" +
+				"\n" +
+				"---- cart::tests::discounts stdout ----\n" +
+				"thread 'cart::tests::discounts' panicked at src/cart.rs:42:9:\n" +
+				"assertion `left == right` failed\n",
+			want: []TestResult{
+				{Runner: "cargo", Package: "shop", Name: "cart::tests::discounts", Message: "src/cart.rs:42:9: assertion `left == right` failed"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ExtractFailedTests(tc.output)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d failed tests, got %+v", len(tc.want), got)
+			}
+			for i, want := range tc.want {
+				want.Status = "fail"
+				if got[i] != want {
+					t.Fatalf("test %d:\n got %+v\nwant %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+
+	if got := ExtractFailedTests("ok  \texample.com/app\t0.1s\n"); got != nil {
+		t.Fatalf("expected no failed tests, got %+v", got)
+	}
+}
+
+func TestFailedTestString(t *testing.T) {
+	test := TestResult{Runner: "go", Package: "example.com/app", Name: "TestAdd", Duration: 1234 * time.Microsecond, Message: "got 3"}
+	if got, want := test.String(), "example.com/app.TestAdd (1ms): got 3"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+	pytest := TestResult{Runner: "pytest", Package: "tests/test_a.py", Name: "test_b"}
+	if got, want := pytest.String(), "tests/test_a.py::test_b"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestRerunCommandTemplates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("expectations use POSIX quoting")
+	}
+	cases := []struct {
+		name  string
+		check config.Check
+		tests []TestResult
+		want  string
+	}{
+		{
+			name:  "go runs top-level tests once",
+			check: config.Check{Run: "go test ./..."},
+			tests: []TestResult{{Runner: "go", Name: "TestTable"}, {Runner: "go", Name: "TestTable/neg"}, {Runner: "go", Name: "TestAdd"}},
+			want:  `go test ./... -run '^(TestTable|TestAdd)$'`,
+		},
+		{
+			name:  "pytest node ids",
+			check: config.Check{Run: "pytest -q"},
+			tests: []TestResult{{Runner: "pytest", Package: "tests/test_a.py", Name: "test_it's"}},
+			want:  `pytest -q 'tests/test_a.py::test_it'\''s'`,
+		},
+		{
+			name:  "jest through npx",
+			check: config.Check{Run: "npx jest --ci"},
+			tests: []TestResult{{Runner: "jest", Package: "src/cart.test.js", Name: "Cart › applies (10%) discounts"}},
+			want:  `npx jest --ci -t '^(Cart applies \(10%\) discounts)$'`,
+		},
+		{
+			name:  "dotnet filter",
+			check: config.Check{Run: "dotnet test"},
+			tests: []TestResult{{Runner: "dotnet", Package: "App.CartTests", Name: "Adds"}, {Runner: "dotnet", Package: "App.CartTests", Name: "Discounts"}},
+			want:  `dotnet test --filter 'FullyQualifiedName=App.CartTests.Adds|FullyQualifiedName=App.CartTests.Discounts'`,
+		},
+		{
+			name:  "cargo keeps an existing separator",
+			check: config.Check{Run: "cargo test -- --nocapture"},
+			tests: []TestResult{{Runner: "cargo", Name: "cart::tests::discounts"}},
+			want:  `cargo test -- --nocapture --exact 'cart::tests::discounts'`,
+		},
+		{
+			name:  "cargo adds a separator",
+			check: config.Check{Run: "cargo test"},
+			tests: []TestResult{{Runner: "cargo", Name: "cart::tests::discounts"}},
+			want:  `cargo test -- --exact 'cart::tests::discounts'`,
+		},
+		{
+			name:  "config template",
+			check: config.Check{Run: "make test", Rerun: "make test TESTS={tests}"},
+			tests: []TestResult{{Runner: "junit", Package: "suite", Name: "adds"}},
+			want:  `make test TESTS='adds'`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := RerunCommand(tc.check, tc.tests)
+			if err != nil {
+				t.Fatalf("RerunCommand: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := RerunCommand(config.Check{Name: "junit", Run: "make test"}, []TestResult{{Runner: "junit", Name: "adds"}}); err == nil {
+		t.Fatalf("expected an error for junit tests without a rerun template")
+	}
+	for _, check := range []config.Check{
+		{Name: "npm", Run: "npm test"},
+		{Name: "make", Run: "make test"},
+		{Name: "script", Run: "sh run_tests.sh"},
+	} {
+		runnerName := TestRunnerJest
+		if check.Name != "npm" {
+			runnerName = TestRunnerGo
+		}
+		_, err := RerunCommand(check, []TestResult{{Runner: runnerName, Name: "TestAdd"}})
+		if err == nil || !strings.Contains(err.Error(), "set rerun:") {
+			t.Fatalf("%s: expected a built-in template to be refused for %q, got %v", check.Name, check.Run, err)
+		}
+	}
+}
+
+func TestReportFileRoundTrip(t *testing.T) {
+	report := Report{
+		Failures:           []string{"go:test"},
+		FailureHeadlines:   map[string]string{"go:test": "Test failed: TestAdd"},
+		LogFiles:           map[string]string{"go:test": "/tmp/go_test.log"},
+		FailedTests:        map[string][]TestResult{"go:test": {{Runner: "go", Name: "TestAdd", Status: "fail", Duration: time.Second}}},
+		FailureDiagnostics: map[string][]Diagnostic{},
+		Skipped:            []string{"lint"},
+		SkipReasons:        map[string]string{"lint": "no changes"},
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteReportFile(path, report); err != nil {
+		t.Fatalf("WriteReportFile: %v", err)
+	}
+	file, err := ReadReportFile(path)
+	if err != nil {
+		t.Fatalf("ReadReportFile: %v", err)
+	}
+	failure, ok := file.Failure("go:test")
+	if file.Passed || !ok || failure.Headline != "Test failed: TestAdd" || failure.Log != "/tmp/go_test.log" {
+		t.Fatalf("unexpected report %+v", file)
+	}
+	if len(failure.FailedTests) != 1 || failure.FailedTests[0] != report.FailedTests["go:test"][0] {
+		t.Fatalf("failed tests did not round-trip: %+v", failure.FailedTests)
+	}
+	if len(file.Skipped) != 1 || file.Skipped[0] != (CheckSkip{Check: "lint", Reason: "no changes"}) {
+		t.Fatalf("skipped did not round-trip: %+v", file.Skipped)
+	}
+}
@@ -0,0 +1,196 @@
+// Package textdiff renders line-based unified diffs for showing config changes before
+// they are written.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff turning a into b, labelled with the given file names.
+// It returns "" when the texts are identical.
+func Unified(fromName string, toName string, a string, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = DefaultContext
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops, context) {
+		writeHunk(&out, ops, h)
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes an edit script from the longest common subsequence of lines.
+// Config files are small, so the quadratic table is fine.
+func diffLines(a []string, b []string) []op {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{kind: opDelete, line: a[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{kind: opDelete, line: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{kind: opInsert, line: b[j]})
+	}
+	return ops
+}
+
+type hunk struct {
+	start, end int // op range [start, end)
+}
+
+// hunks groups changed ops with their surrounding context, merging groups whose
+// context overlaps.
+func hunks(ops []op, context int) []hunk {
+	var out []hunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run < len(ops) && run-end <= 2*context {
+				end = run
+				continue
+			}
+			break
+		}
+		i = end - 1
+		end += context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if len(out) > 0 && start <= out[len(out)-1].end {
+			out[len(out)-1].end = end
+			continue
+		}
+		out = append(out, hunk{start: start, end: end})
+	}
+	return out
+}
+
+func writeHunk(out *strings.Builder, ops []op, h hunk) {
+	// Line numbers are 1-based positions in a and b where the hunk starts.
+	aLine, bLine := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != opInsert {
+			aLine++
+		}
+		if o.kind != opDelete {
+			bLine++
+		}
+	}
+	aCount, bCount := 0, 0
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+	if aCount == 0 {
+		aLine--
+	}
+	if bCount == 0 {
+		bLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+	for _, o := range ops[h.start:h.end] {
+		prefix := " "
+		switch o.kind {
+		case opDelete:
+			prefix = "-"
+		case opInsert:
+			prefix = "+"
+		}
+		out.WriteString(prefix)
+		out.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
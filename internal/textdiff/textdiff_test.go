@@ -0,0 +1,56 @@
+package textdiff
+
+import "testing"
+
+func TestUnifiedIdentical(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny\n", DefaultContext); got != "" {
+		t.Fatalf("expected no diff, got %q", got)
+	}
+}
+
+func TestUnifiedSingleHunk(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+	b := "one\ntwo\nthree\nFOUR\nfive\nsix\nseven\neight\n"
+	want := `--- config.yaml
++++ config.yaml (ci sync)
+@@ -1,7 +1,8 @@
+ one
+ two
+ three
+-four
++FOUR
+ five
+ six
+ seven
++eight
+`
+	if got := Unified("config.yaml", "config.yaml (ci sync)", a, b, DefaultContext); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+	want := `--- a
++++ b
+@@ -1,2 +1,2 @@
+-a
++A
+ b
+@@ -9,2 +9,2 @@
+ i
+-j
++J
+`
+	if got := Unified("a", "b", a, b, 1); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny\n", DefaultContext); got != want {
+		t.Fatalf("unexpected diff:\n%q\nwant:\n%q", got, want)
+	}
+}